@@ -18,6 +18,8 @@ export interface LoginRes {
     ip: string
     login_time: string
     role: RoleType
+    token: string
+    expiresAt: string
   }
   msg: string
 }
@@ -100,6 +102,8 @@ export function pollQrStatus(qrId: string) {
 
 export interface QrAuthRes {
   token: string
+  expiresAt: string
+  accountId: string
   role: RoleType
 }
 
@@ -56,17 +56,16 @@ const useUserStore = defineStore('user', {
       this.setInfo(res.data)
     },
 
-    async loginToken(token: string, role: RoleType) {
+    async loginToken(token: string, role: RoleType, accountId?: string) {
       try {
         // 后端已改为 string 类型，不必再用 String() 转换
-        this.accountId = token
+        this.accountId = accountId || token
         this.role = role || ('user' as any as typeof this.role)
         console.info('[login] 设置角色为:', this.role)
 
-        // 将 accountId 作为 token 存储，确保 isLogin() 返回 true
+        // 存储后端签发的会话令牌，确保 isLogin() 返回 true
         const { setToken } = await import('@/utils/auth')
         setToken(token)
-        console.info('[login] 已将 accountId 作为 token 存储:', token)
       } catch (err) {
         clearToken()
         throw err
@@ -89,10 +88,9 @@ const useUserStore = defineStore('user', {
           console.warn('[login] API未返回角色，已设置默认角色为 user')
         }
 
-        // 将 accountId 作为 token 存储，确保 isLogin() 返回 true
+        // 存储后端签发的会话令牌，确保 isLogin() 返回 true
         const { setToken } = await import('@/utils/auth')
-        setToken(res.data.id)
-        console.info('[login] 已将 accountId 作为 token 存储:', res.data.id)
+        setToken(res.data.token)
       } catch (err) {
         clearToken()
         throw err
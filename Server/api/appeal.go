@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// errAppealExists 同一轮驳回只能申诉一次
+var errAppealExists = errors.New("该材料本轮的驳回已提出过申诉")
+
+// errAppealDecided 申诉已处理
+var errAppealDecided = errors.New("该申诉已处理")
+
+// errOriginalReviewer 申诉由独立的审核人处理，参与过原审核的人不能处理
+var errOriginalReviewer = errors.New("参与过该材料审核的人员不能处理其申诉")
+
+// appealResponse 申诉的展示字段，files 以数组返回
+func appealResponse(a store.Appeal) map[string]interface{} {
+	files := json.RawMessage("[]")
+	if a.Files != "" && a.Files != "null" {
+		files = json.RawMessage(a.Files)
+	}
+	return map[string]interface{}{
+		"id":         a.ID,
+		"materialId": a.MaterialId,
+		"round":      a.Round,
+		"appellant":  a.Appellant,
+		"statement":  a.Statement,
+		"files":      files,
+		"status":     a.Status,
+		"createdAt":  a.CreatedAt,
+		"decidedBy":  a.DecidedBy,
+		"decidedAt":  a.DecidedAt,
+		"comment":    a.Comment,
+	}
+}
+
+// writeAppealError 在 writeWorkflowError 之外处理申诉特有的错误
+func writeAppealError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errAppealExists), errors.Is(err, errAppealDecided):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errOriginalReviewer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		writeWorkflowError(w, err)
+	}
+}
+
+// appealReviewers 返回处理申诉的人员：申诉审核人，尚未设置时由管理员处理
+func appealReviewers(ctx context.Context, tx *store.Store) ([]store.User, error) {
+	users, err := tx.Users.ListByRole(ctx, roleAppealReviewer)
+	if err != nil || len(users) > 0 {
+		return users, err
+	}
+	return tx.Users.ListByRole(ctx, roleAdmin)
+}
+
+// FileAppealHandler 上传者对被驳回的材料提出申诉，附申诉理由与补充材料
+// 材料进入 appealed 状态，等待申诉审核人处理
+func (s *Server) FileAppealHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MaterialId string   `json:"materialId"`
+		Statement  string   `json:"statement"`
+		Files      []string `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+	req.Statement = strings.TrimSpace(req.Statement)
+	if req.Statement == "" {
+		http.Error(w, "申诉理由不能为空", http.StatusBadRequest)
+		return
+	}
+
+	appeal := &store.Appeal{
+		MaterialId: req.MaterialId,
+		Appellant:  principal.AccountId,
+		Statement:  req.Statement,
+		Files:      s.filesJSON(req.Files),
+	}
+	var state workflow.State
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(r.Context(), req.MaterialId)
+		if err != nil {
+			return err
+		}
+		if m.Uploader != principal.AccountId {
+			return errNotUploader
+		}
+		existing, err := tx.Appeals.ListByMaterial(r.Context(), m.ID)
+		if err != nil {
+			return err
+		}
+		for _, a := range existing {
+			if a.Round == m.ReviewRound {
+				return errAppealExists
+			}
+		}
+		if state, err = s.applyAction(r.Context(), tx, m, workflow.ActionAppeal, principal.AccountId, req.Statement); err != nil {
+			return err
+		}
+		appeal.Round = m.ReviewRound
+		if err := tx.Appeals.Create(r.Context(), appeal); err != nil {
+			return err
+		}
+
+		reviewers, err := appealReviewers(r.Context(), tx)
+		if err != nil {
+			return err
+		}
+		for _, u := range reviewers {
+			notify(r.Context(), tx, store.Message{
+				AccountId: u.AccountId, Type: "todo", Title: "新的申诉", SubTitle: m.Title, MessageType: messageInProgress,
+				Content: fmt.Sprintf("材料《%s》的上传者对第 %d 轮驳回提出申诉，请处理", m.Title, m.ReviewRound),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		writeAppealError(w, err)
+		return
+	}
+
+	data := appealResponse(*appeal)
+	data["state"] = state
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// ListAppealsHandler 申诉队列，附材料信息与被驳回轮次的审核意见
+// status 默认为 pending，传 all 返回全部申诉
+func (s *Server) ListAppealsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = store.AppealPending
+	case "all":
+		status = ""
+	case store.AppealPending, store.AppealReopened, store.AppealUpheld:
+	default:
+		http.Error(w, "status 必须为 pending、reopened、upheld 或 all", http.StatusBadRequest)
+		return
+	}
+
+	appeals, err := s.store.Appeals.ListByStatus(r.Context(), status)
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(appeals))
+	for _, a := range appeals {
+		m, err := s.store.Materials.Get(r.Context(), a.MaterialId)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		} else if err != nil {
+			http.Error(w, "Query material error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reviews, err := s.store.MaterialReviews.ListByMaterial(r.Context(), a.MaterialId)
+		if err != nil {
+			http.Error(w, "Query reviews error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rejected := make([]store.MaterialReview, 0)
+		for _, rv := range reviews {
+			if rv.Round == a.Round {
+				rejected = append(rejected, rv)
+			}
+		}
+
+		item := appealResponse(a)
+		item["material"] = materialResponse(*m)
+		item["reviews"] = rejected
+		data = append(data, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// MaterialAppealsHandler 返回材料的全部申诉记录
+// 普通用户只能查看自己上传的材料
+func (s *Server) MaterialAppealsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		http.Error(w, "Missing material ID", http.StatusBadRequest)
+		return
+	}
+	m, err := s.store.Materials.Get(r.Context(), id)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	if principal.Role == roleUser && m.Uploader != principal.AccountId {
+		http.Error(w, "Forbidden: not the uploader of this material", http.StatusForbidden)
+		return
+	}
+
+	appeals, err := s.store.Appeals.ListByMaterial(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := make([]map[string]interface{}, 0, len(appeals))
+	for _, a := range appeals {
+		data = append(data, appealResponse(a))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// DecideAppealHandler 处理申诉：reopen 申诉成立，材料进入新一轮复审；uphold 维持驳回
+// 处理人不能是材料的任一轮审核人，也不能与上传者存在利益冲突
+func (s *Server) DecideAppealHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AppealId int64  `json:"appealId"`
+		Decision string `json:"decision"`
+		Comment  string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AppealId == 0 {
+		http.Error(w, "Missing appealId", http.StatusBadRequest)
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	var action workflow.Action
+	var status string
+	switch strings.ToLower(strings.TrimSpace(req.Decision)) {
+	case string(workflow.ActionReopen):
+		action, status = workflow.ActionReopen, store.AppealReopened
+	case string(workflow.ActionUphold):
+		if req.Comment == "" {
+			http.Error(w, "维持驳回时必须填写处理意见", http.StatusBadRequest)
+			return
+		}
+		action, status = workflow.ActionUphold, store.AppealUpheld
+	default:
+		http.Error(w, "decision 必须为 reopen 或 uphold", http.StatusBadRequest)
+		return
+	}
+
+	var appeal *store.Appeal
+	var state workflow.State
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		a, err := tx.Appeals.Get(r.Context(), req.AppealId)
+		if err != nil {
+			return err
+		}
+		appeal = a
+		if appeal.Status != store.AppealPending {
+			return errAppealDecided
+		}
+		m, err := tx.Materials.GetForUpdate(r.Context(), appeal.MaterialId)
+		if err != nil {
+			return err
+		}
+		if err := checkConflict(r.Context(), tx, principal.AccountId, m.Uploader); err != nil {
+			return err
+		}
+		reviews, err := tx.MaterialReviews.ListByMaterial(r.Context(), m.ID)
+		if err != nil {
+			return err
+		}
+		for _, rv := range reviews {
+			if rv.Reviewer == principal.AccountId {
+				return errOriginalReviewer
+			}
+		}
+
+		if state, err = s.applyAction(r.Context(), tx, m, action, principal.AccountId, req.Comment); err != nil {
+			return err
+		}
+		if err := tx.Appeals.Decide(r.Context(), appeal.ID, status, principal.AccountId, req.Comment); err != nil {
+			return err
+		}
+		appeal.Status, appeal.DecidedBy, appeal.DecidedAt, appeal.Comment = status, principal.AccountId, store.Now(), req.Comment
+
+		outcome := "申诉不成立，维持驳回：" + req.Comment
+		if action == workflow.ActionReopen {
+			outcome = fmt.Sprintf("申诉成立，材料已进入第 %d 轮复审", m.ReviewRound)
+		}
+		notify(r.Context(), tx, store.Message{
+			AccountId: appeal.Appellant, Type: "notice", Title: "申诉处理结果", SubTitle: m.Title, MessageType: messagePlain,
+			Content: fmt.Sprintf("你对材料《%s》的申诉已处理：%s", m.Title, outcome),
+		})
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) && appeal == nil {
+		http.Error(w, "Appeal not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeAppealError(w, err)
+		return
+	}
+
+	data := appealResponse(*appeal)
+	data["state"] = state
+	data["materialStatus"] = state.PublicStatus()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// RegisterAppealRoutes 注册申诉提交、申诉队列与申诉处理路由
+func (s *Server) RegisterAppealRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/appeal", s.FileAppealHandler)
+	mux.HandleFunc("/api/material/appeals", s.MaterialAppealsHandler)
+	mux.HandleFunc("/api/appeal/list", s.ListAppealsHandler)
+	mux.HandleFunc("/api/appeal/decide", s.DecideAppealHandler)
+}
@@ -0,0 +1,564 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// errNotAssigned 材料已分配审核人，当前审核人不在其中
+var errNotAssigned = errors.New("该材料未分配给你审核")
+
+// errConflictOfInterest 审核人与材料上传者存在利益冲突
+var errConflictOfInterest = errors.New("利益冲突")
+
+// errNotAssignable 指定的改派对象不能接收该分配
+var errNotAssignable = errors.New("不能改派给该用户")
+
+// panelSize 每轮需要分配的审核人数：取配置值与审核规则所需票数中的较大者
+func (s *Server) panelSize(policy workflow.Policy) int {
+	if n := policy.Panel(); n > s.cfg.Review.ReviewersPerMaterial {
+		return n
+	}
+	return s.cfg.Review.ReviewersPerMaterial
+}
+
+// checkConflict 检查审核人与材料上传者是否存在利益冲突，存在时返回包装 errConflictOfInterest 的错误
+func checkConflict(ctx context.Context, tx *store.Store, reviewer, uploader string) error {
+	classes, err := tx.Students.Classes(ctx, []string{reviewer, uploader})
+	if err != nil {
+		return err
+	}
+	if reason := workflow.ConflictOfInterest(reviewer, classes[reviewer], uploader, classes[uploader]); reason != "" {
+		return fmt.Errorf("%w: %s", errConflictOfInterest, reason)
+	}
+	return nil
+}
+
+// reviewCandidates 返回可以审核该材料的审核人：角色为审核人、未暂停接收分配、与上传者无利益冲突且不在 excluded 中
+func reviewCandidates(ctx context.Context, tx *store.Store, m *store.Material, excluded map[string]bool) ([]workflow.Candidate, error) {
+	users, err := tx.Users.ListByRole(ctx, roleReviewer)
+	if err != nil {
+		return nil, err
+	}
+	unavailable, err := tx.Assignments.Unavailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	load, err := tx.Assignments.ActiveLoad(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{m.Uploader}
+	for _, u := range users {
+		ids = append(ids, u.AccountId)
+	}
+	classes, err := tx.Students.Classes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]workflow.Candidate, 0, len(users))
+	for _, u := range users {
+		if u.AccountId == "" || excluded[u.AccountId] {
+			continue
+		}
+		if _, paused := unavailable[u.AccountId]; paused {
+			continue
+		}
+		if workflow.ConflictOfInterest(u.AccountId, classes[u.AccountId], m.Uploader, classes[m.Uploader]) != "" {
+			continue
+		}
+		candidates = append(candidates, workflow.Candidate{AccountId: u.AccountId, Load: load[u.AccountId]})
+	}
+	return candidates, nil
+}
+
+// assignReviewers 为材料当前轮次补足待处理的分配，返回新分配的审核人
+// 本轮已分配过的审核人（含已收回的）不会再次被自动选中；候选人不足时尽量分配并记录日志
+// 必须在事务中调用
+func (s *Server) assignReviewers(ctx context.Context, tx *store.Store, m *store.Material, actor string) ([]string, error) {
+	policy, err := resolveReviewPolicy(ctx, tx, m.Category)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := tx.Assignments.ListByMaterial(ctx, m.ID, m.ReviewRound)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool)
+	open := 0
+	for _, a := range assignments {
+		excluded[a.Reviewer] = true
+		if a.Status != store.AssignmentReleased {
+			open++
+		}
+	}
+	need := s.panelSize(policy) - open
+	if need <= 0 {
+		return nil, nil
+	}
+
+	picked, err := s.pickAndAssign(ctx, tx, m, excluded, need, actor)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked) < need {
+		log.Printf("[审核分配] 材料 %s 第 %d 轮还需 %d 名审核人，仅有 %d 名可分配", m.ID, m.ReviewRound, need, len(picked))
+	}
+	return picked, nil
+}
+
+// addReviewer 在本轮已分配的审核人之外追加一名审核人，没有可分配的审核人时返回空字符串
+// 必须在事务中调用
+func (s *Server) addReviewer(ctx context.Context, tx *store.Store, m *store.Material, actor string) (string, error) {
+	assignments, err := tx.Assignments.ListByMaterial(ctx, m.ID, m.ReviewRound)
+	if err != nil {
+		return "", err
+	}
+	excluded := make(map[string]bool)
+	for _, a := range assignments {
+		excluded[a.Reviewer] = true
+	}
+	picked, err := s.pickAndAssign(ctx, tx, m, excluded, 1, actor)
+	if err != nil || len(picked) == 0 {
+		return "", err
+	}
+	return picked[0], nil
+}
+
+// pickAndAssign 按分配策略从 excluded 之外的候选人中选出至多 n 人并写入分配
+func (s *Server) pickAndAssign(ctx context.Context, tx *store.Store, m *store.Material, excluded map[string]bool, n int, actor string) ([]string, error) {
+	candidates, err := reviewCandidates(ctx, tx, m, excluded)
+	if err != nil {
+		return nil, err
+	}
+	last, err := tx.Assignments.LastAssigned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	picked := workflow.PickReviewers(workflow.Strategy(s.cfg.Review.AssignmentStrategy), candidates, last, n)
+	for _, reviewer := range picked {
+		err := tx.Assignments.Create(ctx, &store.ReviewAssignment{
+			MaterialId: m.ID,
+			Round:      m.ReviewRound,
+			Reviewer:   reviewer,
+			AssignedBy: actor,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return picked, nil
+}
+
+// completeAssignment 审核人给出结论时结束其分配
+// 材料已分配审核人时只有被分配者可以给出结论；材料尚无分配时补记一条已完成的分配
+func completeAssignment(ctx context.Context, tx *store.Store, m *store.Material, reviewer string) error {
+	err := tx.Assignments.Complete(ctx, m.ID, m.ReviewRound, reviewer)
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	assignments, err := tx.Assignments.ListByMaterial(ctx, m.ID, m.ReviewRound)
+	if err != nil {
+		return err
+	}
+	for _, a := range assignments {
+		if a.Status != store.AssignmentReleased {
+			return errNotAssigned
+		}
+	}
+	return tx.Assignments.Create(ctx, &store.ReviewAssignment{
+		MaterialId: m.ID,
+		Round:      m.ReviewRound,
+		Reviewer:   reviewer,
+		Status:     store.AssignmentDone,
+		AssignedBy: reviewer,
+		ClosedAt:   store.Now(),
+	})
+}
+
+// AssignBacklog 为处于审核中但审核人不足的材料补充分配，返回新增的分配数
+func (s *Server) AssignBacklog(ctx context.Context, actor string) (int, error) {
+	materials, err := s.store.Materials.List(ctx, store.MaterialFilter{Statuses: reviewableStatuses})
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, m := range materials {
+		err := s.store.InTx(ctx, func(tx *store.Store) error {
+			locked, err := tx.Materials.GetForUpdate(ctx, m.ID)
+			if err != nil {
+				return err
+			}
+			if !workflow.State(locked.Status).InReview() {
+				return nil
+			}
+			picked, err := s.assignReviewers(ctx, tx, locked, actor)
+			total += len(picked)
+			return err
+		})
+		if err != nil {
+			return total, fmt.Errorf("分配材料 %s 失败: %w", m.ID, err)
+		}
+	}
+	return total, nil
+}
+
+// StartAssignBacklog 在后台执行一次 AssignBacklog，服务启动时调用，为升级前的待审材料分配审核人
+func (s *Server) StartAssignBacklog() {
+	s.goBackground("assign-backlog", func(ctx context.Context) {
+		n, err := s.AssignBacklog(ctx, "system")
+		if err != nil {
+			log.Printf("[审核分配] 补充分配失败: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("[审核分配] 已为待审材料补充 %d 条分配", n)
+		}
+	})
+}
+
+// reassign 收回一条待处理的分配并改派：指定 to 时改派给该用户，否则由分配策略选人
+// 返回新的审核人，没有可分配的审核人时为空字符串
+func (s *Server) reassign(ctx context.Context, tx *store.Store, a *store.ReviewAssignment, to, actor, reason string) (string, error) {
+	if err := tx.Assignments.Release(ctx, a.ID, reason); err != nil {
+		return "", err
+	}
+	m, err := tx.Materials.GetForUpdate(ctx, a.MaterialId)
+	if err != nil {
+		return "", err
+	}
+	if !workflow.State(m.Status).InReview() || m.ReviewRound != a.Round {
+		return "", nil
+	}
+
+	if to == "" {
+		picked, err := s.assignReviewers(ctx, tx, m, actor)
+		if err != nil || len(picked) == 0 {
+			return "", err
+		}
+		return picked[0], nil
+	}
+
+	u, err := tx.Users.GetByAccountId(ctx, to)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && u.Role != roleReviewer && u.Role != roleAdmin) {
+		return "", fmt.Errorf("%w: %s 不是审核人", errNotAssignable, to)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := checkConflict(ctx, tx, to, m.Uploader); err != nil {
+		return "", err
+	}
+	assignments, err := tx.Assignments.ListByMaterial(ctx, m.ID, m.ReviewRound)
+	if err != nil {
+		return "", err
+	}
+	for _, other := range assignments {
+		if other.Reviewer == to && other.Status != store.AssignmentReleased {
+			return "", fmt.Errorf("%w: %s 本轮已有该材料的审核任务", errNotAssignable, to)
+		}
+	}
+	err = tx.Assignments.Create(ctx, &store.ReviewAssignment{
+		MaterialId: m.ID,
+		Round:      m.ReviewRound,
+		Reviewer:   to,
+		AssignedBy: actor,
+	})
+	return to, err
+}
+
+// MyQueueHandler 返回分配给当前审核人且尚未处理的材料，按分配先后排列
+func (s *Server) MyQueueHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	assignments, err := s.store.Assignments.ListActiveByReviewer(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Query assignments error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	materials, err := s.store.Materials.List(r.Context(), store.MaterialFilter{Statuses: reviewableStatuses, AssignedTo: principal.AccountId})
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byID := make(map[string]store.Material, len(materials))
+	for _, m := range materials {
+		byID[m.ID] = m
+	}
+	deadline, err := loadReviewDeadline(r.Context(), s.store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	data := make([]map[string]interface{}, 0, len(assignments))
+	for _, a := range assignments {
+		m, ok := byID[a.MaterialId]
+		if !ok || m.ReviewRound != a.Round {
+			continue
+		}
+		item := materialResponse(m)
+		item["assignmentId"] = a.ID
+		item["assignedAt"] = a.AssignedAt
+		if assigned, err := store.ParseTime(a.AssignedAt); err == nil {
+			for k, v := range dueInfo(deadline.due(assigned), now) {
+				item[k] = v
+			}
+		}
+		data = append(data, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// ListAssignmentsHandler 返回材料当前轮次的全部分配，含已完成与已收回的
+func (s *Server) ListAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimSpace(r.URL.Query().Get("materialId"))
+	if id == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+	m, err := s.store.Materials.Get(r.Context(), id)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	assignments, err := s.store.Assignments.ListByMaterial(r.Context(), m.ID, m.ReviewRound)
+	if err != nil {
+		http.Error(w, "Query assignments error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": assignments,
+	})
+}
+
+// ReassignHandler 将一条待处理的分配改派给指定审核人，未指定时按分配策略自动选人
+func (s *Server) ReassignHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AssignmentId int64  `json:"assignmentId"`
+		To           string `json:"to"`
+		Reason       string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AssignmentId == 0 {
+		http.Error(w, "Missing assignmentId", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		req.Reason = "管理员改派"
+	}
+
+	var to string
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		a, err := tx.Assignments.Get(r.Context(), req.AssignmentId)
+		if err != nil {
+			return err
+		}
+		to, err = s.reassign(r.Context(), tx, a, strings.TrimSpace(req.To), principal.AccountId, req.Reason)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Assignment not found or already closed", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"assignmentId": req.AssignmentId,
+			"reviewer":     to,
+		},
+	})
+}
+
+// SetAvailabilityHandler 设置审核人是否接收分配；暂停时将其待处理的分配全部改派
+func (s *Server) SetAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AccountId string `json:"accountId"`
+		Available bool   `json:"available"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.AccountId) == "" {
+		http.Error(w, "Missing accountId", http.StatusBadRequest)
+		return
+	}
+
+	reassigned := make([]map[string]interface{}, 0)
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		err := tx.Assignments.SetAvailability(r.Context(), &store.ReviewerAvailability{
+			AccountId: req.AccountId,
+			Available: req.Available,
+			Reason:    req.Reason,
+			UpdatedBy: principal.AccountId,
+		})
+		if err != nil || req.Available {
+			return err
+		}
+
+		active, err := tx.Assignments.ListActiveByReviewer(r.Context(), req.AccountId)
+		if err != nil {
+			return err
+		}
+		for i := range active {
+			to, err := s.reassign(r.Context(), tx, &active[i], "", principal.AccountId, "审核人暂停审核")
+			if err != nil {
+				return err
+			}
+			reassigned = append(reassigned, map[string]interface{}{"materialId": active[i].MaterialId, "reviewer": to})
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Update availability error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"accountId":  req.AccountId,
+			"available":  req.Available,
+			"reassigned": reassigned,
+		},
+	})
+}
+
+// WorkloadHandler 返回各审核人待处理的分配数与可用状态
+func (s *Server) WorkloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	users, err := s.store.Users.ListByRole(r.Context(), roleReviewer)
+	if err != nil {
+		http.Error(w, "Query users error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	load, err := s.store.Assignments.ActiveLoad(r.Context())
+	if err != nil {
+		http.Error(w, "Query load error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unavailable, err := s.store.Assignments.Unavailable(r.Context())
+	if err != nil {
+		http.Error(w, "Query availability error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		reason, paused := unavailable[u.AccountId]
+		data = append(data, map[string]interface{}{
+			"accountId": u.AccountId,
+			"name":      u.Name,
+			"active":    load[u.AccountId],
+			"available": !paused,
+			"reason":    reason,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// RunAssignmentHandler 为审核人不足的待审材料补充分配，用于新增审核人或恢复审核人后
+func (s *Server) RunAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := s.AssignBacklog(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Assign error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"assigned": n},
+	})
+}
+
+// RegisterAssignmentRoutes 注册审核分配路由
+func (s *Server) RegisterAssignmentRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/my-queue", s.MyQueueHandler)
+	mux.HandleFunc("/api/assignment/list", s.ListAssignmentsHandler)
+	mux.HandleFunc("/api/assignment/reassign", s.ReassignHandler)
+	mux.HandleFunc("/api/assignment/availability", s.SetAvailabilityHandler)
+	mux.HandleFunc("/api/assignment/workload", s.WorkloadHandler)
+	mux.HandleFunc("/api/assignment/run", s.RunAssignmentHandler)
+}
@@ -1,14 +1,12 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
-	"math"
 	"net/http"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/scoring"
 )
 
 const (
@@ -16,8 +14,6 @@ const (
 	bonusTypeComprehensive     = "comprehensive"
 	bonusCategoryAcademic      = "学术专长"
 	bonusCategoryComprehensive = "综合素质"
-	academicScoreCap           = 15.0
-	comprehensiveScoreCap      = 5.0
 )
 
 type BonusRecord struct {
@@ -38,31 +34,45 @@ type BonusSummaryItem struct {
 	ItemCount  int     `json:"itemCount"`
 }
 
+// BonusSummaryRecord 一条加分记录在汇总中的计入情况
+// status 为 counted（全额计入）、capped（受累计上限限制）或 excluded（按条例不计入），reason 说明原因
+type BonusSummaryRecord struct {
+	MaterialId   string  `json:"materialId"`
+	Project      string  `json:"project"`
+	Category     string  `json:"category"`
+	Kind         string  `json:"kind"`
+	CollegeScore float64 `json:"collegeScore"`
+	CountedScore float64 `json:"countedScore"`
+	Status       string  `json:"status"`
+	Reason       string  `json:"reason"`
+}
+
 type BonusSummaryResponse struct {
-	TotalScore float64            `json:"totalScore"`
-	Items      []BonusSummaryItem `json:"items"`
+	TotalScore float64              `json:"totalScore"`
+	Items      []BonusSummaryItem   `json:"items"`
+	Records    []BonusSummaryRecord `json:"records"`
 }
 
-func RegisterBonusRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/bonus/academic/list", bonusListHandler(bonusTypeAcademic))
-	mux.HandleFunc("/api/bonus/comprehensive/list", bonusListHandler(bonusTypeComprehensive))
-	mux.HandleFunc("/api/bonus/summary", bonusSummaryHandler)
+func (s *Server) RegisterBonusRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/bonus/academic/list", s.bonusListHandler(bonusTypeAcademic))
+	mux.HandleFunc("/api/bonus/comprehensive/list", s.bonusListHandler(bonusTypeComprehensive))
+	mux.HandleFunc("/api/bonus/summary", s.bonusSummaryHandler)
 }
 
-func bonusListHandler(targetType string) http.HandlerFunc {
+func (s *Server) bonusListHandler(targetType string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		accountID := strings.TrimSpace(r.URL.Query().Get("accountId"))
-		if accountID == "" {
-			http.Error(w, "accountId is required", http.StatusBadRequest)
+		principal, ok := requirePrincipal(w, r)
+		if !ok {
 			return
 		}
+		accountID := principal.AccountId
 
-		records, err := queryBonusRecords(accountID)
+		records, err := s.store.MaterialRecords.ListByAccount(r.Context(), accountID)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("query records failed: %v", err), http.StatusInternalServerError)
 			return
@@ -85,59 +95,30 @@ func bonusListHandler(targetType string) http.HandlerFunc {
 	}
 }
 
-func bonusSummaryHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) bonusSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	accountID := strings.TrimSpace(r.URL.Query().Get("accountId"))
-	if accountID == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
+	accountID := principal.AccountId
 
-	records, err := queryBonusRecords(accountID)
+	records, err := s.store.MaterialRecords.ListByAccount(r.Context(), accountID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("query records failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	academicScore := 0.0
-	comprehensiveScore := 0.0
-	academicCount := 0
-	comprehensiveCount := 0
-
-	for _, rec := range records {
-		normalized := normalizeBonusType(rec.Type)
-		switch normalized {
-		case bonusTypeAcademic:
-			academicScore += rec.CollegeScore
-			academicCount++
-		case bonusTypeComprehensive:
-			comprehensiveScore += rec.CollegeScore
-			comprehensiveCount++
-		}
-	}
-
-	cappedAcademic := math.Min(academicScore, academicScoreCap)
-	cappedComprehensive := math.Min(comprehensiveScore, comprehensiveScoreCap)
-
-	summary := BonusSummaryResponse{
-		TotalScore: cappedAcademic + cappedComprehensive,
-		Items: []BonusSummaryItem{
-			{
-				Category:   bonusCategoryAcademic,
-				TotalScore: cappedAcademic,
-				ItemCount:  academicCount,
-			},
-			{
-				Category:   bonusCategoryComprehensive,
-				TotalScore: cappedComprehensive,
-				ItemCount:  comprehensiveCount,
-			},
-		},
+	reg, err := s.regulationFor(r.Context(), accountID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("resolve regulation failed: %v", err), http.StatusInternalServerError)
+		return
 	}
+	summary := s.summarizeBonus(records, reg)
 
 	writeJSON(w, map[string]interface{}{
 		"code":   0,
@@ -147,69 +128,58 @@ func bonusSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func queryBonusRecords(accountID string) ([]MaterialRecord, error) {
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	if err := ensureMaterialRecordsTable(db); err != nil {
-		return nil, err
-	}
-
-	rows, err := db.Query(`SELECT materialId, accountId, IFNULL(type, ''), IFNULL(category, ''), IFNULL(id, ''), IFNULL(project, ''), IFNULL(awardDate, ''), IFNULL(awardType, ''), IFNULL(teamRank, ''), IFNULL(selfScore, 0), IFNULL(scoreBasis, ''), IFNULL(collegeScore, 0) FROM material_records WHERE accountId = ?`, accountID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	records := make([]MaterialRecord, 0)
-	for rows.Next() {
-		var rec MaterialRecord
-		if err := rows.Scan(
-			&rec.MaterialId,
-			&rec.AccountId,
-			&rec.Type,
-			&rec.Category,
-			&rec.Id,
-			&rec.Project,
-			&rec.AwardDate,
-			&rec.AwardType,
-			&rec.TeamRank,
-			&rec.SelfScore,
-			&rec.ScoreBasis,
-			&rec.CollegeScore,
-		); err != nil {
-			return nil, err
+// bonusTotals 按学生适用条例的项数限制、取最高规则与各级上限汇总学生的加分记录
+func (s *Server) bonusTotals(records []MaterialRecord, reg *regulation) scoring.Summary {
+	items := make([]scoring.Item, 0, len(records))
+	for i := range records {
+		rec := &records[i]
+		section := normalizeBonusType(rec.Category)
+		if section != bonusTypeAcademic && section != bonusTypeComprehensive {
+			section = normalizeBonusType(rec.Type)
 		}
-		records = append(records, rec)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
+		items = append(items, scoring.Item{
+			ID:        rec.MaterialId,
+			Section:   section,
+			Facts:     recordFacts(rec),
+			Points:    rec.CollegeScore,
+			AwardDate: rec.AwardDate,
+		})
 	}
-
-	return records, nil
+	return reg.rules.Summarize(items, s.cfg.Score.AcademicCap, s.cfg.Score.ComprehensiveCap)
 }
 
-func ensureMaterialRecordsTable(db *sql.DB) error {
-	const createTableSQL = `CREATE TABLE IF NOT EXISTS material_records (
-        materialId TEXT PRIMARY KEY,
-        accountId TEXT,
-        type TEXT,
-        category TEXT,
-        id TEXT,
-        project TEXT,
-        awardDate TEXT,
-        awardType TEXT,
-        teamRank TEXT,
-        selfScore REAL,
-        scoreBasis TEXT,
-        collegeScore REAL
-    )`
-	_, err := db.Exec(createTableSQL)
-	return err
+// summarizeBonus 汇总学生的加分记录，并说明每条记录的计入情况
+func (s *Server) summarizeBonus(records []MaterialRecord, reg *regulation) BonusSummaryResponse {
+	result := s.bonusTotals(records, reg)
+	summary := BonusSummaryResponse{
+		TotalScore: result.Total,
+		Items: []BonusSummaryItem{
+			{
+				Category:   bonusCategoryAcademic,
+				TotalScore: result.Academic.Total,
+				ItemCount:  result.Academic.Items,
+			},
+			{
+				Category:   bonusCategoryComprehensive,
+				TotalScore: result.Comprehensive.Total,
+				ItemCount:  result.Comprehensive.Items,
+			},
+		},
+		Records: make([]BonusSummaryRecord, 0, len(records)),
+	}
+	for i, item := range result.Items {
+		summary.Records = append(summary.Records, BonusSummaryRecord{
+			MaterialId:   item.ID,
+			Project:      records[i].Project,
+			Category:     item.Section,
+			Kind:         string(item.Kind),
+			CollegeScore: item.Points,
+			CountedScore: item.Counted,
+			Status:       item.Status,
+			Reason:       item.Reason,
+		})
+	}
+	return summary
 }
 
 func convertToBonusRecord(rec MaterialRecord) BonusRecord {
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// seedCatalogue 条例还没有目录条目时，由条例附件解析生成，返回写入的条目数
+func (s *Server) seedCatalogue(ctx context.Context, set *store.RegulationSet) (int, error) {
+	n, err := s.store.Catalogue.Count(ctx, set.Code)
+	if err != nil || n > 0 {
+		return 0, err
+	}
+	parsed := append(scoring.ParseJournalCatalogue(set.Journals), scoring.ParseCompetitionCatalogue(set.Competitions)...)
+	err = s.store.InTx(ctx, func(tx *store.Store) error {
+		for _, e := range parsed {
+			entry := &store.CatalogueEntry{
+				RegulationCode: set.Code,
+				Kind:           e.Kind,
+				Name:           e.Name,
+				Aliases:        e.Aliases,
+				Class:          e.Class,
+				Level:          e.Level,
+				Host:           e.Host,
+				External:       e.External,
+				Note:           "由条例附件导入",
+				UpdatedBy:      "system",
+			}
+			// 附件中重复列出的条目只保留第一条
+			if err := tx.Catalogue.Create(ctx, entry); err != nil && !errors.Is(err, store.ErrConflict) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("导入条例 %s 的目录失败: %w", set.Code, err)
+	}
+	if len(parsed) > 0 {
+		log.Printf("[保研条例] 条例 %s 已从附件导入 %d 个目录条目", set.Code, len(parsed))
+	}
+	return len(parsed), nil
+}
+
+// catalogueEntries 返回某套条例指定种类的目录，同时转换为规则引擎使用的条目
+func (s *Server) catalogueEntries(ctx context.Context, code, kind string) ([]store.CatalogueEntry, []scoring.CatalogueEntry, error) {
+	stored, err := s.store.Catalogue.List(ctx, store.CatalogueFilter{RegulationCode: code, Kind: kind})
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make([]scoring.CatalogueEntry, 0, len(stored))
+	for _, e := range stored {
+		entries = append(entries, scoring.CatalogueEntry{
+			Kind:     e.Kind,
+			Name:     e.Name,
+			Aliases:  e.Aliases,
+			Class:    e.Class,
+			Level:    e.Level,
+			Host:     e.Host,
+			External: e.External,
+		})
+	}
+	return stored, entries, nil
+}
+
+// scoreFacts 按条例第 7 条先在目录中核对论文期刊会议与竞赛名称，再按加分表计分
+// 返回按目录修正后的要素；名称不在目录中时返回 scoring.ErrInvalidFacts
+func (s *Server) scoreFacts(ctx context.Context, reg *regulation, facts scoring.Facts) (scoring.Facts, scoring.Result, error) {
+	if kind, _ := scoring.CatalogueQuery(facts); kind != "" {
+		_, entries, err := s.catalogueEntries(ctx, reg.set.Code, kind)
+		if err != nil {
+			return facts, scoring.Result{}, err
+		}
+		facts, _, err = scoring.ResolveCatalogue(facts, entries)
+		if err != nil {
+			return facts, scoring.Result{}, err
+		}
+	}
+	result, err := reg.rules.Score(facts)
+	return facts, result, err
+}
+
+// catalogueCode 请求指定的条例，未指定时为当前用户适用的条例
+func (s *Server) catalogueCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if code := strings.TrimSpace(r.URL.Query().Get("code")); code != "" {
+		return code, true
+	}
+	reg, ok := s.principalRegulation(w, r)
+	if !ok {
+		return "", false
+	}
+	return reg.set.Code, true
+}
+
+// CatalogueListHandler 按条例、种类与关键字查询目录
+func (s *Server) CatalogueListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	code, ok := s.catalogueCode(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	entries, err := s.store.Catalogue.List(r.Context(), store.CatalogueFilter{
+		RegulationCode: code,
+		Kind:           strings.TrimSpace(q.Get("kind")),
+		Keyword:        strings.TrimSpace(q.Get("q")),
+	})
+	if err != nil {
+		http.Error(w, "Query catalogue error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"regulationCode": code,
+			"list":           entries,
+		},
+	})
+}
+
+// CatalogueCandidate 名称查询的候选条目
+type CatalogueCandidate struct {
+	Entry      store.CatalogueEntry `json:"entry"`
+	Confidence float64              `json:"confidence"`
+	MatchedBy  string               `json:"matchedBy"`
+}
+
+// CatalogueLookupHandler 将自由填写的竞赛或期刊会议名称匹配到目录条目
+// 置信度不低于 scoring.MatchThreshold 的最佳候选作为 match 返回，计分时按同样的规则认定
+func (s *Server) CatalogueLookupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	name := strings.TrimSpace(q.Get("q"))
+	if name == "" {
+		http.Error(w, "Missing q", http.StatusBadRequest)
+		return
+	}
+	kind := strings.TrimSpace(q.Get("kind"))
+	if kind != "" && kind != store.CatalogueJournal && kind != store.CatalogueCompetition {
+		http.Error(w, "Invalid kind", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 5
+	}
+	code, ok := s.catalogueCode(w, r)
+	if !ok {
+		return
+	}
+
+	stored, entries, err := s.catalogueEntries(r.Context(), code, kind)
+	if err != nil {
+		http.Error(w, "Query catalogue error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	candidates := make([]CatalogueCandidate, 0, limit)
+	for _, m := range scoring.MatchCatalogue(name, entries, limit) {
+		candidates = append(candidates, CatalogueCandidate{Entry: stored[m.Index], Confidence: m.Confidence, MatchedBy: m.MatchedBy})
+	}
+	var match *CatalogueCandidate
+	if len(candidates) > 0 && candidates[0].Confidence >= scoring.MatchThreshold {
+		match = &candidates[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"query":          name,
+			"regulationCode": code,
+			"threshold":      scoring.MatchThreshold,
+			"match":          match,
+			"candidates":     candidates,
+		},
+	})
+}
+
+// validateCatalogueEntry 校验目录条目的种类、类别与级别
+func validateCatalogueEntry(e *store.CatalogueEntry) error {
+	if e.RegulationCode == "" || e.Name == "" {
+		return errors.New("regulationCode 与 name 不能为空")
+	}
+	classes := map[string][]string{
+		store.CatalogueJournal:     {scoring.ClassTop, scoring.ClassA, scoring.ClassB, scoring.ClassC},
+		store.CatalogueCompetition: {scoring.ClassAPlus, scoring.ClassA, scoring.ClassAMinus},
+	}
+	allowed, ok := classes[e.Kind]
+	if !ok {
+		return fmt.Errorf("未知的目录种类 %q", e.Kind)
+	}
+	if !containsString(allowed, e.Class) {
+		return fmt.Errorf("%s 的类别只能是 %s", e.Kind, strings.Join(allowed, "/"))
+	}
+	if e.Kind == store.CatalogueJournal {
+		e.Level, e.External = "", false
+	} else if e.Level != "" && !containsString([]string{scoring.LevelInternational, scoring.LevelNational, scoring.LevelProvincial, scoring.LevelSchool}, e.Level) {
+		return fmt.Errorf("未知的竞赛级别 %q", e.Level)
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CatalogueSaveHandler 新增（id 为 0）或修改目录条目
+func (s *Server) CatalogueSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var e store.CatalogueEntry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	e.RegulationCode, e.Name = strings.TrimSpace(e.RegulationCode), strings.TrimSpace(e.Name)
+	aliases := make([]string, 0, len(e.Aliases))
+	for _, a := range e.Aliases {
+		if a = strings.TrimSpace(a); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+	e.Aliases = aliases
+	if err := validateCatalogueEntry(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	versions, err := s.store.Regulations.Versions(r.Context(), e.RegulationCode)
+	if err != nil {
+		http.Error(w, "Query regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(versions) == 0 {
+		http.Error(w, "Regulation not found", http.StatusBadRequest)
+		return
+	}
+	e.UpdatedBy = principal.AccountId
+
+	if e.ID > 0 {
+		err = s.store.Catalogue.Update(r.Context(), &e)
+	} else {
+		e.ID, e.UpdatedAt = 0, ""
+		err = s.store.Catalogue.Create(r.Context(), &e)
+	}
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Catalogue entry not found", http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrConflict):
+		http.Error(w, "同一条例的目录中已有同名条目", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Save catalogue entry error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": e,
+	})
+}
+
+// CatalogueDeleteHandler 删除目录条目，已计分的记录不受影响，之后的计分不再认定该项目
+func (s *Server) CatalogueDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+		http.Error(w, "Missing id", http.StatusBadRequest)
+		return
+	}
+	err := s.store.Catalogue.Delete(r.Context(), req.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Catalogue entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Delete catalogue entry error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"id": req.ID},
+	})
+}
+
+// RegisterCatalogueRoutes 注册期刊会议目录与竞赛项目库的查询、名称匹配与维护路由
+func (s *Server) RegisterCatalogueRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/catalogue/list", s.CatalogueListHandler)
+	mux.HandleFunc("/api/catalogue/lookup", s.CatalogueLookupHandler)
+	mux.HandleFunc("/api/catalogue/save", s.CatalogueSaveHandler)
+	mux.HandleFunc("/api/catalogue/delete", s.CatalogueDeleteHandler)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// StudentEligibility 一名学生的推免资格审查结果
+// Checked 为 false 表示尚未导入成绩单，无法审查，暂按具备资格处理
+type StudentEligibility struct {
+	StudentId string `json:"studentId"`
+	Name      string `json:"name"`
+	Major     string `json:"major"`
+	Checked   bool   `json:"checked"`
+	scoring.Eligibility
+	// Courses 已导入的课程数
+	Courses int `json:"courses"`
+}
+
+func transcriptCourses(list []store.TranscriptCourse) []scoring.Course {
+	courses := make([]scoring.Course, 0, len(list))
+	for _, c := range list {
+		courses = append(courses, scoring.Course{Name: c.Course, Credit: c.Credit, Score: c.Score, Graded: c.Graded, Retaken: c.Retaken})
+	}
+	return courses
+}
+
+// eligibilityFor 按学生适用的条例与已导入的成绩单审查推免资格
+func (s *Server) eligibilityFor(ctx context.Context, studentId string, reg *regulation) (StudentEligibility, error) {
+	list, err := s.store.Transcripts.ListByStudent(ctx, studentId)
+	if err != nil {
+		return StudentEligibility{}, err
+	}
+	e := StudentEligibility{
+		StudentId:   studentId,
+		Checked:     len(list) > 0,
+		Eligibility: reg.rules.CheckEligibility(transcriptCourses(list)),
+		Courses:     len(list),
+	}
+	return e, nil
+}
+
+// refreshTranscriptGPA 按成绩单重新计算学生的推免绩点（重修课程按 60 分计），返回更新的学生数
+// 成绩单中没有百分制成绩的学生保留原有绩点
+func (s *Server) refreshTranscriptGPA(ctx context.Context, studentIds []string) (int, error) {
+	updated := 0
+	for _, id := range studentIds {
+		reg, err := s.regulationFor(ctx, id)
+		if err != nil {
+			return updated, err
+		}
+		e, err := s.eligibilityFor(ctx, id, reg)
+		if err != nil {
+			return updated, err
+		}
+		if e.Credits <= 0 {
+			continue
+		}
+		n, err := s.store.Students.SetGPA(ctx, id, e.GPA)
+		if err != nil {
+			return updated, fmt.Errorf("更新学号 %s 的推免绩点失败: %w", id, err)
+		}
+		if n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// EligibilityListHandler 按专业列出学生的推免资格审查结果，ineligible=true 时只返回不具备资格的学生
+func (s *Server) EligibilityListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	roster, err := s.store.Students.Roster(r.Context(), strings.TrimSpace(q.Get("major")))
+	if err != nil {
+		http.Error(w, "Query students error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	onlyIneligible := q.Get("ineligible") == "true"
+
+	list := make([]StudentEligibility, 0, len(roster))
+	for _, st := range roster {
+		reg, err := s.regulationFor(r.Context(), st.StudentId)
+		if err != nil {
+			http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		e, err := s.eligibilityFor(r.Context(), st.StudentId, reg)
+		if err != nil {
+			http.Error(w, "Query transcript error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if onlyIneligible && e.Eligible {
+			continue
+		}
+		e.Name, e.Major = st.Name, st.Major
+		list = append(list, e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"total": len(list),
+			"list":  list,
+		},
+	})
+}
+
+// MyEligibilityHandler 返回当前学生的推免资格、成绩单与成果截止日期，以及晚于截止日期的材料
+func (s *Server) MyEligibilityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	reg, err := s.regulationFor(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	e, err := s.eligibilityFor(r.Context(), principal.AccountId, reg)
+	if err != nil {
+		http.Error(w, "Query transcript error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if st, err := s.store.Students.Get(r.Context(), principal.AccountId); err == nil {
+		e.Name, e.Major = st.Name, st.Major
+	}
+	courses, err := s.store.Transcripts.ListByStudent(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Query transcript error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	materials, err := s.store.Materials.List(r.Context(), store.MaterialFilter{Uploader: principal.AccountId})
+	if err != nil {
+		http.Error(w, "Query materials error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	late := make([]map[string]interface{}, 0)
+	for _, m := range materials {
+		if m.CutoffFlag != "" {
+			late = append(late, map[string]interface{}{
+				"id":         m.ID,
+				"title":      m.Title,
+				"awardDate":  m.AwardDate,
+				"cutoffFlag": m.CutoffFlag,
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"eligibility":   e,
+			"cutoffDate":    reg.cutoffDate(),
+			"transcript":    courses,
+			"lateMaterials": late,
+		},
+	})
+}
+
+// RegisterEligibilityRoutes 注册推免资格审查路由，成绩单经 /api/info/import/transcript 导入
+func (s *Server) RegisterEligibilityRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/eligibility/list", s.EligibilityListHandler)
+	mux.HandleFunc("/api/eligibility/mine", s.MyEligibilityHandler)
+}
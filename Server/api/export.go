@@ -1,150 +1,230 @@
 package api
 
 import (
-	"database/sql"
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/xuri/excelize/v2"
 )
 
-// 学生结构体
-type Student struct {
-	ID        int
-	Name      string
-	StudentID string
-	Major     string
-	Class     string
-	Score     float64
+const (
+	studentSheet   = "学生名单"
+	breakdownSheet = "分类加分"
+)
+
+var studentSheetHeaders = []interface{}{"序号", "学号", "姓名", "专业", "班级", "推免绩点", "学术专长加分", "综合素质加分", "考核综合成绩总分", "综合成绩", "专业排名", "排名人数"}
+
+var breakdownSheetHeaders = []interface{}{"学号", "姓名", "专业", "板块", "类别", "记录数", "学院核定加分", "计入加分"}
+
+// categoryBreakdown 一名学生在一个板块、一个类别下的加分
+type categoryBreakdown struct {
+	section string
+	kind    scoring.Kind
+	count   int
+	points  float64
+	counted float64
 }
 
-// 模拟学生数据（暂时保留，TXT 导出未改造前使用）
-var students = []Student{
-	{1, "张三", "2021001", "计算机科学", "计科1班", 5.0},
-	{2, "李四", "2021002", "软件工程", "软工1班", 3.5},
-	{3, "王五", "2021003", "人工智能", "AI1班", 4.2},
+// breakdownByCategory 按板块与类别汇总计分结果，顺序与记录首次出现的顺序一致
+func breakdownByCategory(summary scoring.Summary) []*categoryBreakdown {
+	list := make([]*categoryBreakdown, 0)
+	index := make(map[string]*categoryBreakdown)
+	for _, item := range summary.Items {
+		key := item.Section + "/" + string(item.Kind)
+		b, ok := index[key]
+		if !ok {
+			b = &categoryBreakdown{section: item.Section, kind: item.Kind}
+			index[key] = b
+			list = append(list, b)
+		}
+		b.count++
+		b.points += item.Points
+		b.counted += item.Counted
+	}
+	return list
 }
 
-// 导出学生信息为 Excel（CSV）
-func ExportStudentsExcel(w http.ResponseWriter, r *http.Request) {
-	// 连接数据库
-	db, err := sql.Open("sqlite3", "./students.db")
-	if err != nil {
-		http.Error(w, fmt.Sprintf("数据库连接失败: %v", err), http.StatusInternalServerError)
-		return
+func sectionLabel(section string) string {
+	if section == bonusTypeComprehensive {
+		return bonusCategoryComprehensive
 	}
-	defer db.Close()
-
-	// 确保表存在
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS students (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		studentId TEXT,
-		major TEXT,
-		class TEXT,
-		score REAL
-	)`)
+	return bonusCategoryAcademic
+}
+
+// newStreamSheet 创建流式写入的工作表并写入冻结的表头行
+// 流式写入要求列宽与窗格在写入行之前设置
+func newStreamSheet(f *excelize.File, sheet string, headers []interface{}, widths []float64, headerStyle int) (*excelize.StreamWriter, error) {
+	sw, err := f.NewStreamWriter(sheet)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("初始化数据表失败: %v", err), http.StatusInternalServerError)
+		return nil, err
+	}
+	for i, width := range widths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, err
+		}
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, err
+	}
+	cells := make([]interface{}, len(headers))
+	for i, h := range headers {
+		cells[i] = excelize.Cell{StyleID: headerStyle, Value: h}
+	}
+	return sw, sw.SetRow("A1", cells)
+}
+
+// extendWriteDeadline 将响应的写超时顺延 server.writeTimeout
+// 服务器的写超时从读完请求起计算，耗时较长的导出在生成完成后、写出每名学生的内容前调用，避免响应被截断
+func (s *Server) extendWriteDeadline(w http.ResponseWriter) {
+	timeout := s.cfg.Server.WriteTimeout
+	if timeout <= 0 {
 		return
 	}
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("[导出] 顺延写超时失败: %v", err)
+	}
+}
 
-	// 查询学生信息
-	rows, err := db.Query(`SELECT id, name, studentId, major, class, score FROM students`)
+// ExportStudentsExcel 导出学生名单为 xlsx：第一张表为每名学生的推免绩点、加分与排名，第二张表为按类别汇总的加分
+// 数值写为数字单元格，工作表流式写入，学生较多时不在内存中保留整个工作簿；可按 major、class 筛选
+func (s *Server) ExportStudentsExcel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	major, class := strings.TrimSpace(q.Get("major")), strings.TrimSpace(q.Get("class"))
+	roster, err := s.store.Students.Roster(ctx, major)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("查询数据失败: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	w.Header().Set("Content-Type", "application/vnd.ms-excel")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"students_%d.csv\"", time.Now().Unix()))
-
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
 
-	// 写入表头
-	if err := writer.Write([]string{"id", "name", "studentId", "major", "class", "score"}); err != nil {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", studentSheet); err != nil {
+		http.Error(w, fmt.Sprintf("创建工作表失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if _, err := f.NewSheet(breakdownSheet); err != nil {
+		http.Error(w, fmt.Sprintf("创建工作表失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("创建样式失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	list, err := newStreamSheet(f, studentSheet, studentSheetHeaders, []float64{6, 14, 10, 18, 12, 10, 14, 14, 18, 10, 10, 10}, headerStyle)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("写入表头失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	detail, err := newStreamSheet(f, breakdownSheet, breakdownSheetHeaders, []float64{14, 10, 18, 10, 16, 8, 14, 10}, headerStyle)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("写入表头失败: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	for rows.Next() {
-		var s Student
-		if err := rows.Scan(&s.ID, &s.Name, &s.StudentID, &s.Major, &s.Class, &s.Score); err != nil {
-			http.Error(w, fmt.Sprintf("读取数据失败: %v", err), http.StatusInternalServerError)
+	row, detailRow := 2, 2
+	for _, st := range roster {
+		if class != "" && st.Class != class {
+			continue
+		}
+		reg, err := s.regulationFor(ctx, st.StudentId)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("查询条例失败: %v", err), http.StatusInternalServerError)
 			return
 		}
-		record := []string{
-			strconv.Itoa(s.ID),
-			s.Name,
-			s.StudentID,
-			s.Major,
-			s.Class,
-			fmt.Sprintf("%.2f", s.Score),
+		records, err := s.store.MaterialRecords.ListByAccount(ctx, st.StudentId)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("查询加分记录失败: %v", err), http.StatusInternalServerError)
+			return
 		}
-		if err := writer.Write(record); err != nil {
+		summary := s.bonusTotals(records, reg)
+
+		// 未录入绩点或未参与排名的学生对应单元格留空
+		var gpa, composite, rank, total interface{}
+		if st.GPA > 0 {
+			gpa = st.GPA
+		}
+		rk, err := s.store.Rankings.Get(ctx, st.StudentId)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("查询排名失败: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			composite, rank, total = rk.Composite, rk.Rank, rk.Total
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := list.SetRow(cell, []interface{}{
+			row - 1, st.StudentId, st.Name, st.Major, st.Class, gpa,
+			summary.Academic.Total, summary.Comprehensive.Total, summary.Total, composite, rank, total,
+		}); err != nil {
 			http.Error(w, fmt.Sprintf("写入数据失败: %v", err), http.StatusInternalServerError)
 			return
 		}
+		row++
+
+		for _, b := range breakdownByCategory(summary) {
+			cell, _ := excelize.CoordinatesToCellName(1, detailRow)
+			if err := detail.SetRow(cell, []interface{}{
+				st.StudentId, st.Name, st.Major, sectionLabel(b.section), b.kind.Label(), b.count, scoring.Round(b.points), scoring.Round(b.counted),
+			}); err != nil {
+				http.Error(w, fmt.Sprintf("写入数据失败: %v", err), http.StatusInternalServerError)
+				return
+			}
+			detailRow++
+		}
 	}
-}
-
-// 导出学生信息为 TXT
-func ExportStudentsTxt(w http.ResponseWriter, r *http.Request) {
-	// 连接数据库
-	db, err := sql.Open("sqlite3", "./students.db")
-	if err != nil {
-		http.Error(w, fmt.Sprintf("数据库连接失败: %v", err), http.StatusInternalServerError)
-		return
+	for _, sw := range []*excelize.StreamWriter{list, detail} {
+		if err := sw.Flush(); err != nil {
+			http.Error(w, fmt.Sprintf("写入数据失败: %v", err), http.StatusInternalServerError)
+			return
+		}
 	}
-	defer db.Close()
-
-	// 确保表存在
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS students (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		studentId TEXT,
-		major TEXT,
-		class TEXT,
-		score REAL
-	)`)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("初始化数据表失败: %v", err), http.StatusInternalServerError)
-		return
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"students_%d.xlsx\"", time.Now().Unix()))
+	s.extendWriteDeadline(w)
+	if err := f.Write(w); err != nil {
+		log.Printf("[导出] 写入学生名单失败: %v", err)
 	}
+}
 
+// 导出学生信息为 TXT
+func (s *Server) ExportStudentsTxt(w http.ResponseWriter, r *http.Request) {
 	// 查询学生信息
-	rows, err := db.Query(`SELECT id, name, studentId, major, class, score FROM students`)
+	students, err := s.store.Students.Roster(r.Context(), "")
 	if err != nil {
 		http.Error(w, fmt.Sprintf("查询数据失败: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"students_%d.txt\"", time.Now().Unix()))
 
-	for rows.Next() {
-		var s Student
-		if err := rows.Scan(&s.ID, &s.Name, &s.StudentID, &s.Major, &s.Class, &s.Score); err != nil {
-			http.Error(w, fmt.Sprintf("读取数据失败: %v", err), http.StatusInternalServerError)
-			return
-		}
-		line := fmt.Sprintf("ID: %d, 姓名: %s, 学号: %s, 专业: %s, 班级: %s, 成绩: %.2f",
-			s.ID, s.Name, s.StudentID, s.Major, s.Class, s.Score)
+	for i, st := range students {
+		line := fmt.Sprintf("序号: %d, 姓名: %s, 学号: %s, 专业: %s, 班级: %s, 成绩: %.2f",
+			i+1, st.Name, st.StudentId, st.Major, st.Class, st.Score)
 		if _, err := w.Write([]byte(line + "\n")); err != nil {
-			http.Error(w, fmt.Sprintf("写入数据失败: %v", err), http.StatusInternalServerError)
+			log.Printf("[导出] 写入学生名单失败: %v", err)
 			return
 		}
 	}
 }
 
 // 注册路由函数
-func RegisterExportRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/export/students/excel", ExportStudentsExcel)
-	mux.HandleFunc("/api/export/students/txt", ExportStudentsTxt)
+func (s *Server) RegisterExportRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/export/students/excel", s.ExportStudentsExcel)
+	mux.HandleFunc("/api/export/students/txt", s.ExportStudentsTxt)
+	mux.HandleFunc("/api/export/register", s.ExportRegisterHandler)
+	mux.HandleFunc("/api/export/report", s.ExportReportHandler)
+	mux.HandleFunc("/api/export/bundle", s.ExportBundleHandler)
 }
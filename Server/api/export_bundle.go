@@ -0,0 +1,325 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// bundleEntry 证明材料压缩包清单中的一行，Path 为空表示上传目录中已找不到该文件
+type bundleEntry struct {
+	Path         string  `json:"path"`
+	StudentId    string  `json:"studentId"`
+	Name         string  `json:"name"`
+	Major        string  `json:"major"`
+	MaterialId   string  `json:"materialId"`
+	Title        string  `json:"title"`
+	Status       string  `json:"status"`
+	Category     string  `json:"category"`
+	Project      string  `json:"project"`
+	AwardDate    string  `json:"awardDate"`
+	CollegeScore float64 `json:"collegeScore"`
+	ScoreBasis   string  `json:"scoreBasis"`
+	File         string  `json:"file"`
+	Size         int64   `json:"size"`
+}
+
+var bundleCSVHeaders = []string{"path", "studentId", "name", "major", "materialId", "title", "status", "category", "project", "awardDate", "collegeScore", "scoreBasis", "file", "size"}
+
+func (e bundleEntry) csvRow() []string {
+	return []string{e.Path, e.StudentId, e.Name, e.Major, e.MaterialId, e.Title, e.Status, e.Category, e.Project,
+		e.AwardDate, strconv.FormatFloat(e.CollegeScore, 'f', -1, 64), e.ScoreBasis, e.File, strconv.FormatInt(e.Size, 10)}
+}
+
+// bundleFilter 压缩包的筛选条件
+type bundleFilter struct {
+	accountId string
+	major     string
+	statuses  []string
+	// from、before 为上传时间范围 [from, before)
+	from, before string
+}
+
+// parseBundleFilter 解析查询参数，status 以逗号分隔且默认为 approved，from、to 为包含两端的日期 YYYY-MM-DD
+func parseBundleFilter(q url.Values) (bundleFilter, error) {
+	f := bundleFilter{
+		accountId: strings.TrimSpace(q.Get("accountId")),
+		major:     strings.TrimSpace(q.Get("major")),
+	}
+	for _, st := range strings.Split(q.Get("status"), ",") {
+		st = strings.TrimSpace(st)
+		if st == "" {
+			continue
+		}
+		if !workflow.State(st).Valid() {
+			return f, fmt.Errorf("未知的材料状态 %q", st)
+		}
+		f.statuses = append(f.statuses, st)
+	}
+	if len(f.statuses) == 0 {
+		f.statuses = []string{string(workflow.Approved)}
+	}
+	if v := strings.TrimSpace(q.Get("from")); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fmt.Errorf("from 日期格式应为 YYYY-MM-DD: %q", v)
+		}
+		f.from = store.FormatTime(t)
+	}
+	if v := strings.TrimSpace(q.Get("to")); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fmt.Errorf("to 日期格式应为 YYYY-MM-DD: %q", v)
+		}
+		f.before = store.FormatTime(t.AddDate(0, 0, 1))
+	}
+	return f, nil
+}
+
+// bundleStudents 返回压缩包涉及的学生，指定 accountId 时只包含该学生
+func (s *Server) bundleStudents(ctx context.Context, f bundleFilter) ([]store.Student, error) {
+	if f.accountId == "" {
+		return s.store.Students.Roster(ctx, f.major)
+	}
+	st, err := s.store.Students.Get(ctx, f.accountId)
+	if errors.Is(err, store.ErrNotFound) {
+		st = &store.Student{StudentId: f.accountId}
+		if u, err := s.store.Users.GetByAccountId(ctx, f.accountId); err == nil {
+			st.Name = u.Name
+		}
+		return []store.Student{*st}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.major != "" && st.Major != f.major {
+		return nil, nil
+	}
+	return []store.Student{*st}, nil
+}
+
+// safePathPart 把名称转换为可用作压缩包目录或文件名的一段路径
+func safePathPart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+	s = strings.Trim(s, ". ")
+	if runes := []rune(s); len(runes) > 60 {
+		s = string(runes[:60])
+	}
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// bundleWriter 向压缩包中依次写入证明材料，并记录清单
+type bundleWriter struct {
+	zw      *zip.Writer
+	dir     string
+	used    map[string]bool
+	entries []bundleEntry
+}
+
+// uniquePath 同名文件追加序号，避免覆盖
+func (b *bundleWriter) uniquePath(name string) string {
+	if !b.used[name] {
+		b.used[name] = true
+		return name
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s(%d)%s", base, i, ext)
+		if !b.used[candidate] {
+			b.used[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// addFile 把上传目录中的文件写入压缩包，文件不存在时只记录到清单
+func (b *bundleWriter) addFile(entry bundleEntry, zipPath string) error {
+	f, err := os.Open(filepath.Join(b.dir, entry.File))
+	if err != nil {
+		log.Printf("[导出] 证明材料 %s 不可读: %v", entry.File, err)
+		b.entries = append(b.entries, entry)
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	entry.Path = b.uniquePath(zipPath)
+	entry.Size = info.Size()
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: entry.Path, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return err
+	}
+	b.entries = append(b.entries, entry)
+	return nil
+}
+
+// writeManifest 写入 manifest.json 与 manifest.csv，CSV 带 BOM 以便 Excel 正确识别中文
+func (b *bundleWriter) writeManifest() error {
+	w, err := b.zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b.entries); err != nil {
+		return err
+	}
+
+	w, err = b.zw.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(bundleCSVHeaders)
+	for _, e := range b.entries {
+		cw.Write(e.csvRow())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeBundleStudent 写入一名学生符合条件的材料，路径为 <学号>_<姓名>/<类别>/<项目>.<扩展名>
+func (s *Server) writeBundleStudent(ctx context.Context, b *bundleWriter, st store.Student, f bundleFilter) error {
+	materials, err := s.store.Materials.List(ctx, store.MaterialFilter{
+		Uploader:       st.StudentId,
+		Statuses:       f.statuses,
+		UploadedFrom:   f.from,
+		UploadedBefore: f.before,
+	})
+	if err != nil {
+		return err
+	}
+	folder := safePathPart(st.StudentId + "_" + st.Name)
+	for _, m := range materials {
+		entry := bundleEntry{
+			StudentId:  st.StudentId,
+			Name:       st.Name,
+			Major:      st.Major,
+			MaterialId: m.ID,
+			Title:      m.Title,
+			Status:     m.Status,
+			Category:   m.Category,
+			Project:    m.Title,
+			AwardDate:  m.AwardDate,
+		}
+		rec, err := s.store.MaterialRecords.Get(ctx, m.ID)
+		switch {
+		case err == nil:
+			section := normalizeBonusType(rec.Category)
+			if section != bonusTypeAcademic && section != bonusTypeComprehensive {
+				section = normalizeBonusType(rec.Type)
+			}
+			entry.Category = sectionLabel(section)
+			if rec.Project != "" {
+				entry.Project = rec.Project
+			}
+			if rec.AwardDate != "" {
+				entry.AwardDate = rec.AwardDate
+			}
+			entry.CollegeScore, entry.ScoreBasis = rec.CollegeScore, rec.ScoreBasis
+		case !errors.Is(err, store.ErrNotFound):
+			return err
+		}
+		if entry.Category == "" {
+			entry.Category = "未分类"
+		}
+		if entry.Project == "" {
+			entry.Project = m.ID
+		}
+
+		files := uploadNames(m.Files)
+		for i, name := range files {
+			base := safePathPart(entry.Project)
+			if len(files) > 1 {
+				base = fmt.Sprintf("%s_%d", base, i+1)
+			}
+			entry.File = name
+			zipPath := path.Join(folder, safePathPart(entry.Category), base+strings.ToLower(filepath.Ext(name)))
+			if err := b.addFile(entry, zipPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportBundleHandler 以 ZIP 流式导出学生的证明材料，每名学生一个目录，附带关联加分记录的清单
+// 可按 accountId、major、status（默认 approved）与上传日期 from、to 筛选；压缩包直接写入响应，不产生临时文件
+func (s *Server) ExportBundleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	filter, err := parseBundleFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	students, err := s.bundleStudents(r.Context(), filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("查询数据失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	name := "证明材料"
+	for _, part := range []string{filter.accountId, filter.major} {
+		if part != "" {
+			name += "_" + part
+		}
+	}
+	name = fmt.Sprintf("%s_%d.zip", name, time.Now().Unix())
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"evidence_%d.zip\"; filename*=UTF-8''%s", time.Now().Unix(), url.PathEscape(name)))
+
+	// 响应已开始写出，之后的错误只能记录日志并中止压缩包
+	b := &bundleWriter{zw: zip.NewWriter(w), dir: s.cfg.Upload.Dir, used: make(map[string]bool), entries: make([]bundleEntry, 0)}
+	for _, st := range students {
+		s.extendWriteDeadline(w)
+		if err := s.writeBundleStudent(r.Context(), b, st, filter); err != nil {
+			log.Printf("[导出] 写入学生 %s 的证明材料失败: %v", st.StudentId, err)
+			return
+		}
+	}
+	s.extendWriteDeadline(w)
+	if err := b.writeManifest(); err != nil {
+		log.Printf("[导出] 写入证明材料清单失败: %v", err)
+		return
+	}
+	if err := b.zw.Close(); err != nil {
+		log.Printf("[导出] 写入证明材料压缩包失败: %v", err)
+	}
+}
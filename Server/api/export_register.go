@@ -0,0 +1,372 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/xuri/excelize/v2"
+)
+
+const registerSheet = "成果加分登记表"
+
+// registerBasicHeaders 登记表每名学生的基本信息列
+var registerBasicHeaders = []string{"序号", "系", "所在专业", "学号", "姓名", "性别", "CET4/CET6", "推免绩点", "换算成绩"}
+
+// registerBlockHeaders 学术专长与综合表现两个区块各自的列
+var registerBlockHeaders = []string{"项目（请填写全称）", "获奖时间", "奖项级别", "个人或集体奖项", "集体奖项中第几作者", "自评加分", "加分依据", "学院核定加分", "学院核定总分"}
+
+// registerTailHeaders 登记表末尾的汇总列
+var registerTailHeaders = []string{"考核综合成绩总分", "综合成绩", "专业成绩排名", "排名人数"}
+
+// registerStudent 登记表中一名学生的一组行
+type registerStudent struct {
+	student       store.Student
+	standing      scoring.Standing
+	academic      []registerRecord
+	comprehensive []registerRecord
+	summary       scoring.Summary
+	rank, total   string
+}
+
+// registerRecord 登记表中的一条加分记录
+type registerRecord struct {
+	rec    MaterialRecord
+	result scoring.ItemResult
+}
+
+// teamColumns 由计分要素得出「个人或集体奖项」与「集体奖项中第几作者」两列，旧记录使用模型整理的 teamRank
+func teamColumns(rec *MaterialRecord) (string, string) {
+	f := recordFacts(rec)
+	rank := f.TeamRank
+	if f.Kind == scoring.KindPaper || f.Kind == scoring.KindPatent {
+		rank = f.AuthorRank
+	}
+	switch {
+	case f.SoleAuthor || f.TeamSize == 1:
+		return "个人", ""
+	case f.TeamSize > 1 || f.Collective || rank > 0:
+		if rank > 0 {
+			return "集体", strconv.Itoa(rank)
+		}
+		return "集体", ""
+	}
+	return rec.TeamRank, ""
+}
+
+// registerStudents 按专业、名次、学号顺序整理登记表中的学生，major、class 为空表示不限
+func (s *Server) registerStudents(ctx context.Context, major, class string) ([]registerStudent, error) {
+	roster, err := s.store.Students.Roster(ctx, major)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]registerStudent, 0, len(roster))
+	ranks := make(map[string]int, len(roster))
+	for _, st := range roster {
+		if class != "" && st.Class != class {
+			continue
+		}
+		reg, err := s.regulationFor(ctx, st.StudentId)
+		if err != nil {
+			return nil, err
+		}
+		records, err := s.store.MaterialRecords.ListByAccount(ctx, st.StudentId)
+		if err != nil {
+			return nil, err
+		}
+		rs := registerStudent{student: st, summary: s.bonusTotals(records, reg)}
+		for i, item := range rs.summary.Items {
+			rr := registerRecord{rec: records[i], result: item}
+			if item.Section == bonusTypeComprehensive {
+				rs.comprehensive = append(rs.comprehensive, rr)
+			} else {
+				rs.academic = append(rs.academic, rr)
+			}
+		}
+		if st.GPA > 0 {
+			rs.standing = reg.rules.Standing(scoring.Candidate{
+				StudentId:     st.StudentId,
+				GPA:           st.GPA,
+				Academic:      rs.summary.Academic.Total,
+				Comprehensive: rs.summary.Comprehensive.Total,
+			})
+		}
+
+		rk, err := s.store.Rankings.Get(ctx, st.StudentId)
+		switch {
+		case err == nil:
+			rs.rank, rs.total = strconv.Itoa(rk.Rank), strconv.Itoa(rk.Total)
+			if rk.Tied {
+				rs.rank += "（并列）"
+			}
+			ranks[st.StudentId] = rk.Rank
+		case errors.Is(err, store.ErrNotFound):
+			e, err := s.eligibilityFor(ctx, st.StudentId, reg)
+			if err != nil {
+				return nil, err
+			}
+			if !e.Eligible {
+				rs.rank = "不具备推免资格"
+			}
+		default:
+			return nil, err
+		}
+		list = append(list, rs)
+	}
+
+	// 未参与排名的学生排在专业末尾
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].student, list[j].student
+		if a.Major != b.Major {
+			return a.Major < b.Major
+		}
+		ra, rb := ranks[a.StudentId], ranks[b.StudentId]
+		if (ra > 0) != (rb > 0) {
+			return ra > 0
+		}
+		if ra != rb {
+			return ra < rb
+		}
+		return a.StudentId < b.StudentId
+	})
+	return list, nil
+}
+
+// registerWriter 逐组写入登记表的行
+type registerWriter struct {
+	f    *excelize.File
+	cell int
+	text int
+}
+
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+// merge 合并 [col1,row1]-[col2,row2] 并写入值，区域只有一格时只写值
+func (rw *registerWriter) merge(col1, row1, col2, row2 int, value interface{}) error {
+	if col1 != col2 || row1 != row2 {
+		if err := rw.f.MergeCell(registerSheet, cellName(col1, row1), cellName(col2, row2)); err != nil {
+			return err
+		}
+	}
+	return rw.f.SetCellValue(registerSheet, cellName(col1, row1), value)
+}
+
+// writeHeader 写入标题行与两行表头，返回数据起始行
+func (rw *registerWriter) writeHeader(cfg registerCaps) (int, error) {
+	basic, block := len(registerBasicHeaders), len(registerBlockHeaders)
+	last := basic + 2*block + len(registerTailHeaders)
+	if err := rw.merge(1, 1, last, 1, "信息学院推荐免试攻读硕士学位研究生成果加分登记表"); err != nil {
+		return 0, err
+	}
+	col := 1
+	for _, h := range registerBasicHeaders {
+		if err := rw.merge(col, 2, col, 3, h); err != nil {
+			return 0, err
+		}
+		col++
+	}
+	for _, title := range []string{
+		fmt.Sprintf("学术专长成绩（满分 %s 分）", scoring.Format(cfg.academic)),
+		fmt.Sprintf("综合表现成绩（满分 %s 分）", scoring.Format(cfg.comprehensive)),
+	} {
+		if err := rw.merge(col, 2, col+block-1, 2, title); err != nil {
+			return 0, err
+		}
+		for i, h := range registerBlockHeaders {
+			if err := rw.f.SetCellValue(registerSheet, cellName(col+i, 3), h); err != nil {
+				return 0, err
+			}
+		}
+		col += block
+	}
+	for _, h := range registerTailHeaders {
+		if err := rw.merge(col, 2, col, 3, h); err != nil {
+			return 0, err
+		}
+		col++
+	}
+	if err := rw.f.SetCellStyle(registerSheet, cellName(1, 1), cellName(last, 3), rw.cell); err != nil {
+		return 0, err
+	}
+	return 4, nil
+}
+
+// registerCaps 表头中注明的两个区块的上限
+type registerCaps struct {
+	academic, comprehensive float64
+}
+
+// writeStudent 从 row 开始写入一名学生的一组行，返回下一名学生的起始行
+func (rw *registerWriter) writeStudent(row, seq int, rs registerStudent) (int, error) {
+	n := len(rs.academic)
+	if len(rs.comprehensive) > n {
+		n = len(rs.comprehensive)
+	}
+	if n == 0 {
+		n = 1
+	}
+	last := row + n - 1
+
+	var gpa, converted, composite interface{} = "", "", ""
+	if rs.student.GPA > 0 {
+		gpa, converted, composite = rs.standing.GPA, rs.standing.AcademicScore, rs.standing.Composite
+	}
+	basic := []interface{}{seq, "", rs.student.Major, rs.student.StudentId, rs.student.Name, "", "", gpa, converted}
+	col := 1
+	for _, v := range basic {
+		if err := rw.merge(col, row, col, last, v); err != nil {
+			return 0, err
+		}
+		col++
+	}
+
+	blocks := []struct {
+		records []registerRecord
+		total   float64
+	}{
+		{rs.academic, rs.summary.Academic.Total},
+		{rs.comprehensive, rs.summary.Comprehensive.Total},
+	}
+	for _, b := range blocks {
+		for i, rr := range b.records {
+			team, order := teamColumns(&rr.rec)
+			basis := rr.rec.ScoreBasis
+			if rr.result.Status != scoring.StatusCounted && rr.result.Reason != "" {
+				basis = strings.TrimSpace(fmt.Sprintf("%s（%s，计入 %s 分）", basis, rr.result.Reason, scoring.Format(rr.result.Counted)))
+			}
+			values := []interface{}{rr.rec.Project, rr.rec.AwardDate, rr.rec.AwardType, team, order, rr.rec.SelfScore, basis, rr.rec.CollegeScore}
+			for j, v := range values {
+				if err := rw.f.SetCellValue(registerSheet, cellName(col+j, row+i), v); err != nil {
+					return 0, err
+				}
+			}
+		}
+		// 学院核定总分为按条例项数限制与上限汇总后的分值
+		if err := rw.merge(col+len(registerBlockHeaders)-1, row, col+len(registerBlockHeaders)-1, last, b.total); err != nil {
+			return 0, err
+		}
+		col += len(registerBlockHeaders)
+	}
+
+	tail := []interface{}{rs.summary.Total, composite, rs.rank, rs.total}
+	for _, v := range tail {
+		if err := rw.merge(col, row, col, last, v); err != nil {
+			return 0, err
+		}
+		col++
+	}
+	if err := rw.f.SetCellStyle(registerSheet, cellName(1, row), cellName(col-1, last), rw.cell); err != nil {
+		return 0, err
+	}
+	// 项目与加分依据内容较长，左对齐换行
+	for _, offset := range []int{0, 6} {
+		for _, start := range []int{len(registerBasicHeaders) + 1, len(registerBasicHeaders) + len(registerBlockHeaders) + 1} {
+			if err := rw.f.SetCellStyle(registerSheet, cellName(start+offset, row), cellName(start+offset, last), rw.text); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return last + 1, nil
+}
+
+// buildRegister 生成登记表工作簿
+func (s *Server) buildRegister(students []registerStudent) (*excelize.File, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", registerSheet); err != nil {
+		return nil, err
+	}
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1},
+	}
+	cell, err := f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	text, err := f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: &excelize.Alignment{Horizontal: "left", Vertical: "center", WrapText: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	rw := &registerWriter{f: f, cell: cell, text: text}
+
+	row, err := rw.writeHeader(registerCaps{academic: s.cfg.Score.AcademicCap, comprehensive: s.cfg.Score.ComprehensiveCap})
+	if err != nil {
+		return nil, err
+	}
+	for i, rs := range students {
+		if row, err = rw.writeStudent(row, i+1, rs); err != nil {
+			return nil, err
+		}
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(registerBasicHeaders) + 2*len(registerBlockHeaders) + len(registerTailHeaders))
+	if err := f.SetColWidth(registerSheet, "A", lastCol, 12); err != nil {
+		return nil, err
+	}
+	for _, start := range []int{len(registerBasicHeaders) + 1, len(registerBasicHeaders) + len(registerBlockHeaders) + 1} {
+		for _, offset := range []int{0, 6} {
+			name, _ := excelize.ColumnNumberToName(start + offset)
+			if err := f.SetColWidth(registerSheet, name, name, 30); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := f.SetPanes(registerSheet, &excelize.Panes{Freeze: true, XSplit: 5, YSplit: 3, TopLeftCell: "F4", ActivePane: "bottomRight"}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ExportRegisterHandler 按《信息学院推荐免试攻读硕士学位研究生成果加分登记表》的格式导出 xlsx
+// 每名学生一组行，列出其审核通过材料的加分记录，学院核定总分按条例项数限制与上限汇总；可按 major、class 筛选
+func (s *Server) ExportRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	major, class := strings.TrimSpace(q.Get("major")), strings.TrimSpace(q.Get("class"))
+
+	students, err := s.registerStudents(r.Context(), major, class)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("查询数据失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	f, err := s.buildRegister(students)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("生成登记表失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	name := registerSheet
+	for _, part := range []string{major, class} {
+		if part != "" {
+			name += "_" + part
+		}
+	}
+	name = fmt.Sprintf("%s_%d.xlsx", name, time.Now().Unix())
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"register_%d.xlsx\"; filename*=UTF-8''%s", time.Now().Unix(), url.PathEscape(name)))
+	s.extendWriteDeadline(w)
+	if err := f.Write(w); err != nil {
+		http.Error(w, fmt.Sprintf("写入数据失败: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/pdf"
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// studentReport 学生个人成果加分汇总报告，供打印签字后存入推免档案
+type studentReport struct {
+	AccountId      string          `json:"accountId"`
+	Name           string          `json:"name"`
+	Major          string          `json:"major"`
+	Class          string          `json:"class"`
+	GPA            float64         `json:"gpa"`
+	RegulationCode string          `json:"regulationCode"`
+	Sections       []reportSection `json:"sections"`
+	Total          float64         `json:"total"`
+	// Ranking 专业排名，未参与排名时为空
+	Ranking     *store.Ranking `json:"ranking,omitempty"`
+	GeneratedAt string         `json:"generatedAt"`
+
+	uploadDir string
+}
+
+// reportSection 报告中的一个加分区块，Total 为按条例汇总封顶后的分值
+type reportSection struct {
+	Section string         `json:"section"`
+	Label   string         `json:"label"`
+	Raw     float64        `json:"raw"`
+	Total   float64        `json:"total"`
+	Cap     float64        `json:"cap"`
+	Records []reportRecord `json:"records"`
+}
+
+// reportRecord 一条审核通过的加分记录及其计入情况、审核签名与证明材料
+type reportRecord struct {
+	MaterialRecord
+	Counted float64           `json:"counted"`
+	Status  string            `json:"status"`
+	Reason  string            `json:"reason"`
+	Reviews []reportSignature `json:"reviews"`
+	// Files 证明材料在上传目录下的文件名
+	Files []string `json:"files"`
+}
+
+// reportSignature 审核人对材料给出的结论
+type reportSignature struct {
+	Reviewer string `json:"reviewer"`
+	Decision string `json:"decision"`
+	Date     string `json:"date"`
+}
+
+// reportFormat 个人加分汇总报告的一种导出格式，新增格式时加入 reportFormats
+type reportFormat interface {
+	ContentType() string
+	Extension() string
+	Render(w io.Writer, rep *studentReport) error
+}
+
+var reportFormats = map[string]reportFormat{
+	"pdf":  pdfReport{},
+	"json": jsonReport{},
+}
+
+// reviewSignatures 返回材料的审核结论，没有逐人审核意见的旧材料使用材料上的审核人与审核时间
+func (s *Server) reviewSignatures(ctx context.Context, m *store.Material) ([]reportSignature, error) {
+	reviews, err := s.store.MaterialReviews.ListByMaterial(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]reportSignature, 0, len(reviews))
+	for _, rv := range reviews {
+		if workflow.Decision(rv.Decision) == workflow.DecisionComment {
+			continue
+		}
+		list = append(list, reportSignature{Reviewer: rv.Reviewer, Decision: rv.Decision, Date: rv.CreatedAt})
+	}
+	if len(list) == 0 && m.Reviewer != "" {
+		list = append(list, reportSignature{Reviewer: m.Reviewer, Decision: string(workflow.DecisionApprove), Date: m.ReviewTime})
+	}
+	return list, nil
+}
+
+// buildStudentReport 汇总学生的资料、审核通过的加分记录、封顶后的总分与排名
+func (s *Server) buildStudentReport(ctx context.Context, accountId string) (*studentReport, error) {
+	rep := &studentReport{
+		AccountId:   accountId,
+		GeneratedAt: store.Now(),
+		uploadDir:   s.cfg.Upload.Dir,
+	}
+	st, err := s.store.Students.Get(ctx, accountId)
+	switch {
+	case err == nil:
+		rep.Name, rep.Major, rep.Class, rep.GPA = st.Name, st.Major, st.Class, st.GPA
+	case errors.Is(err, store.ErrNotFound):
+		if u, err := s.store.Users.GetByAccountId(ctx, accountId); err == nil {
+			rep.Name = u.Name
+		}
+	default:
+		return nil, err
+	}
+
+	reg, err := s.regulationFor(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+	rep.RegulationCode = reg.set.Code
+	records, err := s.store.MaterialRecords.ListByAccount(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+	summary := s.bonusTotals(records, reg)
+	rep.Total = summary.Total
+
+	rep.Sections = []reportSection{
+		{Section: bonusTypeAcademic, Label: bonusCategoryAcademic, Raw: summary.Academic.Raw, Total: summary.Academic.Total, Cap: summary.Academic.Cap, Records: make([]reportRecord, 0)},
+		{Section: bonusTypeComprehensive, Label: bonusCategoryComprehensive, Raw: summary.Comprehensive.Raw, Total: summary.Comprehensive.Total, Cap: summary.Comprehensive.Cap, Records: make([]reportRecord, 0)},
+	}
+	for i, item := range summary.Items {
+		m, err := s.store.Materials.Get(ctx, records[i].MaterialId)
+		if err != nil {
+			return nil, err
+		}
+		reviews, err := s.reviewSignatures(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		rr := reportRecord{
+			MaterialRecord: records[i],
+			Counted:        item.Counted,
+			Status:         item.Status,
+			Reason:         item.Reason,
+			Reviews:        reviews,
+			Files:          uploadNames(m.Files),
+		}
+		section := &rep.Sections[0]
+		if item.Section == bonusTypeComprehensive {
+			section = &rep.Sections[1]
+		}
+		section.Records = append(section.Records, rr)
+	}
+
+	rk, err := s.store.Rankings.Get(ctx, accountId)
+	if err == nil {
+		rep.Ranking = rk
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// jsonReport 以 JSON 输出报告内容，供前端预览
+type jsonReport struct{}
+
+func (jsonReport) ContentType() string { return "application/json" }
+func (jsonReport) Extension() string   { return "json" }
+func (jsonReport) Render(w io.Writer, rep *studentReport) error {
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": rep,
+	})
+}
+
+// pdfReport 输出可打印的 PDF 报告，证明材料中的图片以缩略图嵌入
+type pdfReport struct{}
+
+func (pdfReport) ContentType() string { return "application/pdf" }
+func (pdfReport) Extension() string   { return "pdf" }
+
+// 报告版面，单位为点
+const (
+	reportMargin    = 50.0
+	reportBottom    = pdf.PageHeight - 60
+	reportThumbW    = 110.0
+	reportThumbH    = 82.0
+	reportThumbPx   = 320
+	reportFontSize  = 10.5
+	reportLineSpace = 16.0
+)
+
+// reportPage 按行写入报告，空间不足时换页并写页码
+type reportPage struct {
+	doc *pdf.Document
+	y   float64
+}
+
+func (p *reportPage) newPage() {
+	p.doc.AddPage()
+	footer := fmt.Sprintf("第 %d 页", p.doc.PageCount())
+	p.doc.Text((pdf.PageWidth-pdf.TextWidth(footer, 9))/2, pdf.PageHeight-30, 9, footer)
+	p.y = reportMargin
+}
+
+// ensure 当前页剩余高度不足 h 时换页
+func (p *reportPage) ensure(h float64) {
+	if p.doc.PageCount() == 0 || p.y+h > reportBottom {
+		p.newPage()
+	}
+}
+
+// text 从 x 开始写一段文字，超出右边距时换行
+func (p *reportPage) text(x, size float64, s string) {
+	for _, line := range pdf.Wrap(s, size, pdf.PageWidth-reportMargin-x) {
+		p.ensure(reportLineSpace)
+		p.y += reportLineSpace
+		p.doc.Text(x, p.y, size, line)
+	}
+}
+
+// thumbnails 依次绘制证明材料中的图片缩略图，之后列出无法作为图片解码的附件文件名
+func (p *reportPage) thumbnails(dir string, files []string) {
+	x := reportMargin + 14
+	others := make([]string, 0)
+	for _, name := range files {
+		img, err := loadImage(filepath.Join(dir, name))
+		if err != nil {
+			others = append(others, name)
+			continue
+		}
+		id, err := p.doc.AddImage(pdf.Thumbnail(img, reportThumbPx, reportThumbPx))
+		if err != nil {
+			log.Printf("[导出] 嵌入图片 %s 失败: %v", name, err)
+			continue
+		}
+		pw, ph := p.doc.ImageSize(id)
+		w, h := reportThumbW, reportThumbW*float64(ph)/float64(pw)
+		if h > reportThumbH {
+			w, h = reportThumbH*float64(pw)/float64(ph), reportThumbH
+		}
+		if x+w > pdf.PageWidth-reportMargin {
+			x = reportMargin + 14
+			p.y += reportThumbH + 6
+		}
+		if p.y+6+reportThumbH > reportBottom {
+			p.newPage()
+			x = reportMargin + 14
+		}
+		p.doc.DrawImage(id, x, p.y+6, w, h)
+		x += w + 8
+	}
+	if x > reportMargin+14 {
+		p.y += reportThumbH + 6
+	}
+	for _, name := range others {
+		p.text(reportMargin+14, 9, "附件："+name)
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func decisionLabel(decision string) string {
+	switch workflow.Decision(decision) {
+	case workflow.DecisionApprove:
+		return "通过"
+	case workflow.DecisionReject:
+		return "不通过"
+	}
+	return decision
+}
+
+func (pdfReport) Render(w io.Writer, rep *studentReport) error {
+	doc := pdf.New()
+	p := &reportPage{doc: doc}
+	p.newPage()
+
+	title := "推荐免试攻读硕士学位研究生个人成果加分汇总表"
+	doc.Text((pdf.PageWidth-pdf.TextWidth(title, 16))/2, p.y+16, 16, title)
+	p.y += 28
+
+	gpa := "未录入"
+	if rep.GPA > 0 {
+		gpa = scoring.Format(rep.GPA)
+	}
+	p.text(reportMargin, reportFontSize, fmt.Sprintf("学号：%s　　姓名：%s　　专业：%s　　班级：%s", rep.AccountId, rep.Name, rep.Major, rep.Class))
+	p.text(reportMargin, reportFontSize, fmt.Sprintf("推免绩点：%s　　适用条例：%s　　生成时间：%s", gpa, rep.RegulationCode, rep.GeneratedAt))
+	p.y += 6
+	doc.Line(reportMargin, p.y, pdf.PageWidth-reportMargin, p.y, 0.8)
+
+	for _, section := range rep.Sections {
+		p.y += 8
+		p.text(reportMargin, 12, fmt.Sprintf("%s（学院核定 %s 分，满分 %s 分）", section.Label, scoring.Format(section.Total), scoring.Format(section.Cap)))
+		if len(section.Records) == 0 {
+			p.text(reportMargin+14, reportFontSize, "无审核通过的加分记录")
+		}
+		for i, rec := range section.Records {
+			p.ensure(4 * reportLineSpace)
+			p.text(reportMargin+4, reportFontSize, fmt.Sprintf("%d. %s", i+1, rec.Project))
+			team, order := teamColumns(&rec.MaterialRecord)
+			if order != "" {
+				team += "，第 " + order + " 位"
+			}
+			p.text(reportMargin+14, reportFontSize, fmt.Sprintf("获奖时间：%s　　奖项级别：%s　　个人或集体：%s", rec.AwardDate, rec.AwardType, team))
+			p.text(reportMargin+14, reportFontSize, "加分依据："+rec.ScoreBasis)
+			counted := fmt.Sprintf("学院核定加分：%s　　计入：%s", scoring.Format(rec.CollegeScore), scoring.Format(rec.Counted))
+			if rec.Reason != "" {
+				counted += "（" + rec.Reason + "）"
+			}
+			p.text(reportMargin+14, reportFontSize, counted)
+			for _, rv := range rec.Reviews {
+				p.text(reportMargin+14, reportFontSize, fmt.Sprintf("审核人：%s　　结论：%s　　日期：%s", rv.Reviewer, decisionLabel(rv.Decision), rv.Date))
+			}
+			p.thumbnails(rep.uploadDir, rec.Files)
+		}
+	}
+
+	p.y += 8
+	p.ensure(6 * reportLineSpace)
+	doc.Line(reportMargin, p.y, pdf.PageWidth-reportMargin, p.y, 0.8)
+	p.text(reportMargin, 12, fmt.Sprintf("考核综合成绩总分：%s 分", scoring.Format(rep.Total)))
+	if rep.Ranking != nil {
+		rank := fmt.Sprintf("推免综合成绩：%s　　专业排名：%d / %d", scoring.Format(rep.Ranking.Composite), rep.Ranking.Rank, rep.Ranking.Total)
+		if rep.Ranking.Tied {
+			rank += "（并列）"
+		}
+		p.text(reportMargin, reportFontSize, rank)
+	}
+	p.y += 24
+	p.text(reportMargin, reportFontSize, "本人确认以上成果真实有效。")
+	p.y += 12
+	p.text(reportMargin, reportFontSize, "学生签名：____________________　　日期：____________________")
+	p.y += 12
+	p.text(reportMargin, reportFontSize, "学院审核：____________________　　日期：____________________")
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// ExportReportHandler 导出一名学生的个人成果加分汇总报告，format 默认为 pdf
+// 学生只能导出自己的报告，拥有导出权限的用户可通过 accountId 指定学生
+func (s *Server) ExportReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	accountId := strings.TrimSpace(q.Get("accountId"))
+	if accountId == "" {
+		accountId = principal.AccountId
+	}
+	if accountId != principal.AccountId && !hasPermission(principal.Role, permExport) {
+		s.recordAudit(principal, r, permExport, "denied")
+		http.Error(w, "Forbidden: missing permission "+permExport, http.StatusForbidden)
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(q.Get("format")))
+	if name == "" {
+		name = "pdf"
+	}
+	format, ok := reportFormats[name]
+	if !ok {
+		http.Error(w, "Unsupported format: "+name, http.StatusBadRequest)
+		return
+	}
+
+	rep, err := s.buildStudentReport(r.Context(), accountId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("查询数据失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if name != "json" {
+		filename := fmt.Sprintf("成果加分汇总表_%s_%s.%s", accountId, rep.Name, format.Extension())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"report_%s_%d.%s\"; filename*=UTF-8''%s", accountId, time.Now().Unix(), format.Extension(), url.PathEscape(filename)))
+	}
+	s.extendWriteDeadline(w)
+	if err := format.Render(w, rep); err != nil {
+		log.Printf("[导出] 生成学生 %s 的报告失败: %v", accountId, err)
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// healthCheckTimeout 单项依赖检查的超时
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck 单项依赖检查结果
+type HealthCheck struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+type healthProbe struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// livenessProbes 本地依赖：数据库与上传目录，失败时重启进程可能恢复
+func (s *Server) livenessProbes() []healthProbe {
+	return []healthProbe{
+		{"database", s.checkDatabase},
+		{"upload", s.checkUploadDir},
+	}
+}
+
+// readinessProbes 在本地依赖之外检查外部服务，失败时应暂停向本实例转发流量
+func (s *Server) readinessProbes() []healthProbe {
+	return append(s.livenessProbes(),
+		healthProbe{"llm", s.checkLLM},
+		healthProbe{"bridge", s.checkBridge},
+	)
+}
+
+func (s *Server) checkDatabase(ctx context.Context) error {
+	return s.store.DB.PingContext(ctx)
+}
+
+// checkUploadDir 确认上传目录存在且可写
+func (s *Server) checkUploadDir(ctx context.Context) error {
+	info, err := os.Stat(s.cfg.Upload.Dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s 不是目录", s.cfg.Upload.Dir)
+	}
+	f, err := os.CreateTemp(s.cfg.Upload.Dir, ".healthz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// checkLLM 确认模型凭据已配置且接口地址可达，不发起计费请求
+func (s *Server) checkLLM(ctx context.Context) error {
+	if s.cfg.LLM.APIKey == "" {
+		return errors.New("未配置 API 密钥")
+	}
+	u, err := url.Parse(s.cfg.LLM.BaseURL)
+	if err != nil {
+		return err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkBridge 确认桥接服务可以完成 WebSocket 握手
+func (s *Server) checkBridge(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.cfg.Bridge.URL, nil)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// runProbes 并发执行检查，返回结果与是否全部通过
+func runProbes(ctx context.Context, probes []healthProbe) ([]HealthCheck, bool) {
+	results := make([]HealthCheck, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p healthProbe) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := p.check(cctx)
+			results[i] = HealthCheck{Name: p.name, OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, p)
+	}
+	wg.Wait()
+
+	ok := true
+	for _, r := range results {
+		ok = ok && r.OK
+	}
+	return results, ok
+}
+
+func writeHealth(w http.ResponseWriter, status string, ok bool, checks []HealthCheck) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// HealthzHandler 存活检查：数据库可连接且上传目录可写
+func (s *Server) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	checks, ok := runProbes(r.Context(), s.livenessProbes())
+	status := "ok"
+	if !ok {
+		status = "unhealthy"
+	}
+	writeHealth(w, status, ok, checks)
+}
+
+// ReadyzHandler 就绪检查：在存活检查之外确认大模型与桥接服务可用，关闭过程中始终返回 503
+func (s *Server) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if s.Draining() {
+		writeHealth(w, "draining", false, []HealthCheck{})
+		return
+	}
+	checks, ok := runProbes(r.Context(), s.readinessProbes())
+	status := "ready"
+	if !ok {
+		status = "not ready"
+	}
+	writeHealth(w, status, ok, checks)
+}
+
+// RegisterHealthRoutes 注册健康检查路由，无需登录
+func (s *Server) RegisterHealthRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", s.HealthzHandler)
+	mux.HandleFunc("/readyz", s.ReadyzHandler)
+}
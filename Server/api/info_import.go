@@ -2,25 +2,33 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
-	"time"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/store"
 	"github.com/xuri/excelize/v2"
 )
 
-// UploadDir 临时文件保存目录
-var UploadDir = filepath.Join(os.TempDir(), "hci_info_import")
+// afterStudentImport 名单或成绩单导入后在后台重算全部专业的排名
+func (s *Server) afterStudentImport() {
+	s.goBackground("ranking-import", func(context.Context) {
+		if _, err := s.recomputeRankings(context.Background(), nil); err != nil {
+			log.Printf("[专业排名] 导入后重算排名失败: %v", err)
+		}
+	})
+}
 
-// 导入 Excel 文件
-func ImportExcelHandler(w http.ResponseWriter, r *http.Request) {
+// 导入学生名单 Excel 文件
+// 表头按列名（中文或英文别名）识别，可位于任意工作表的前几行，sheet 指定时只读取该工作表
+// 先校验全部行：dryRun=true 时只返回预览；否则存在校验错误时不导入任何数据，全部通过时在一个事务中按学号新增或更新
+func (s *Server) ImportExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -37,7 +45,8 @@ func ImportExcelHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	ext := filepath.Ext(handler.Filename)
+	w.Header().Set("Content-Type", "application/json")
+	ext := strings.ToLower(filepath.Ext(handler.Filename))
 	if ext != ".xls" && ext != ".xlsx" {
 		resp := map[string]interface{}{
 			"code":    400,
@@ -47,84 +56,84 @@ func ImportExcelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	os.MkdirAll(UploadDir, 0755)
-	dstPath := filepath.Join(UploadDir, fmt.Sprintf("%d_%s", time.Now().Unix(), handler.Filename))
-	dst, _ := os.Create(dstPath)
-	defer dst.Close()
-	io.Copy(dst, file)
+	xlFile, err := excelize.OpenReader(file)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": fmt.Sprintf("Excel 解析错误: %v", err),
+		})
+		return
+	}
+	defer xlFile.Close()
 
-	// 解析 Excel 并写入数据库
-	importedRows := 0
-	errors := make([]string, 0)
-	func() {
-		// 打开 Excel 文件
-		xlFile, err := excelize.OpenFile(dstPath)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Excel 解析错误: %v", err))
-			return
-		}
-		rows, err := xlFile.GetRows("Sheet1")
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("读取表格错误: %v", err))
-			return
-		}
+	preview, err := s.previewStudentImport(r.Context(), xlFile, strings.TrimSpace(r.FormValue("sheet")))
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
 
-		// 连接数据库
-		db, err := sql.Open("sqlite3", "./app.db")
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("数据库连接失败: %v", err))
-			return
-		}
-		defer db.Close()
+	s.finishStudentImport(w, r, preview, "Excel 文件已成功导入")
+}
 
-		// 确保 students 表存在
-		createTableSQL := `CREATE TABLE IF NOT EXISTS students (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT,
-			studentId TEXT,
-			major TEXT,
-			class TEXT,
-			score REAL
-		);`
-		if _, err := db.Exec(createTableSQL); err != nil {
-			errors = append(errors, fmt.Sprintf("创建表失败: %v", err))
-			return
-		}
+// finishStudentImport 按预览完成名单导入并写出响应
+// dryRun=true 时只返回预览；存在校验错误时不导入任何数据；全部通过时在一个事务中按学号新增或更新
+func (s *Server) finishStudentImport(w http.ResponseWriter, r *http.Request, preview *importPreview, message string) {
+	if r.FormValue("dryRun") == "true" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    200,
+			"message": "预览生成成功，未写入数据",
+			"data": map[string]interface{}{
+				"committed": false,
+				"preview":   preview,
+			},
+		})
+		return
+	}
+	if preview.Invalid > 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": fmt.Sprintf("%d 行数据校验未通过，未导入任何数据", preview.Invalid),
+			"data": map[string]interface{}{
+				"committed": false,
+				"preview":   preview,
+			},
+		})
+		return
+	}
 
-		// 遍历 Excel 行
-		for i, row := range rows {
-			if i == 0 {
-				continue // 跳过表头
-			}
-			if len(row) < 6 {
-				errors = append(errors, fmt.Sprintf("第 %d 行数据不足6列", i+1))
-				continue
-			}
-			scoreVal, _ := strconv.ParseFloat(row[5], 64)
-			_, err := db.Exec(`INSERT INTO students (name, studentId, major, class, score) VALUES (?, ?, ?, ?, ?)`,
-				row[1], row[2], row[3], row[4], scoreVal)
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("插入第 %d 行失败: %v", i+1, err))
-				continue
-			}
-			importedRows++
-		}
-	}()
+	created, updated, err := s.commitStudentImport(r.Context(), preview)
+	if err != nil {
+		http.Error(w, "Import error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if created+updated > 0 {
+		s.afterStudentImport()
+	}
 
 	resp := map[string]interface{}{
 		"code":    200,
-		"message": "Excel 文件已成功导入",
+		"message": message,
 		"data": map[string]interface{}{
-			"rows":   importedRows,
-			"errors": errors,
+			"committed": true,
+			"rows":      created + updated,
+			// lines 与 rows 相同，保留旧版 TXT 导入接口的字段
+			"lines":   created + updated,
+			"created": created,
+			"updated": updated,
+			"errors":  []string{},
+			"preview": preview,
 		},
 	}
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
 // 导入 TXT 文件或直接文本
-func ImportTxtHandler(w http.ResponseWriter, r *http.Request) {
+// 每行一名学生，字段以制表符或逗号分隔；首行为表头时按列名识别，否则按旧版列顺序（序号、姓名、学号、专业、班级、成绩、毕业年份、推免绩点）读取
+// 校验与写入规则与 Excel 名单相同，支持 dryRun
+func (s *Server) ImportTxtHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -134,147 +143,219 @@ func ImportTxtHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var lines int
-	errors := make([]string, 0)
-
-	// splitLines 按行分割字符串
-	splitLines := func(s string) []string {
-		var result []string
-		current := ""
-		for _, r := range s {
-			if r == '\n' || r == '\r' {
-				if current != "" {
-					result = append(result, current)
-					current = ""
-				}
-			} else {
-				current += string(r)
-			}
+	w.Header().Set("Content-Type", "application/json")
+	var text string
+	file, handler, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+		if strings.ToLower(filepath.Ext(handler.Filename)) != ".txt" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"code":    400,
+				"message": "TXT 文件格式错误或内容为空",
+			})
+			return
 		}
-		if current != "" {
-			result = append(result, current)
+		content, err := io.ReadAll(file)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"code":    400,
+				"message": fmt.Sprintf("读取文件失败: %v", err),
+			})
+			return
 		}
-		return result
+		text = string(content)
+	} else {
+		// 如果 file 为空，尝试读取 text 字段内容
+		text = r.FormValue("text")
 	}
-
-	// parseFields 按逗号或制表符分割字段
-	parseFields := func(line string) []string {
-		if len(line) == 0 {
-			return []string{}
-		}
-		// 优先按逗号分割
-		if fields := filepath.SplitList(line); len(fields) > 1 {
-			return fields
-		}
-		// 按制表符分割
-		return filepath.SplitList(line)
+	if strings.TrimSpace(text) == "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": "TXT 文件格式错误或内容为空",
+		})
+		return
 	}
 
-	// 连接数据库
-	db, err := sql.Open("sqlite3", "./app.db")
+	preview, err := s.previewTxtImport(r.Context(), text)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("数据库连接失败: %v", err), http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": err.Error(),
+		})
 		return
 	}
-	defer db.Close()
+	s.finishStudentImport(w, r, preview, "导入成功")
+}
+
+// transcriptRetaken 识别成绩单「是否重修」列
+func transcriptRetaken(cell string) bool {
+	switch strings.ToLower(strings.TrimSpace(cell)) {
+	case "是", "重修", "y", "yes", "1", "true":
+		return true
+	}
+	return false
+}
 
-	// 确保 students 表存在
-	createTableSQL := `CREATE TABLE IF NOT EXISTS students (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		studentId TEXT,
-		major TEXT,
-		class TEXT,
-		score REAL
-	);`
-	if _, err := db.Exec(createTableSQL); err != nil {
-		http.Error(w, fmt.Sprintf("创建表失败: %v", err), http.StatusInternalServerError)
+// 导入成绩单 Excel 文件
+// 表头按列名识别（学号、学期、课程名称、学分、成绩（百分制）、是否重修），可位于任意工作表的前几行，sheet 指定时只读取该工作表
+// 只需导入参与推免成绩计算的课程；成绩不是数字的课程（如两级计分制）只参与重修门次判断
+// 每次导入以文件内容替换涉及学生的全部成绩单，学期为必填列，同一课程多次修读按不同学期各占一行
+func (s *Server) ImportTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseMultipartForm(50 << 20); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
 	file, handler, err := r.FormFile("file")
-	if err == nil {
-		defer file.Close()
-		ext := filepath.Ext(handler.Filename)
-		if ext != ".txt" {
-			resp := map[string]interface{}{
+	if err != nil {
+		http.Error(w, "Missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	ext := strings.ToLower(filepath.Ext(handler.Filename))
+	if ext != ".xls" && ext != ".xlsx" {
+		resp := map[string]interface{}{
+			"code":    400,
+			"message": "文件格式错误，仅支持 .xls/.xlsx",
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	xlFile, err := excelize.OpenReader(file)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": fmt.Sprintf("Excel 解析错误: %v", err),
+		})
+		return
+	}
+	defer xlFile.Close()
+
+	_, rows, headerIdx, err := findHeader(xlFile, strings.TrimSpace(r.FormValue("sheet")), transcriptImportSchema)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+	columns, _ := mapHeader(rows[headerIdx], transcriptImportSchema)
+	for _, col := range transcriptImportSchema {
+		if _, ok := columns[col.Key]; !ok && col.Required {
+			json.NewEncoder(w).Encode(map[string]interface{}{
 				"code":    400,
-				"message": "TXT 文件格式错误或内容为空",
-			}
-			json.NewEncoder(w).Encode(resp)
+				"message": fmt.Sprintf("表头缺少「%s」列", col.Label),
+			})
 			return
 		}
-		os.MkdirAll(UploadDir, 0755)
-		dstPath := filepath.Join(UploadDir, fmt.Sprintf("%d_%s", time.Now().Unix(), handler.Filename))
-		dst, _ := os.Create(dstPath)
-		defer dst.Close()
-		io.Copy(dst, file)
+	}
+	s.importTranscriptRows(w, r, rows[headerIdx+1:], headerIdx+2, columns)
+}
 
-		// 读取 TXT 文件并解析
-		content, err := os.ReadFile(dstPath)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("读取文件失败: %v", err))
-		} else {
-			linesArr := splitLines(string(content))
-			for i, line := range linesArr {
-				fields := parseFields(line)
-				if len(fields) < 6 {
-					errors = append(errors, fmt.Sprintf("第 %d 行数据不足6列", i+1))
-					continue
-				}
-				scoreVal, _ := strconv.ParseFloat(fields[5], 64)
-				_, err := db.Exec(`INSERT INTO students (name, studentId, major, class, score) VALUES (?, ?, ?, ?, ?)`,
-					fields[1], fields[2], fields[3], fields[4], scoreVal)
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("插入第 %d 行失败: %v", i+1, err))
-					continue
-				}
-				lines++
-			}
+// importTranscriptRows 校验成绩单各行并写出响应，firstRow 为 rows[0] 在表格中的行号
+// 任一行有误时不写入任何数据；全部通过时在同一事务中以文件内容替换涉及学生的全部成绩单，
+// 更正后重新导入可以移除之前导入的错误课程与重修标记
+func (s *Server) importTranscriptRows(w http.ResponseWriter, r *http.Request, rows [][]string, firstRow int, columns map[string]int) {
+	errors := make([]string, 0)
+	students := make([]string, 0)
+	byStudent := make(map[string][]store.TranscriptCourse)
+	// 同一课程多次修读按学期区分，同一学期的同名课程出现两次时无法判断哪一行有效
+	seen := make(map[[3]string]int)
+	for i, cells := range rows {
+		row := previewRow(cells, columns)
+		if row == nil {
+			continue
 		}
-	} else {
-		// 如果 file 为空，尝试读取 text 字段内容
-		text := r.FormValue("text")
-		if len(text) == 0 {
-			resp := map[string]interface{}{
-				"code":    400,
-				"message": "TXT 文件格式错误或内容为空",
-			}
-			json.NewEncoder(w).Encode(resp)
-			return
+		line, v := firstRow+i, row.Values
+		course := store.TranscriptCourse{
+			StudentId: v["studentId"],
+			Term:      v["term"],
+			Course:    v["course"],
 		}
-		linesArr := splitLines(text)
-		for i, line := range linesArr {
-			fields := parseFields(line)
-			if len(fields) < 6 {
-				errors = append(errors, fmt.Sprintf("第 %d 行数据不足6列", i+1))
-				continue
-			}
-			scoreVal, _ := strconv.ParseFloat(fields[5], 64)
-			_, err := db.Exec(`INSERT INTO students (name, studentId, major, class, score) VALUES (?, ?, ?, ?, ?)`,
-				fields[1], fields[2], fields[3], fields[4], scoreVal)
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("插入第 %d 行失败: %v", i+1, err))
-				continue
+		if course.StudentId == "" || course.Term == "" || course.Course == "" {
+			errors = append(errors, fmt.Sprintf("第 %d 行缺少学号、学期或课程名称", line))
+			continue
+		}
+		key := [3]string{course.StudentId, course.Term, course.Course}
+		if prev, ok := seen[key]; ok {
+			errors = append(errors, fmt.Sprintf("第 %d 行与第 %d 行为同一学生同一学期的同名课程", line, prev))
+			continue
+		}
+		seen[key] = line
+		credit, err := strconv.ParseFloat(v["credit"], 64)
+		if err != nil || credit < 0 {
+			errors = append(errors, fmt.Sprintf("第 %d 行学分 %q 无效", line, v["credit"]))
+			continue
+		}
+		course.Credit = credit
+		if score, err := strconv.ParseFloat(v["score"], 64); err == nil {
+			course.Score, course.Graded = score, true
+		}
+		course.Retaken = transcriptRetaken(v["retaken"])
+		if _, ok := byStudent[course.StudentId]; !ok {
+			students = append(students, course.StudentId)
+		}
+		byStudent[course.StudentId] = append(byStudent[course.StudentId], course)
+	}
+	if len(errors) > 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    400,
+			"message": fmt.Sprintf("%d 行数据校验未通过，未导入任何数据", len(errors)),
+			"data": map[string]interface{}{
+				"errors": errors,
+			},
+		})
+		return
+	}
+
+	importedRows := 0
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		for _, id := range students {
+			if err := tx.Transcripts.ReplaceStudent(r.Context(), id, byStudent[id]); err != nil {
+				return fmt.Errorf("写入学生 %s 的成绩单失败: %w", id, err)
 			}
-			lines++
+			importedRows += len(byStudent[id])
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Import error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	gpaUpdated := 0
+	if len(students) > 0 {
+		n, err := s.refreshTranscriptGPA(r.Context(), students)
+		if err != nil {
+			errors = append(errors, err.Error())
 		}
+		gpaUpdated = n
+		s.afterStudentImport()
 	}
 
 	resp := map[string]interface{}{
 		"code":    200,
-		"message": "导入成功",
+		"message": "成绩单已成功导入",
 		"data": map[string]interface{}{
-			"lines":  lines,
-			"errors": errors,
+			"rows":       importedRows,
+			"students":   len(students),
+			"gpaUpdated": gpaUpdated,
+			"errors":     errors,
 		},
 	}
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
 // RegisterInfoImportRoutes 注册信息导入相关路由
-func RegisterInfoImportRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/info/import/excel", ImportExcelHandler)
-	mux.HandleFunc("/api/info/import/txt", ImportTxtHandler)
+func (s *Server) RegisterInfoImportRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/info/import/excel", s.ImportExcelHandler)
+	mux.HandleFunc("/api/info/import/txt", s.ImportTxtHandler)
+	mux.HandleFunc("/api/info/import/transcript", s.ImportTranscriptHandler)
 }
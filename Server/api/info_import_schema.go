@@ -0,0 +1,387 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/xuri/excelize/v2"
+)
+
+// importColumn 学生名单中可识别的一列，Aliases 为表头的中文或英文名称
+type importColumn struct {
+	Key      string
+	Label    string
+	Aliases  []string
+	Required bool
+}
+
+// studentImportSchema 学生名单的列定义，表头按别名识别，列的顺序不限
+var studentImportSchema = []importColumn{
+	{Key: "studentId", Label: "学号", Aliases: []string{"学号", "studentid", "studentno", "学生学号"}, Required: true},
+	{Key: "name", Label: "姓名", Aliases: []string{"姓名", "name", "学生姓名"}, Required: true},
+	{Key: "major", Label: "专业", Aliases: []string{"专业", "所在专业", "专业名称", "major"}},
+	{Key: "class", Label: "班级", Aliases: []string{"班级", "行政班", "class"}},
+	{Key: "score", Label: "成绩", Aliases: []string{"成绩", "score"}},
+	{Key: "graduationYear", Label: "毕业年份", Aliases: []string{"毕业年份", "毕业年级", "届别", "graduationyear", "cohort"}},
+	{Key: "gpa", Label: "推免绩点", Aliases: []string{"推免绩点", "绩点", "平均学分绩点", "gpa"}},
+}
+
+// transcriptImportSchema 成绩单的列定义，表头按别名识别，列的顺序不限
+var transcriptImportSchema = []importColumn{
+	{Key: "studentId", Label: "学号", Aliases: []string{"学号", "studentid", "studentno", "学生学号"}, Required: true},
+	{Key: "term", Label: "学期", Aliases: []string{"学期", "开课学期", "修读学期", "term", "semester"}, Required: true},
+	{Key: "course", Label: "课程名称", Aliases: []string{"课程名称", "课程", "课程名", "course", "coursename"}, Required: true},
+	{Key: "credit", Label: "学分", Aliases: []string{"学分", "credit", "credits"}, Required: true},
+	{Key: "score", Label: "成绩", Aliases: []string{"成绩", "总评成绩", "课程成绩", "score", "grade"}, Required: true},
+	{Key: "retaken", Label: "是否重修", Aliases: []string{"是否重修", "重修", "重修标记", "retaken", "retake"}},
+}
+
+// headerScanRows 在每张工作表的前几行中查找表头
+const headerScanRows = 10
+
+var headerNoise = regexp.MustCompile(`[（(][^）)]*[）)]|[\s_\-]`)
+
+// normalizeHeader 统一表头写法：忽略大小写、空白、下划线、连字符与括号中的说明
+func normalizeHeader(s string) string {
+	return strings.ToLower(headerNoise.ReplaceAllString(strings.TrimSpace(s), ""))
+}
+
+// mapHeader 按列定义识别一行表头，返回列名到列下标的映射与无法识别的表头
+// 同一列名出现多次时使用第一次出现的列
+func mapHeader(row []string, schema []importColumn) (map[string]int, []string) {
+	columns := make(map[string]int)
+	unmapped := make([]string, 0)
+	for i, cell := range row {
+		name := normalizeHeader(cell)
+		if name == "" {
+			continue
+		}
+		matched := false
+		for _, col := range schema {
+			if _, ok := columns[col.Key]; ok {
+				continue
+			}
+			for _, alias := range col.Aliases {
+				if name == normalizeHeader(alias) {
+					columns[col.Key], matched = i, true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			unmapped = append(unmapped, strings.TrimSpace(cell))
+		}
+	}
+	return columns, unmapped
+}
+
+// importPreviewRow 预览中的一行，Action 为 create 或 update，Errors 非空时该行不能导入
+type importPreviewRow struct {
+	Row    int               `json:"row"`
+	Values map[string]string `json:"values"`
+	Action string            `json:"action"`
+	Errors []string          `json:"errors"`
+
+	upsert store.StudentUpsert
+}
+
+// importPreview 名单导入的预览结果
+type importPreview struct {
+	Sheet     string `json:"sheet"`
+	HeaderRow int    `json:"headerRow"`
+	// Columns 识别出的列：列名到表头文字
+	Columns  map[string]string  `json:"columns"`
+	Unmapped []string           `json:"unmapped"`
+	Total    int                `json:"total"`
+	Valid    int                `json:"valid"`
+	Invalid  int                `json:"invalid"`
+	Rows     []importPreviewRow `json:"rows"`
+}
+
+// findHeader 按列定义在工作簿中查找表头，sheet 非空时只查找该工作表
+// 表头至少需要识别出学号列和另一列
+func findHeader(f *excelize.File, sheet string, schema []importColumn) (string, [][]string, int, error) {
+	sheets := f.GetSheetList()
+	if sheet != "" {
+		if idx, _ := f.GetSheetIndex(sheet); idx < 0 {
+			return "", nil, 0, fmt.Errorf("工作表 %q 不存在", sheet)
+		}
+		sheets = []string{sheet}
+	}
+	for _, name := range sheets {
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("读取工作表 %s 失败: %w", name, err)
+		}
+		for i := 0; i < len(rows) && i < headerScanRows; i++ {
+			columns, _ := mapHeader(rows[i], schema)
+			if _, ok := columns["studentId"]; ok && len(columns) >= 2 {
+				return name, rows, i, nil
+			}
+		}
+	}
+	return "", nil, 0, fmt.Errorf("未找到包含「学号」等列的表头")
+}
+
+// knownMajors 返回配置中可导入的专业，未配置时返回空集合，表示不校验专业
+func (s *Server) knownMajors() map[string]bool {
+	majors := make(map[string]bool)
+	for _, m := range s.cfg.Import.Majors {
+		if m = strings.TrimSpace(m); m != "" {
+			majors[m] = true
+		}
+	}
+	return majors
+}
+
+// legacyTxtColumns 没有表头的 TXT 名单沿用旧版的列顺序：序号、姓名、学号、专业、班级、成绩、毕业年份、推免绩点
+var legacyTxtColumns = map[string]int{"name": 1, "studentId": 2, "major": 3, "class": 4, "score": 5, "graduationYear": 6, "gpa": 7}
+
+// legacyTxtMinColumns 没有表头时每行至少需要的列数（到成绩列为止）
+const legacyTxtMinColumns = 6
+
+// newImportPreview 按表头建立预览，缺少必填列时返回错误
+func newImportPreview(header []string) (*importPreview, map[string]int, error) {
+	columns, unmapped := mapHeader(header, studentImportSchema)
+	preview := &importPreview{
+		Columns:  make(map[string]string, len(columns)),
+		Unmapped: unmapped,
+		Rows:     make([]importPreviewRow, 0),
+	}
+	for _, col := range studentImportSchema {
+		if i, ok := columns[col.Key]; ok {
+			preview.Columns[col.Key] = strings.TrimSpace(header[i])
+		} else if col.Required {
+			return nil, nil, fmt.Errorf("表头缺少「%s」列", col.Label)
+		}
+	}
+	return preview, columns, nil
+}
+
+// previewStudentImport 按列定义解析并校验 Excel 名单，不写入数据库
+func (s *Server) previewStudentImport(ctx context.Context, f *excelize.File, sheet string) (*importPreview, error) {
+	sheet, rows, headerIdx, err := findHeader(f, sheet, studentImportSchema)
+	if err != nil {
+		return nil, err
+	}
+	preview, columns, err := newImportPreview(rows[headerIdx])
+	if err != nil {
+		return nil, err
+	}
+	preview.Sheet, preview.HeaderRow = sheet, headerIdx+1
+	if err := s.validateImportRows(ctx, preview, rows[headerIdx+1:], headerIdx+2, columns, 0); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// splitTxtRow 按制表符或逗号（含全角逗号）分割一行 TXT 名单
+func splitTxtRow(line string) []string {
+	if strings.Contains(line, "\t") {
+		return strings.Split(line, "\t")
+	}
+	return strings.Split(strings.ReplaceAll(line, "，", ","), ",")
+}
+
+// previewTxtImport 按列定义解析并校验 TXT 名单，不写入数据库
+// 首个非空行能识别为表头时按列名读取，否则按 legacyTxtColumns 的旧版列顺序读取
+func (s *Server) previewTxtImport(ctx context.Context, text string) (*importPreview, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n"), "\n")
+	rows := make([][]string, len(lines))
+	first := -1
+	for i, line := range lines {
+		rows[i] = splitTxtRow(line)
+		if first < 0 && strings.TrimSpace(line) != "" {
+			first = i
+		}
+	}
+	if first < 0 {
+		return nil, errors.New("TXT 内容为空")
+	}
+
+	if columns, _ := mapHeader(rows[first], studentImportSchema); len(columns) >= 2 {
+		if _, ok := columns["studentId"]; ok {
+			preview, columns, err := newImportPreview(rows[first])
+			if err != nil {
+				return nil, err
+			}
+			preview.HeaderRow = first + 1
+			if err := s.validateImportRows(ctx, preview, rows[first+1:], first+2, columns, 0); err != nil {
+				return nil, err
+			}
+			return preview, nil
+		}
+	}
+
+	preview := &importPreview{Columns: make(map[string]string, len(legacyTxtColumns)), Unmapped: make([]string, 0), Rows: make([]importPreviewRow, 0)}
+	for key, i := range legacyTxtColumns {
+		preview.Columns[key] = fmt.Sprintf("第 %d 列", i+1)
+	}
+	if err := s.validateImportRows(ctx, preview, rows, 1, legacyTxtColumns, legacyTxtMinColumns); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// validateImportRows 校验数据行并追加到预览，firstRow 为 rows[0] 在文件中的行号
+// minCells 大于 0 时，列数不足的行记为错误
+func (s *Server) validateImportRows(ctx context.Context, preview *importPreview, rows [][]string, firstRow int, columns map[string]int, minCells int) error {
+	idPattern, err := regexp.Compile(s.cfg.Import.StudentIdPattern)
+	if err != nil {
+		return err
+	}
+	majors := s.knownMajors()
+	scale := s.rules.GPAScale
+	if scale <= 0 {
+		scale = scoring.DefaultRules().GPAScale
+	}
+
+	seen := make(map[string]int)
+	for i, cells := range rows {
+		row := previewRow(cells, columns)
+		if row == nil {
+			continue
+		}
+		row.Row = firstRow + i
+		if minCells > 0 && len(cells) < minCells {
+			row.Errors = append(row.Errors, fmt.Sprintf("数据不足 %d 列", minCells))
+		}
+		s.validateImportRow(ctx, row, idPattern, majors, scale)
+		if first, ok := seen[row.upsert.StudentId]; ok && row.upsert.StudentId != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("学号与第 %d 行重复", first))
+		} else {
+			seen[row.upsert.StudentId] = row.Row
+		}
+		preview.Total++
+		if len(row.Errors) == 0 {
+			preview.Valid++
+		} else {
+			preview.Invalid++
+		}
+		preview.Rows = append(preview.Rows, *row)
+	}
+	return nil
+}
+
+// previewRow 取出一行中已识别列的值，整行为空时返回 nil
+func previewRow(cells []string, columns map[string]int) *importPreviewRow {
+	row := &importPreviewRow{Values: make(map[string]string, len(columns)), Errors: make([]string, 0)}
+	empty := true
+	for key, i := range columns {
+		if i < len(cells) {
+			row.Values[key] = strings.TrimSpace(cells[i])
+			if row.Values[key] != "" {
+				empty = false
+			}
+		}
+	}
+	if empty {
+		return nil
+	}
+	return row
+}
+
+// validateImportRow 按列定义校验一行并生成待写入的数据，空单元格表示保留名单中已有的值
+func (s *Server) validateImportRow(ctx context.Context, row *importPreviewRow, idPattern *regexp.Regexp, majors map[string]bool, scale float64) {
+	v := row.Values
+	fail := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+	u := &row.upsert
+
+	u.StudentId = v["studentId"]
+	switch {
+	case u.StudentId == "":
+		fail("学号不能为空")
+	case !idPattern.MatchString(u.StudentId):
+		fail("学号 %q 格式不正确", u.StudentId)
+	}
+
+	row.Action = "update"
+	if u.StudentId != "" {
+		_, err := s.store.Students.Get(ctx, u.StudentId)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			row.Action = "create"
+		case err != nil:
+			fail("查询学号失败: %v", err)
+		}
+	}
+
+	if name := v["name"]; name != "" {
+		u.Name = &name
+	} else {
+		fail("姓名不能为空")
+	}
+	if major := v["major"]; major != "" {
+		if len(majors) > 0 && !majors[major] {
+			fail("未知专业 %q", major)
+		}
+		u.Major = &major
+	} else if row.Action == "create" {
+		fail("新增学生需填写专业")
+	}
+	if class := v["class"]; class != "" {
+		u.Class = &class
+	}
+	if raw := v["score"]; raw != "" {
+		score, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			fail("成绩 %q 不是数字", raw)
+		} else {
+			u.Score = &score
+		}
+	}
+	if raw := v["graduationYear"]; raw != "" {
+		year, err := strconv.Atoi(strings.TrimSuffix(raw, "届"))
+		if err != nil || year < 1900 || year > 2200 {
+			fail("毕业年份 %q 无效", raw)
+		} else {
+			u.GraduationYear = &year
+		}
+	}
+	if raw := v["gpa"]; raw != "" {
+		gpa, err := strconv.ParseFloat(raw, 64)
+		switch {
+		case err != nil:
+			fail("推免绩点 %q 不是数字", raw)
+		case gpa <= 0 || gpa > scale:
+			fail("推免绩点 %v 应在 0 到 %v 之间", gpa, scale)
+		default:
+			gpa = scoring.Round(gpa)
+			u.GPA = &gpa
+		}
+	}
+}
+
+// commitStudentImport 在一个事务中按学号写入预览中的全部行，返回新增与更新的人数
+func (s *Server) commitStudentImport(ctx context.Context, preview *importPreview) (int, int, error) {
+	created, updated := 0, 0
+	err := s.store.InTx(ctx, func(tx *store.Store) error {
+		created, updated = 0, 0
+		for i := range preview.Rows {
+			row := &preview.Rows[i]
+			isNew, err := tx.Students.Upsert(ctx, &row.upsert)
+			if err != nil {
+				return fmt.Errorf("写入第 %d 行失败: %w", row.Row, err)
+			}
+			if isNew {
+				created++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	return created, updated, err
+}
@@ -2,47 +2,35 @@ package api
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
 	"google.golang.org/genai"
 )
 
-var formPrompt string
-var guidelines string
-var calculatePrompt string
-var analyzePrompt string
-var BASE_URL string
-var API_KEY string
-
-func readFileElsePanic(path string) string {
-	file, err := os.Open(path)
+// newLLMClient 按配置创建大模型客户端，仅支持 Gemini 接口
+func (s *Server) newLLMClient(ctx context.Context) (*genai.Client, error) {
+	genai.SetDefaultBaseURLs(genai.BaseURLParameters{
+		GeminiURL: s.cfg.LLM.BaseURL,
+		VertexURL: s.cfg.LLM.BaseURL,
+	})
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey: s.cfg.LLM.APIKey,
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		panic(err)
+	if client.ClientConfig().Backend == genai.BackendVertexAI {
+		return nil, fmt.Errorf("Vertex AI backend is not supported for this operation")
 	}
-	return string(data)
-}
-
-func init() {
-	formPrompt = readFileElsePanic("./docs/识别文件.md")
-	BASE_URL = readFileElsePanic("./secret/BASE_URL")
-	API_KEY = readFileElsePanic("./secret/API_KEY")
-	guidelines = readFileElsePanic("./docs/保研条例.md")
-	calculatePrompt = readFileElsePanic("./docs/审核信息.md")
-	analyzePrompt = readFileElsePanic("./docs/信息分析.md")
+	return client, nil
 }
 
 type FormResult struct {
@@ -52,7 +40,7 @@ type FormResult struct {
 	Description string   `json:"description"`
 }
 
-func FormHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) FormHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -67,30 +55,28 @@ func FormHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
 	ctx := r.Context()
-	genai.SetDefaultBaseURLs(genai.BaseURLParameters{
-		GeminiURL: BASE_URL,
-		VertexURL: BASE_URL,
-	})
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: API_KEY,
-	})
+	reg, err := s.regulationFor(ctx, principal.AccountId)
 	if err != nil {
-		http.Error(w, "Failed to create LLM client: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	if client.ClientConfig().Backend == genai.BackendVertexAI {
-		http.Error(w, "Vertex AI backend is not supported for this operation", http.StatusInternalServerError)
+	client, err := s.newLLMClient(ctx)
+	if err != nil {
+		http.Error(w, "Failed to create LLM client: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	parts := []*genai.Part{
-		{Text: formPrompt},
-		{Text: "保研条例如下，请严格按照条例要求进行材料填写，否则不予通过。\n" + guidelines},
+		{Text: s.prompts.form},
+		{Text: reg.prompt()},
 	}
 	for _, fname := range req.Files {
-		filename := "./upload/" + fname
+		filename := s.uploadPath(fname)
 		fileBytes, err := os.ReadFile(filename)
 		if err != nil {
 			http.Error(w, "Failed to read file: "+err.Error(), http.StatusInternalServerError)
@@ -109,7 +95,7 @@ func FormHandler(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	result, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", []*genai.Content{{Parts: parts}}, nil)
+	result, err := client.Models.GenerateContent(ctx, s.cfg.LLM.Model, []*genai.Content{{Parts: parts}}, nil)
 	if err != nil {
 		http.Error(w, "LLM error: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -133,41 +119,40 @@ func FormHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func RegisterLLMRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/llm/form", FormHandler)
+func (s *Server) RegisterLLMRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/llm/form", s.FormHandler)
 }
 
+// LLMCalculateResult 上传时的预评分，AiScore 由规则引擎根据模型提取的 Facts 计算
 type LLMCalculateResult struct {
-	AiScore       float64 `json:"aiScore"`
-	AiConfidence  float64 `json:"aiConfidence"`
-	AiSuggestions string  `json:"aiSuggestions"`
-	AiRiskLevel   string  `json:"aiRiskLevel"`
+	Facts         scoring.Facts `json:"facts"`
+	AiScore       float64       `json:"aiScore"`
+	AiConfidence  float64       `json:"aiConfidence"`
+	AiSuggestions string        `json:"aiSuggestions"`
+	AiRiskLevel   string        `json:"aiRiskLevel"`
+	// AwardDate 模型从材料中识别的成果取得时间
+	AwardDate string `json:"awardDate"`
 }
 
-func CalculateScore(res *MaterialUploadRequest) (*LLMCalculateResult, error) {
+func (s *Server) CalculateScore(res *MaterialUploadRequest) (*LLMCalculateResult, error) {
 	ctx := context.Background()
-	genai.SetDefaultBaseURLs(genai.BaseURLParameters{
-		GeminiURL: BASE_URL,
-		VertexURL: BASE_URL,
-	})
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: API_KEY,
-	})
+	reg, err := s.regulationFor(ctx, res.AccountId)
 	if err != nil {
 		return nil, err
 	}
-
-	if client.ClientConfig().Backend == genai.BackendVertexAI {
-		return nil, fmt.Errorf("Vertex AI backend is not supported for this operation")
+	client, err := s.newLLMClient(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	parts := []*genai.Part{
-		{Text: calculatePrompt},
+		{Text: s.prompts.calculate},
+		{Text: s.prompts.facts},
 		{Text: fmt.Sprintf("材料标题：%s\n材料类别：%s\n材料标签：%v\n材料描述：%s\n", res.Title, res.Category, res.Tags, res.Description)},
-		{Text: "保研条例如下，请严格按照条例要求进行材料填写，否则不予通过。\n" + guidelines},
+		{Text: reg.prompt()},
 	}
 	for _, fname := range res.Files {
-		filename := "./upload/" + fname
+		filename := s.uploadPath(fname)
 		fileBytes, err := os.ReadFile(filename)
 		if err != nil {
 			return nil, err
@@ -185,7 +170,7 @@ func CalculateScore(res *MaterialUploadRequest) (*LLMCalculateResult, error) {
 		})
 	}
 
-	result, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", []*genai.Content{{Parts: parts}}, nil)
+	result, err := client.Models.GenerateContent(ctx, s.cfg.LLM.Model, []*genai.Content{{Parts: parts}}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -201,23 +186,25 @@ func CalculateScore(res *MaterialUploadRequest) (*LLMCalculateResult, error) {
 		return nil, err
 	}
 
+	facts, scored, err := s.scoreFacts(ctx, reg, score.Facts)
+	if err != nil && !errors.Is(err, scoring.ErrInvalidFacts) {
+		return nil, err
+	}
+	score.Facts = facts
+	if err != nil {
+		// 要素不完整时不给出预评分，提示人工核定
+		score.AiScore = 0
+		score.AiRiskLevel = "high"
+		score.AiSuggestions = strings.TrimPrefix(score.AiSuggestions+","+err.Error(), ",")
+		return &score, nil
+	}
+	score.AiScore = scored.Points
+
 	return &score, nil
 }
 
-type MaterialRecord struct {
-	MaterialId   string  `json:"materialId"`
-	AccountId    string  `json:"accountId"`
-	Type         string  `json:"type"`
-	Category     string  `json:"category"`
-	Id           string  `json:"id"`
-	Project      string  `json:"project"`
-	AwardDate    string  `json:"awardDate"`
-	AwardType    string  `json:"awardType"`
-	TeamRank     string  `json:"teamRank"`
-	SelfScore    float64 `json:"selfScore"`
-	ScoreBasis   string  `json:"scoreBasis"`
-	CollegeScore float64 `json:"collegeScore"`
-}
+// MaterialRecord 模型根据材料整理出的加分记录
+type MaterialRecord = store.MaterialRecord
 
 type MaterialFile struct {
 	FileURL  string `json:"fileUrl"`
@@ -242,153 +229,25 @@ type MaterialDetail struct {
 	AiConfidence  float64        `json:"aiConfidence"`
 	AiSuggestions []string       `json:"aiSuggestions"`
 	AiRiskLevel   string         `json:"aiRiskLevel"`
+	AwardDate     string         `json:"awardDate"`
 }
 
-func materialRecordExists(materialId string) (bool, error) {
-	materialId = strings.TrimSpace(materialId)
-	if materialId == "" {
-		return false, errors.New("materialId is required")
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		return false, err
-	}
-	defer db.Close()
-
-	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS material_records (
-	materialId TEXT PRIMARY KEY,
-	accountId TEXT,
-	type TEXT,
-	category TEXT,
-	id TEXT,
-	project TEXT,
-	awardDate TEXT,
-	awardType TEXT,
-	teamRank TEXT,
-	selfScore REAL,
-	scoreBasis TEXT,
-	collegeScore REAL
-)`); err != nil {
-		return false, err
-	}
-
-	var count int
-	if err := db.QueryRow(`SELECT COUNT(1) FROM material_records WHERE materialId = ?`, materialId).Scan(&count); err != nil {
-		return false, err
-	}
-
-	return count > 0, nil
-}
-
-func saveMaterialRecord(record *MaterialRecord) error {
-	if record == nil {
-		return errors.New("record is nil")
-	}
-	if strings.TrimSpace(record.MaterialId) == "" {
-		return errors.New("record.MaterialId is required")
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	const createTableSQL = `CREATE TABLE IF NOT EXISTS material_records (
-	materialId TEXT PRIMARY KEY,
-	accountId TEXT,
-	type TEXT,
-	category TEXT,
-	id TEXT,
-	project TEXT,
-	awardDate TEXT,
-	awardType TEXT,
-	teamRank TEXT,
-	selfScore REAL,
-	scoreBasis TEXT,
-	collegeScore REAL
-)`
-
-	if _, err := db.Exec(createTableSQL); err != nil {
-		return err
-	}
-
-	_, err = db.Exec(`INSERT OR REPLACE INTO material_records (
-	materialId, accountId, type, category, id, project, awardDate, awardType, teamRank, selfScore, scoreBasis, collegeScore
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-
-		record.MaterialId,
-		record.AccountId,
-		record.Type,
-		record.Category,
-		record.Id,
-		record.Project,
-		record.AwardDate,
-		record.AwardType,
-		record.TeamRank,
-		record.SelfScore,
-		record.ScoreBasis,
-		record.CollegeScore,
-	)
-	return err
-}
-
-func getMaterialDetail(materialID string) (*MaterialDetail, error) {
+func (s *Server) getMaterialDetail(materialID string) (*MaterialDetail, error) {
 	materialID = strings.TrimSpace(materialID)
 	if materialID == "" {
 		return nil, errors.New("materialID is required")
 	}
 
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	const createTableSQL = `CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		category TEXT,
-		tags TEXT,
-		files TEXT,
-		status TEXT,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT,
-		reviewComment TEXT,
-		aiScore REAL,
-		aiConfidence REAL,
-		aiSuggestions TEXT,
-		aiRiskLevel TEXT
-	)`
-	if _, err := db.Exec(createTableSQL); err != nil {
-		return nil, err
+	m, err := s.store.Materials.Get(context.Background(), materialID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("material %s not found", materialID)
 	}
-
-	var (
-		id, title, description, category, tagsStr, filesJSON              string
-		status, uploader, uploadTime, reviewer, reviewTime, reviewComment string
-		aiSuggestionsStr, aiRiskLevel                                     string
-		aiScore, aiConfidence                                             float64
-	)
-
-	row := db.QueryRow(`SELECT id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel FROM materials WHERE id = ?`, materialID)
-	if err := row.Scan(
-		&id, &title, &description, &category, &tagsStr, &filesJSON,
-		&status, &uploader, &uploadTime, &reviewer, &reviewTime, &reviewComment,
-		&aiScore, &aiConfidence, &aiSuggestionsStr, &aiRiskLevel,
-	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("material %s not found", materialID)
-		}
+	if err != nil {
 		return nil, err
 	}
 
 	tags := make([]string, 0)
-	for _, tag := range strings.Split(tagsStr, ",") {
+	for _, tag := range strings.Split(m.Tags, ",") {
 		trimmed := strings.TrimSpace(tag)
 		if trimmed != "" {
 			tags = append(tags, trimmed)
@@ -396,7 +255,7 @@ func getMaterialDetail(materialID string) (*MaterialDetail, error) {
 	}
 
 	aiSuggestions := make([]string, 0)
-	for _, suggestion := range strings.Split(aiSuggestionsStr, ",") {
+	for _, suggestion := range strings.Split(m.AiSuggestions, ",") {
 		trimmed := strings.TrimSpace(suggestion)
 		if trimmed != "" {
 			aiSuggestions = append(aiSuggestions, trimmed)
@@ -404,34 +263,37 @@ func getMaterialDetail(materialID string) (*MaterialDetail, error) {
 	}
 
 	files := make([]MaterialFile, 0)
-	if strings.TrimSpace(filesJSON) != "" {
-		if err := json.Unmarshal([]byte(filesJSON), &files); err != nil {
+	if strings.TrimSpace(m.Files) != "" {
+		if err := json.Unmarshal([]byte(m.Files), &files); err != nil {
 			return nil, fmt.Errorf("parse files json failed: %w", err)
 		}
 	}
 
 	return &MaterialDetail{
-		ID:            id,
-		Title:         title,
-		Description:   description,
-		Category:      category,
+		ID:            m.ID,
+		Title:         m.Title,
+		Description:   m.Description,
+		Category:      m.Category,
 		Tags:          tags,
 		Files:         files,
-		Status:        status,
-		Uploader:      uploader,
-		UploadTime:    uploadTime,
-		Reviewer:      reviewer,
-		ReviewTime:    reviewTime,
-		ReviewComment: reviewComment,
-		AiScore:       aiScore,
-		AiConfidence:  aiConfidence,
+		Status:        m.Status,
+		Uploader:      m.Uploader,
+		UploadTime:    m.UploadTime,
+		Reviewer:      m.Reviewer,
+		ReviewTime:    m.ReviewTime,
+		ReviewComment: m.ReviewComment,
+		AiScore:       m.AiScore,
+		AiConfidence:  m.AiConfidence,
 		AiSuggestions: aiSuggestions,
-		AiRiskLevel:   aiRiskLevel,
+		AiRiskLevel:   m.AiRiskLevel,
+		AwardDate:     m.AwardDate,
 	}, nil
 }
 
-func DealMaterialToRecord(materialId string) error {
-	ok, err := materialRecordExists(materialId)
+func (s *Server) DealMaterialToRecord(materialId string) error {
+	ctx := context.Background()
+
+	ok, err := s.store.MaterialRecords.Exists(ctx, strings.TrimSpace(materialId))
 	if err != nil {
 		return err
 	}
@@ -440,35 +302,28 @@ func DealMaterialToRecord(materialId string) error {
 		return nil
 	}
 
-	ctx := context.Background()
-
-	detail, err := getMaterialDetail(materialId)
+	detail, err := s.getMaterialDetail(materialId)
 	if err != nil {
 		return err
 	}
 
-	genai.SetDefaultBaseURLs(genai.BaseURLParameters{
-		GeminiURL: BASE_URL,
-		VertexURL: BASE_URL,
-	})
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: API_KEY,
-	})
+	reg, err := s.regulationFor(ctx, detail.Uploader)
 	if err != nil {
 		return err
 	}
-
-	if client.ClientConfig().Backend == genai.BackendVertexAI {
-		return fmt.Errorf("Vertex AI backend is not supported for this operation")
+	client, err := s.newLLMClient(ctx)
+	if err != nil {
+		return err
 	}
 
 	parts := []*genai.Part{
-		{Text: analyzePrompt},
+		{Text: s.prompts.analyze},
+		{Text: s.prompts.facts},
 		{Text: fmt.Sprintf("材料标题：%s\n材料类别：%s\n材料标签：%v\n材料描述：%s\n审核意见：%s\n", detail.Title, detail.Category, detail.Tags, detail.Description, detail.ReviewComment)},
-		{Text: "保研条例如下，请严格按照条例要求进行材料填写，否则不予通过。\n" + guidelines},
+		{Text: reg.prompt()},
 	}
 	for _, fname := range detail.Files {
-		filename := "./upload/" + fname.FileName
+		filename := s.uploadPath(fname.FileName)
 		fileBytes, err := os.ReadFile(filename)
 		if err != nil {
 			return err
@@ -486,7 +341,7 @@ func DealMaterialToRecord(materialId string) error {
 		})
 	}
 
-	result, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", []*genai.Content{{Parts: parts}}, nil)
+	result, err := client.Models.GenerateContent(ctx, s.cfg.LLM.Model, []*genai.Content{{Parts: parts}}, nil)
 	if err != nil {
 		return err
 	}
@@ -496,16 +351,24 @@ func DealMaterialToRecord(materialId string) error {
 	ret = strings.ReplaceAll(ret, " ", "")
 	ret = strings.ReplaceAll(ret, "\n", "")
 
-	var score MaterialRecord
+	var extracted struct {
+		MaterialRecord
+		Facts scoring.Facts `json:"facts"`
+	}
 
-	if err := json.Unmarshal([]byte(ret), &score); err != nil {
+	if err := json.Unmarshal([]byte(ret), &extracted); err != nil {
 		return err
 	}
-
-	err = saveMaterialRecord(&score)
-	if err != nil {
+	score := extracted.MaterialRecord
+	// 归属信息以材料本身为准，不信任模型输出
+	score.MaterialId = detail.ID
+	score.AccountId = detail.Uploader
+	if strings.TrimSpace(score.AwardDate) == "" {
+		score.AwardDate = detail.AwardDate
+	}
+	if err := s.applyScore(ctx, &score, extracted.Facts, reg); err != nil {
 		return err
 	}
 
-	return nil
+	return s.store.MaterialRecords.Save(ctx, &score)
 }
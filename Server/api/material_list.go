@@ -2,198 +2,80 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
 )
 
+// materialResponse 将材料记录转换为前端使用的结构
+func materialResponse(m store.Material) map[string]interface{} {
+	// 将tags和aiSuggestions从字符串转为数组
+	tags := strings.Split(m.Tags, ",")
+	aiSuggestions := strings.Split(m.AiSuggestions, ",")
+
+	// 解析files JSON
+	var files []map[string]interface{}
+	_ = json.Unmarshal([]byte(m.Files), &files)
+
+	state := workflow.State(m.Status)
+	return map[string]interface{}{
+		"id":            m.ID,
+		"title":         m.Title,
+		"description":   m.Description,
+		"category":      m.Category,
+		"tags":          tags,
+		"files":         files,
+		"status":        state.PublicStatus(),
+		"state":         state,
+		"reviewRound":   m.ReviewRound,
+		"revision":      m.Revision,
+		"uploader":      m.Uploader,
+		"uploadTime":    m.UploadTime,
+		"reviewer":      m.Reviewer,
+		"reviewTime":    m.ReviewTime,
+		"reviewComment": m.ReviewComment,
+		"awardDate":     m.AwardDate,
+		"cutoffFlag":    m.CutoffFlag,
+		"aiReviewResult": map[string]interface{}{
+			"score":       m.AiScore,
+			"confidence":  m.AiConfidence,
+			"suggestions": aiSuggestions,
+			"riskLevel":   m.AiRiskLevel,
+		},
+	}
+}
+
 // 获取材料列表
-func GetMaterialListHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetMaterialListHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// 连接 SQLite 数据库
-	// 使用 user_info.db 存储材料数据以与用户信息保持一致
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 在查询之前插入示例数据以验证查询逻辑
-	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		category TEXT,
-		tags TEXT,
-		files TEXT, -- 存储多文件信息的JSON字符串
-		status TEXT,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT,
-		reviewComment TEXT,
-		aiScore REAL,
-		aiConfidence REAL,
-		aiSuggestions TEXT,
-		aiRiskLevel TEXT
-	)`)
-
-	/*
-		// 从 auth 中获取当前用户 ID 以插入示例数据
-		authDB, _ := sql.Open("sqlite3", "./user_info.db")
-		defer authDB.Close()
-		var sampleUploader string
-		_ = authDB.QueryRow(`SELECT accountId FROM users WHERE username = (SELECT username FROM login_records ORDER BY id DESC LIMIT 1)`).Scan(&sampleUploader)
-
-		fmt.Printf("调试: sampleUploader = %s\n", sampleUploader)
-		if _, err := db.Exec(`INSERT OR REPLACE INTO materials
-			(id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			"approved_sample_1", "示例材料", "这是一个通过审核的示例材料", "教材", "教育,数学",
-			`[{"fileUrl":"/uploads/files/sample.pdf","fileName":"sample.pdf","fileSize":102400},{"fileUrl":"/uploads/files/sample_extra.pdf","fileName":"sample_extra.pdf","fileSize":2048}]`,
-			"approved", sampleUploader, "2025-11-15T08:00:00Z", "reviewer01", "2025-11-15T09:00:00Z", "资料齐全", 0.95, 0.9, "增加封面,优化目录", "low"); err != nil {
-			fmt.Println("插入 approved_sample_1 出错:", err)
-		}
-
-		if _, err := db.Exec(`INSERT OR REPLACE INTO materials
-			(id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			"pending_sample_1", "另一个示例材料", "这是另一个正在审核的示例材料", "论文", "科研,计算机",
-			`[{"fileUrl":"/uploads/files/sample2.pdf","fileName":"sample2.pdf","fileSize":204800}]`,
-			"pending", sampleUploader, "2025-11-16T10:00:00Z", "reviewer02", "2025-11-16T11:00:00Z", "内容详实", 0.92, 0.85, "补充参考文献,优化格式", "medium"); err != nil {
-			fmt.Println("插入 pending_sample_1 出错:", err)
-		}
-
-		if _, err := db.Exec(`INSERT OR REPLACE INTO materials
-			(id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			"rejected_sample_1", "被拒绝的示例材料", "这是一个被拒绝的示例材料", "报告", "工作总结",
-			`[{"fileUrl":"/uploads/files/sample3.pdf","fileName":"sample3.pdf","fileSize":51200}]`,
-			"rejected", sampleUploader, "2025-11-17T12:00:00Z", "reviewer03", "2025-11-17T13:00:00Z", "内容不完整", 0.4, 0.3, "补充数据分析,完善结论", "high"); err != nil {
-			fmt.Println("插入 rejected_sample_1 出错:", err)
-		}
-	*/
-
-	// 确保 materials 表存在，并包含完整字段（多文件JSON存储）
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		category TEXT,
-		tags TEXT,
-		files TEXT, -- 存储多文件信息的JSON字符串
-		status TEXT,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT,
-		reviewComment TEXT,
-		aiScore REAL,
-		aiConfidence REAL,
-		aiSuggestions TEXT,
-		aiRiskLevel TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to ensure table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// 从 auth 中获取当前用户 ID
-	authDB, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Auth database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer authDB.Close()
-	var accountId string
-	row := authDB.QueryRow(`SELECT accountId FROM users WHERE username = (SELECT username FROM login_records ORDER BY id DESC LIMIT 1)`)
-	if err := row.Scan(&accountId); err != nil {
-		http.Error(w, "Failed to get accountId from auth: "+err.Error(), http.StatusUnauthorized)
+	// 从会话中获取当前用户 ID 和角色
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	// 查询role
-	var role string
-	if err := authDB.QueryRow(`SELECT role FROM users WHERE accountId = ?`, accountId).Scan(&role); err != nil {
-		http.Error(w, "Failed to get user role: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	var rows *sql.Rows
-	if role == "user" {
-		rows, err = db.Query(`SELECT id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel FROM materials WHERE uploader = ?`, accountId)
-	} else {
-		rows, err = db.Query(`SELECT id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel FROM materials`)
+	// 普通用户只能看到自己上传的材料
+	filter := store.MaterialFilter{}
+	if principal.Role == roleUser {
+		filter.Uploader = principal.AccountId
 	}
+	materials, err := s.store.Materials.List(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	data := make([]map[string]interface{}, 0)
-
-	for rows.Next() {
-		var id, title, description, category, tagsStr, filesJSON, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiSuggestionsStr, aiRiskLevel string
-		var aiScore, aiConfidence float64
-		if err := rows.Scan(
-			&id,
-			&title,
-			&description,
-			&category,
-			&tagsStr,
-			&filesJSON, // 多文件 JSON 字符串
-			&status,
-			&uploader,
-			&uploadTime,
-			&reviewer,
-			&reviewTime,
-			&reviewComment,
-			&aiScore,
-			&aiConfidence,
-			&aiSuggestionsStr,
-			&aiRiskLevel,
-		); err != nil {
-			http.Error(w, "Scan error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		// 将tags和aiSuggestions从字符串转为数组
-		tags := strings.Split(tagsStr, ",")
-		aiSuggestions := strings.Split(aiSuggestionsStr, ",")
-
-		// 解析files JSON
-		var files []map[string]interface{}
-		_ = json.Unmarshal([]byte(filesJSON), &files)
-
-		data = append(data, map[string]interface{}{
-			"id":            id,
-			"title":         title,
-			"description":   description,
-			"category":      category,
-			"tags":          tags,
-			"files":         files,
-			"status":        status,
-			"uploader":      uploader,
-			"uploadTime":    uploadTime,
-			"reviewer":      reviewer,
-			"reviewTime":    reviewTime,
-			"reviewComment": reviewComment,
-			"aiReviewResult": map[string]interface{}{
-				"score":       aiScore,
-				"confidence":  aiConfidence,
-				"suggestions": aiSuggestions,
-				"riskLevel":   aiRiskLevel,
-			},
-		})
+
+	data := make([]map[string]interface{}, 0, len(materials))
+	for _, m := range materials {
+		data = append(data, materialResponse(m))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -205,8 +87,8 @@ func GetMaterialListHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// 删除材料
-func DeleteMaterialHandler(w http.ResponseWriter, r *http.Request) {
+// 删除材料，材料移入回收站并收回待处理的审核分配
+func (s *Server) DeleteMaterialHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -219,39 +101,60 @@ func DeleteMaterialHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 连接 SQLite 数据库
-	// 使用 user_info.db 存储材料数据以与用户信息保持一致
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
-	defer db.Close()
 
-	// 删除指定材料记录
-	result, err := db.Exec(`DELETE FROM materials WHERE id = ?`, id)
+	// 移入回收站，保留期内可以恢复；普通用户只能删除自己上传的材料
+	var deleted *store.Material
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if principal.Role != roleAdmin && m.Uploader != principal.AccountId {
+			return errNotUploader
+		}
+		if err := tx.Materials.SoftDelete(r.Context(), id, principal.AccountId); err != nil {
+			return err
+		}
+		if _, err := tx.Assignments.ReleaseByMaterial(r.Context(), id, trashReleaseReason); err != nil {
+			return err
+		}
+		deleted, err = tx.Materials.GetDeleted(r.Context(), id)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Material not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errNotUploader) {
+		s.recordAudit(principal, r, permMaterialDelete, "denied")
+		http.Error(w, "Forbidden: not the uploader of this material", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Delete error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Material not found", http.StatusNotFound)
-		return
+	if workflow.State(deleted.Status) == workflow.Approved {
+		s.refreshStudentRanking(r.Context(), deleted.Uploader)
 	}
 
 	resp := map[string]interface{}{
 		"code":    200,
-		"message": "删除成功",
+		"message": "已移入回收站",
 		"id":      id,
+		"purgeAt": s.purgeAt(*deleted),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
 // 审核材料
-func ReviewMaterialHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) ReviewMaterialHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -261,6 +164,13 @@ func ReviewMaterialHandler(w http.ResponseWriter, r *http.Request) {
 		MaterialId string `json:"materialId"`
 		Status     string `json:"status"`
 		Comment    string `json:"comment"`
+		// Revision 审核人看到的材料版本，为 0 时按当前版本审核
+		Revision int `json:"revision"`
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
 	}
 
 	var req ReviewRequest
@@ -268,243 +178,83 @@ func ReviewMaterialHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
-
-	// 连接 SQLite 数据库
-	db, err := sql.Open("sqlite3", "./user_info.db")
+	decision, err := workflow.ParseDecision(req.Status)
 	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	// 获取当前记录的reviewer和status
-	var currentReviewer, currentStatus string
-	err = db.QueryRow(`SELECT reviewer, status FROM materials WHERE id = ?`, req.MaterialId).Scan(&currentReviewer, &currentStatus)
+	// 当前登录用户 accountId 作为 review 人，意见与状态变更由状态机统一处理
+	m, err := s.recordReview(r.Context(), req.MaterialId, principal.AccountId, decision, req.Comment, req.Revision)
 	if err != nil {
-		http.Error(w, "Query current reviewer/status error: "+err.Error(), http.StatusInternalServerError)
+		writeWorkflowError(w, err)
 		return
 	}
 
-	// 将reviewer字段转为切片
-	var reviewers []string
-	if strings.TrimSpace(currentReviewer) != "" {
-		reviewers = strings.Split(currentReviewer, ",")
-	}
-
-	// 获取当前用户accountId作为review人
-	authDB2, _ := sql.Open("sqlite3", "./user_info.db")
-	defer authDB2.Close()
-	var reviewerName string
-	_ = authDB2.QueryRow(`SELECT accountId FROM users WHERE username = (SELECT username FROM login_records ORDER BY id DESC LIMIT 1)`).Scan(&reviewerName)
-
-	// 如果status是rejected，直接更新为rejected
-	if strings.ToLower(req.Status) == "rejected" {
-		if !containsReviewer(reviewers, reviewerName) {
-			reviewers = append(reviewers, reviewerName)
-		}
-		_, err = db.Exec(`UPDATE materials SET status = 'rejected', reviewer = ?, reviewTime = datetime('now'), reviewComment = ? WHERE id = ?`,
-			strings.Join(reviewers, ","), req.Comment, req.MaterialId)
-		if err != nil {
-			http.Error(w, "Update rejected error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else if strings.ToLower(req.Status) == "approved" {
-		// 如果status是approved，先添加reviewer但不立即改状态，直到3人
-		if !containsReviewer(reviewers, reviewerName) {
-			go func() {
-				err := DealMaterialToRecord(req.MaterialId)
-				if err != nil {
-					fmt.Println("模型写入记录错误", err)
-				}
-			}()
-			reviewers = append(reviewers, reviewerName)
-		}
-		newStatus := currentStatus
-		if len(reviewers) >= 3 {
-			err := DealMaterialToRecord(req.MaterialId)
-			if err != nil {
-				http.Error(w, "Update Record error"+err.Error(), http.StatusInternalServerError)
-				return
-			}
-			newStatus = "approved"
-		}
-		_, err = db.Exec(`UPDATE materials SET status = ?, reviewer = ?, reviewTime = datetime('now'), reviewComment = ? WHERE id = ?`,
-			newStatus, strings.Join(reviewers, ","), req.Comment, req.MaterialId)
-		if err != nil {
-			http.Error(w, "Update approved/pending error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		// 其他状态只更新reviewer和评论
-		if !containsReviewer(reviewers, reviewerName) {
-			reviewers = append(reviewers, reviewerName)
-		}
-		_, err = db.Exec(`UPDATE materials SET reviewer = ?, reviewTime = datetime('now'), reviewComment = ? WHERE id = ?`,
-			strings.Join(reviewers, ","), req.Comment, req.MaterialId)
-		if err != nil {
-			http.Error(w, "Update other status error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	state := workflow.State(m.Status)
+	if state == workflow.Approved {
+		s.afterApproved(m.ID)
 	}
 
 	resp := map[string]interface{}{
-		"code":    200,
-		"message": "审核成功",
-		"id":      req.MaterialId,
-		"status":  req.Status,
-		"comment": req.Comment,
+		"code":        200,
+		"message":     "审核成功",
+		"id":          req.MaterialId,
+		"status":      state.PublicStatus(),
+		"state":       state,
+		"decision":    decision,
+		"reviewRound": m.ReviewRound,
+		"revision":    m.Revision,
+		"comment":     req.Comment,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-func containsReviewer(list []string, name string) bool {
-	for _, v := range list {
-		if strings.TrimSpace(v) == strings.TrimSpace(name) {
-			return true
-		}
-	}
-	return false
-}
+// reviewableStatuses 可以写入审核意见的材料状态
+var reviewableStatuses = []string{string(workflow.Submitted), string(workflow.UnderReview), string(workflow.ReReview)}
 
-func GetPendingMaterialsHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetPendingMaterialsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
+	accountId := principal.AccountId
 
-	if req.AccountId == "" {
-		http.Error(w, "Missing accountId", http.StatusBadRequest)
-		return
+	// 审核人只看到分配给自己的材料，管理员可以看到全部待审材料
+	filter := store.MaterialFilter{Statuses: reviewableStatuses}
+	switch principal.Role {
+	case roleUser:
+		filter.Uploader = accountId
+	case roleReviewer, roleAppealReviewer:
+		filter.AssignedTo = accountId
 	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
+	materials, err := s.store.Materials.List(r.Context(), filter)
 	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 确保表存在
-	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		category TEXT,
-		tags TEXT,
-		files TEXT, -- 存储多文件信息的JSON字符串
-		status TEXT,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT,
-		reviewComment TEXT,
-		aiScore REAL,
-		aiConfidence REAL,
-		aiSuggestions TEXT,
-		aiRiskLevel TEXT
-	)`)
-
-	/*
-		// 在查询前插入示例数据（模仿 user_setting.go 中的逻辑，改为多文件JSON存储）
-		if _, err := db.Exec(`INSERT OR REPLACE INTO materials
-			(id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			"pending_sample_for_user", "示例材料", "这是一个待审核的示例材料", "教材", "教育,数学",
-			`[{"fileUrl":"/uploads/files/sample.pdf","fileName":"sample.pdf","fileSize":102400},{"fileUrl":"/uploads/files/sample_extra.pdf","fileName":"sample_extra.pdf","fileSize":2048}]`,
-			"pending", req.AccountId, "2025-11-15T08:00:00Z", "teacher01", "2025-11-15T09:00:00Z", "资料齐全", 0.95, 0.9, "增加封面,优化目录", "low"); err != nil {
-			http.Error(w, "Failed to insert sample data: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	*/
-
-	// 查询role
-	var role string
-	if err := db.QueryRow(`SELECT role FROM users WHERE accountId = ?`, req.AccountId).Scan(&role); err != nil {
-		http.Error(w, "Failed to get user role: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	var rows *sql.Rows
-	if role == "user" {
-		rows, err = db.Query(`SELECT id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel 
-			FROM materials WHERE status = 'pending' AND uploader = ?`, req.AccountId)
-	} else {
-		rows, err = db.Query(`SELECT id, title, description, category, tags, files, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel 
-			FROM materials WHERE status = 'pending'`)
-	}
+	decided, err := s.store.MaterialReviews.DecidedRounds(r.Context(), accountId)
 	if err != nil {
-		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Query reviews error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	data := make([]map[string]interface{}, 0)
-	for rows.Next() {
-		var id, title, description, category, tagsStr, filesJSON, status, uploader, uploadTime, reviewer, reviewTime, reviewComment, aiSuggestionsStr, aiRiskLevel string
-		var aiScore, aiConfidence float64
-		if err := rows.Scan(
-			&id,
-			&title,
-			&description,
-			&category,
-			&tagsStr,
-			&filesJSON, // 多文件 JSON 字符串
-			&status,
-			&uploader,
-			&uploadTime,
-			&reviewer,
-			&reviewTime,
-			&reviewComment,
-			&aiScore,
-			&aiConfidence,
-			&aiSuggestionsStr,
-			&aiRiskLevel,
-		); err != nil {
-			http.Error(w, "Scan error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
 
-		// 如果pending的reviewer里包含当前accountId，则跳过
-		if status == "pending" && strings.Contains(reviewer, req.AccountId) {
+	data := make([]map[string]interface{}, 0, len(materials))
+	for _, m := range materials {
+		// 当前用户已在本轮给出结论的材料不再出现在待审列表中
+		if decided[m.ID] >= m.ReviewRound {
 			continue
 		}
 
-		tags := strings.Split(tagsStr, ",")
-		aiSuggestions := strings.Split(aiSuggestionsStr, ",")
-
-		var files []map[string]interface{}
-		_ = json.Unmarshal([]byte(filesJSON), &files)
-
-		data = append(data, map[string]interface{}{
-			"id":            id,
-			"title":         title,
-			"description":   description,
-			"category":      category,
-			"tags":          tags,
-			"files":         files,
-			"status":        status,
-			"uploader":      uploader,
-			"uploadTime":    uploadTime,
-			"reviewer":      reviewer,
-			"reviewTime":    reviewTime,
-			"reviewComment": reviewComment,
-			"aiReviewResult": map[string]interface{}{
-				"score":       aiScore,
-				"confidence":  aiConfidence,
-				"suggestions": aiSuggestions,
-				"riskLevel":   aiRiskLevel,
-			},
-			"accountId": req.AccountId,
-		})
+		item := materialResponse(m)
+		item["accountId"] = accountId
+		data = append(data, item)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -515,125 +265,54 @@ func GetPendingMaterialsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// RegisterMaterialListRoutes 注册材料列表路由
-func GetMaterialStatisticsHandler(w http.ResponseWriter, r *http.Request) {
+// GetMaterialStatisticsHandler 统计材料数量，普通用户只统计自己上传的材料
+func (s *Server) GetMaterialStatisticsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-	if req.AccountId == "" {
-		http.Error(w, "Missing accountId", http.StatusBadRequest)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	db, err := sql.Open("sqlite3", "./user_info.db")
+	uploader := ""
+	if strings.EqualFold(principal.Role, roleUser) {
+		uploader = principal.AccountId
+	}
+	stats, err := s.store.Materials.Statistics(r.Context(), uploader)
 	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Statistics query error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
 
-	var role string
-	if err := db.QueryRow(`SELECT role FROM users WHERE accountId = ?`, req.AccountId).Scan(&role); err != nil {
-		http.Error(w, "Failed to get user role: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	var total, pending, approved, rejected int
-	byCategory := make(map[string]int)
-
-	if strings.EqualFold(role, "user") {
-		accountID := req.AccountId
-
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE uploader = ?`, accountID).Scan(&total); err != nil {
-			http.Error(w, "Total query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'pending' AND uploader = ?`, accountID).Scan(&pending); err != nil {
-			http.Error(w, "Pending query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'approved' AND uploader = ?`, accountID).Scan(&approved); err != nil {
-			http.Error(w, "Approved query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'rejected' AND uploader = ?`, accountID).Scan(&rejected); err != nil {
-			http.Error(w, "Rejected query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		rows, err := db.Query(`SELECT category, COUNT(*) FROM materials WHERE uploader = ? GROUP BY category`, accountID)
-		if err != nil {
-			http.Error(w, "Category query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var cat string
-			var count int
-			if err := rows.Scan(&cat, &count); err == nil {
-				byCategory[cat] = count
-			}
-		}
-	} else {
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials`).Scan(&total); err != nil {
-			http.Error(w, "Total query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'pending'`).Scan(&pending); err != nil {
-			http.Error(w, "Pending query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'approved'`).Scan(&approved); err != nil {
-			http.Error(w, "Approved query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := db.QueryRow(`SELECT COUNT(*) FROM materials WHERE status = 'rejected'`).Scan(&rejected); err != nil {
-			http.Error(w, "Rejected query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		rows, err := db.Query(`SELECT category, COUNT(*) FROM materials GROUP BY category`)
-		if err != nil {
-			http.Error(w, "Category query error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var cat string
-			var count int
-			if err := rows.Scan(&cat, &count); err == nil {
-				byCategory[cat] = count
-			}
-		}
+	// 前端按三态展示，审核流程中的各状态合并计入 pending
+	public := make(map[string]int)
+	for st, n := range stats.ByStatus {
+		public[workflow.State(st).PublicStatus()] += n
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 0,
 		"data": map[string]interface{}{
-			"total":      total,
-			"pending":    pending,
-			"approved":   approved,
-			"rejected":   rejected,
-			"byCategory": byCategory,
+			"total":      stats.Total,
+			"pending":    public["pending"],
+			"approved":   public["approved"],
+			"rejected":   public["rejected"],
+			"byStatus":   stats.ByStatus,
+			"byCategory": stats.ByCategory,
 		},
 		"message": "success",
 	})
 }
 
-func RegisterMaterialListRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/material/list", GetMaterialListHandler)
-	mux.HandleFunc("/api/material/pending", GetPendingMaterialsHandler)
-	mux.HandleFunc("/api/material/review", ReviewMaterialHandler)
-	mux.HandleFunc("/api/material/", DeleteMaterialHandler)
-	mux.HandleFunc("/api/material/statistics", GetMaterialStatisticsHandler)
+// RegisterMaterialListRoutes 注册材料列表路由
+func (s *Server) RegisterMaterialListRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/list", s.GetMaterialListHandler)
+	mux.HandleFunc("/api/material/pending", s.GetPendingMaterialsHandler)
+	mux.HandleFunc("/api/material/review", s.ReviewMaterialHandler)
+	mux.HandleFunc("/api/material/", s.DeleteMaterialHandler)
+	mux.HandleFunc("/api/material/statistics", s.GetMaterialStatisticsHandler)
 }
@@ -0,0 +1,463 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// errNoChanges 修改内容与当前版本相同
+var errNoChanges = errors.New("没有需要保存的修改")
+
+// splitTags 将逗号分隔的标签拆为数组，空字符串返回空数组
+func splitTags(tags string) []string {
+	out := make([]string, 0)
+	for _, t := range strings.Split(tags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// parseFiles 解析 materials.files 中的 JSON 数组，格式错误时返回空数组
+func parseFiles(files string) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0)
+	_ = json.Unmarshal([]byte(files), &out)
+	if out == nil {
+		out = make([]map[string]interface{}, 0)
+	}
+	return out
+}
+
+// fileNames 从 materials.files 中取出上传文件名，与 filesJSON 互逆
+func fileNames(files string) []string {
+	var names []string
+	for _, f := range parseFiles(files) {
+		names = append(names, fmt.Sprint(f["fileName"]))
+	}
+	return names
+}
+
+// revisionResponse 版本的展示字段，tags 与 files 以数组返回
+func revisionResponse(rev store.MaterialRevision) map[string]interface{} {
+	return map[string]interface{}{
+		"revision":    rev.Revision,
+		"title":       rev.Title,
+		"description": rev.Description,
+		"category":    rev.Category,
+		"tags":        splitTags(rev.Tags),
+		"files":       parseFiles(rev.Files),
+		"editor":      rev.Editor,
+		"note":        rev.Note,
+		"createdAt":   rev.CreatedAt,
+	}
+}
+
+// fieldChange 文本字段在两个版本间的变化
+type fieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// revisionDiff 两个版本之间的差异：文本字段逐项比较，标签与文件按集合比较
+type revisionDiff struct {
+	Changes []fieldChange `json:"changes"`
+	Tags    struct {
+		Added   []string `json:"added"`
+		Removed []string `json:"removed"`
+	} `json:"tags"`
+	Files struct {
+		Added   []map[string]interface{} `json:"added"`
+		Removed []map[string]interface{} `json:"removed"`
+		// Changed 同名文件被替换为不同的内容
+		Changed []map[string]interface{} `json:"changed"`
+	} `json:"files"`
+}
+
+// diffRevisions 比较 from 与 to 两个版本，文件以 fileName 识别，fileUrl 或 fileSize 不同视为替换
+func diffRevisions(from, to store.MaterialRevision) revisionDiff {
+	var d revisionDiff
+	d.Changes = make([]fieldChange, 0)
+	for _, f := range []fieldChange{
+		{Field: "title", From: from.Title, To: to.Title},
+		{Field: "description", From: from.Description, To: to.Description},
+		{Field: "category", From: from.Category, To: to.Category},
+	} {
+		if f.From != f.To {
+			d.Changes = append(d.Changes, f)
+		}
+	}
+
+	d.Tags.Added, d.Tags.Removed = make([]string, 0), make([]string, 0)
+	oldTags, newTags := make(map[string]bool), make(map[string]bool)
+	for _, t := range splitTags(from.Tags) {
+		oldTags[t] = true
+	}
+	for _, t := range splitTags(to.Tags) {
+		newTags[t] = true
+		if !oldTags[t] {
+			d.Tags.Added = append(d.Tags.Added, t)
+		}
+	}
+	for _, t := range splitTags(from.Tags) {
+		if !newTags[t] {
+			d.Tags.Removed = append(d.Tags.Removed, t)
+		}
+	}
+
+	d.Files.Added, d.Files.Removed, d.Files.Changed = make([]map[string]interface{}, 0), make([]map[string]interface{}, 0), make([]map[string]interface{}, 0)
+	oldFiles := make(map[string]map[string]interface{})
+	for _, f := range parseFiles(from.Files) {
+		oldFiles[fmt.Sprint(f["fileName"])] = f
+	}
+	seen := make(map[string]bool)
+	for _, f := range parseFiles(to.Files) {
+		name := fmt.Sprint(f["fileName"])
+		seen[name] = true
+		old, ok := oldFiles[name]
+		switch {
+		case !ok:
+			d.Files.Added = append(d.Files.Added, f)
+		case fmt.Sprint(old["fileUrl"]) != fmt.Sprint(f["fileUrl"]) || fmt.Sprint(old["fileSize"]) != fmt.Sprint(f["fileSize"]):
+			d.Files.Changed = append(d.Files.Changed, map[string]interface{}{"fileName": name, "from": old, "to": f})
+		}
+	}
+	for _, f := range parseFiles(from.Files) {
+		if !seen[fmt.Sprint(f["fileName"])] {
+			d.Files.Removed = append(d.Files.Removed, f)
+		}
+	}
+	return d
+}
+
+// EditMaterialHandler 上传者修改材料内容，每次修改生成一个新版本
+// 未传的字段保持不变；revision 为修改所基于的版本，材料已被修改过时拒绝，为 0 时不校验
+// 更换证明文件或填写 awardDate 时重新识别成果取得时间并核对条例截止日期，模型评估结果一并更新
+// 审核中的材料修改后，本轮已给出结论的审核人需要对新版本重新审核
+func (s *Server) EditMaterialHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MaterialId  string    `json:"materialId"`
+		Revision    int       `json:"revision"`
+		Title       *string   `json:"title"`
+		Description *string   `json:"description"`
+		Category    *string   `json:"category"`
+		Tags        *[]string `json:"tags"`
+		Files       *[]string `json:"files"`
+		// AwardDate 更正成果取得时间，未填写时使用模型从新文件中识别的时间
+		AwardDate *string `json:"awardDate"`
+		Note      string  `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		http.Error(w, "标题不能为空", http.StatusBadRequest)
+		return
+	}
+
+	// 模型评估耗时较长，在事务外基于当前版本完成，写入前确认文件未被并发修改
+	current, err := s.store.Materials.Get(r.Context(), req.MaterialId)
+	if err == nil && current.Uploader != principal.AccountId {
+		err = errNotUploader
+	}
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	files := current.Files
+	if req.Files != nil {
+		files = s.filesJSON(*req.Files)
+	}
+	var assessed *store.Material
+	if files != current.Files || req.AwardDate != nil {
+		upload := MaterialUploadRequest{
+			Title: current.Title, Description: current.Description, Category: current.Category,
+			Tags: splitTags(current.Tags), Files: fileNames(files), AccountId: current.Uploader,
+		}
+		if req.Title != nil {
+			upload.Title = strings.TrimSpace(*req.Title)
+		}
+		if req.Description != nil {
+			upload.Description = *req.Description
+		}
+		if req.Category != nil {
+			upload.Category = *req.Category
+		}
+		if req.Tags != nil {
+			upload.Tags = *req.Tags
+		}
+		if req.AwardDate != nil {
+			upload.AwardDate = *req.AwardDate
+		}
+		score, err := s.CalculateScore(&upload)
+		if err != nil {
+			http.Error(w, "Score calculation error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		awardDate, cutoffFlag, err := s.checkCutoff(r.Context(), current.Uploader, upload.AwardDate, score)
+		if err != nil {
+			http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		assessed = &store.Material{
+			ID: current.ID, AiScore: score.AiScore, AiConfidence: score.AiConfidence,
+			AiSuggestions: score.AiSuggestions, AiRiskLevel: score.AiRiskLevel,
+			AwardDate: awardDate, CutoffFlag: cutoffFlag,
+		}
+	}
+
+	var updated *store.Material
+	err = s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(r.Context(), req.MaterialId)
+		if err != nil {
+			return err
+		}
+		if m.Uploader != principal.AccountId {
+			return errNotUploader
+		}
+		state := workflow.State(m.Status)
+		if !state.Editable() {
+			return &workflow.TransitionError{From: state, Action: "edit"}
+		}
+		if req.Revision != 0 && req.Revision != m.Revision {
+			return store.ErrConflict
+		}
+		if err := checkSubmissionOpen(r.Context(), tx); err != nil {
+			return err
+		}
+
+		rev := &store.MaterialRevision{
+			MaterialId:  m.ID,
+			Revision:    m.Revision + 1,
+			Title:       m.Title,
+			Description: m.Description,
+			Category:    m.Category,
+			Tags:        m.Tags,
+			Files:       m.Files,
+			Editor:      principal.AccountId,
+			Note:        strings.TrimSpace(req.Note),
+		}
+		if req.Title != nil {
+			rev.Title = strings.TrimSpace(*req.Title)
+		}
+		if req.Description != nil {
+			rev.Description = *req.Description
+		}
+		if req.Category != nil {
+			rev.Category = *req.Category
+		}
+		if req.Tags != nil {
+			rev.Tags = strings.Join(*req.Tags, ",")
+		}
+		if req.Files != nil {
+			rev.Files = files
+		}
+		if assessed != nil && m.Files != current.Files {
+			return store.ErrConflict
+		}
+		if rev.Title == m.Title && rev.Description == m.Description && rev.Category == m.Category &&
+			rev.Tags == m.Tags && rev.Files == m.Files && (assessed == nil || assessed.AwardDate == m.AwardDate) {
+			return errNoChanges
+		}
+		if err := tx.Materials.Revise(r.Context(), rev); err != nil {
+			return err
+		}
+		m.Title, m.Description, m.Category, m.Tags, m.Files, m.Revision = rev.Title, rev.Description, rev.Category, rev.Tags, rev.Files, rev.Revision
+		if assessed != nil {
+			if err := tx.Materials.UpdateAssessment(r.Context(), assessed); err != nil {
+				return err
+			}
+			m.AiScore, m.AiConfidence, m.AiSuggestions, m.AiRiskLevel = assessed.AiScore, assessed.AiConfidence, assessed.AiSuggestions, assessed.AiRiskLevel
+			m.AwardDate, m.CutoffFlag = assessed.AwardDate, assessed.CutoffFlag
+		}
+		updated = m
+
+		if !state.InReview() {
+			return nil
+		}
+		if _, err := tx.Assignments.Reactivate(r.Context(), m.ID, m.ReviewRound); err != nil {
+			return err
+		}
+		assignments, err := tx.Assignments.ListByMaterial(r.Context(), m.ID, m.ReviewRound)
+		if err != nil {
+			return err
+		}
+		for _, a := range assignments {
+			if a.Status != store.AssignmentActive {
+				continue
+			}
+			notify(r.Context(), tx, store.Message{
+				AccountId: a.Reviewer, Type: "todo", Title: "材料已更新", SubTitle: m.Title, MessageType: messageInProgress,
+				Content: fmt.Sprintf("材料《%s》已更新到第 %d 版，请审核最新版本", m.Title, m.Revision),
+			})
+		}
+		return nil
+	})
+	if errors.Is(err, errNoChanges) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": materialResponse(*updated),
+	})
+}
+
+// ListRevisionsHandler 返回材料的全部版本及每个版本上的审核意见
+// 普通用户只能查看自己上传的材料
+func (s *Server) ListRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	m, ok := s.readableMaterial(w, r)
+	if !ok {
+		return
+	}
+	revisions, err := s.store.Materials.Revisions(r.Context(), m.ID)
+	if err != nil {
+		http.Error(w, "Query revisions error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reviews, err := s.store.MaterialReviews.ListByMaterial(r.Context(), m.ID)
+	if err != nil {
+		http.Error(w, "Query reviews error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byRevision := make(map[int][]store.MaterialReview)
+	for _, rv := range reviews {
+		byRevision[rv.Revision] = append(byRevision[rv.Revision], rv)
+	}
+
+	data := make([]map[string]interface{}, 0, len(revisions))
+	for _, rev := range revisions {
+		item := revisionResponse(rev)
+		item["current"] = rev.Revision == m.Revision
+		item["reviews"] = byRevision[rev.Revision]
+		if item["reviews"] == nil {
+			item["reviews"] = []store.MaterialReview{}
+		}
+		data = append(data, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// RevisionDiffHandler 比较材料的两个版本，to 默认为当前版本，from 默认为 to 的上一版本
+func (s *Server) RevisionDiffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	m, ok := s.readableMaterial(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	to := m.Revision
+	if v := query.Get("to"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+		to = n
+	}
+	from := to - 1
+	if v := query.Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+		from = n
+	}
+
+	revs := make([]*store.MaterialRevision, 0, 2)
+	for _, n := range []int{from, to} {
+		rev, err := s.store.Materials.GetRevision(r.Context(), m.ID, n)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("材料没有第 %d 版", n), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Query revision error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		revs = append(revs, rev)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"materialId": m.ID,
+			"from":       revisionResponse(*revs[0]),
+			"to":         revisionResponse(*revs[1]),
+			"diff":       diffRevisions(*revs[0], *revs[1]),
+		},
+	})
+}
+
+// readableMaterial 读取 id 参数指定的材料，普通用户只能读取自己上传的材料，失败时已写入响应
+func (s *Server) readableMaterial(w http.ResponseWriter, r *http.Request) (*store.Material, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return nil, false
+	}
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		http.Error(w, "Missing material ID", http.StatusBadRequest)
+		return nil, false
+	}
+	m, err := s.store.Materials.Get(r.Context(), id)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return nil, false
+	}
+	if principal.Role == roleUser && m.Uploader != principal.AccountId {
+		http.Error(w, "Forbidden: not the uploader of this material", http.StatusForbidden)
+		return nil, false
+	}
+	return m, true
+}
+
+// RegisterMaterialRevisionRoutes 注册材料修改、版本列表与版本比较路由
+func (s *Server) RegisterMaterialRevisionRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/edit", s.EditMaterialHandler)
+	mux.HandleFunc("/api/material/revisions", s.ListRevisionsHandler)
+	mux.HandleFunc("/api/material/revision/diff", s.RevisionDiffHandler)
+}
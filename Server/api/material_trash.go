@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// trashReleaseReason 删除材料时收回分配所记录的原因，恢复时据此把材料交还原审核人
+const trashReleaseReason = "材料已删除"
+
+// errRestoreExpired 材料在回收站中已超过保留期，等待永久删除
+var errRestoreExpired = errors.New("材料已超过回收站保留期，无法恢复")
+
+// purgeAt 回收站中的材料被永久删除的时间，deletedAt 无法解析时为空
+func (s *Server) purgeAt(m store.Material) string {
+	deletedAt, err := store.ParseTime(m.DeletedAt)
+	if err != nil {
+		return ""
+	}
+	return store.FormatTime(deletedAt.Add(s.cfg.Upload.TrashRetention))
+}
+
+// restorable 材料是否仍在保留期内
+func (s *Server) restorable(m store.Material, now time.Time) bool {
+	deletedAt, err := store.ParseTime(m.DeletedAt)
+	return err == nil && now.Before(deletedAt.Add(s.cfg.Upload.TrashRetention))
+}
+
+// uploadNames 从 files 列的 JSON 中取出上传目录下的文件名
+// 优先取 fileUrl 去掉 /upload/ 前缀的部分，没有 fileUrl 时使用 fileName
+func uploadNames(files string) []string {
+	names := make([]string, 0)
+	for _, f := range parseFiles(files) {
+		name, _ := f["fileUrl"].(string)
+		name = strings.TrimPrefix(name, "/upload/")
+		if name == "" {
+			name, _ = f["fileName"].(string)
+		}
+		// 只接受上传目录下的文件名，避免越出目录
+		name = filepath.Base(name)
+		if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// TrashHandler 返回回收站中的材料，管理员看到全部，其他用户只看到自己上传的材料
+func (s *Server) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	filter := store.MaterialFilter{Deleted: true}
+	if principal.Role != roleAdmin {
+		filter.Uploader = principal.AccountId
+	}
+	materials, err := s.store.Materials.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	data := make([]map[string]interface{}, 0, len(materials))
+	for _, m := range materials {
+		item := materialResponse(m)
+		item["deletedAt"] = m.DeletedAt
+		item["deletedBy"] = m.DeletedBy
+		item["purgeAt"] = s.purgeAt(m)
+		item["restorable"] = s.restorable(m, now)
+		data = append(data, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// RestoreMaterialHandler 将回收站中的材料恢复到删除前的状态
+// 普通用户只能恢复自己上传的材料；审核中的材料恢复后重新分配审核人
+func (s *Server) RestoreMaterialHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MaterialId string `json:"materialId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+
+	var restored *store.Material
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetDeleted(r.Context(), req.MaterialId)
+		if err != nil {
+			return err
+		}
+		if principal.Role != roleAdmin && m.Uploader != principal.AccountId {
+			return errNotUploader
+		}
+		if !s.restorable(*m, time.Now()) {
+			return errRestoreExpired
+		}
+		if err := tx.Materials.Restore(r.Context(), m.ID); err != nil {
+			return err
+		}
+		if restored, err = tx.Materials.GetForUpdate(r.Context(), m.ID); err != nil {
+			return err
+		}
+		if !workflow.State(restored.Status).InReview() {
+			return nil
+		}
+		if err := s.returnToReviewers(r.Context(), tx, restored, principal.AccountId); err != nil {
+			return err
+		}
+		_, err = s.assignReviewers(r.Context(), tx, restored, principal.AccountId)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Material not in trash", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errRestoreExpired) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	if workflow.State(restored.Status) == workflow.Approved {
+		s.refreshStudentRanking(r.Context(), restored.Uploader)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": materialResponse(*restored),
+	})
+}
+
+// returnToReviewers 为删除时被收回分配的审核人重新建立本轮分配
+func (s *Server) returnToReviewers(ctx context.Context, tx *store.Store, m *store.Material, actor string) error {
+	assignments, err := tx.Assignments.ListByMaterial(ctx, m.ID, m.ReviewRound)
+	if err != nil {
+		return err
+	}
+	open := make(map[string]bool)
+	for _, a := range assignments {
+		if a.Status != store.AssignmentReleased {
+			open[a.Reviewer] = true
+		}
+	}
+	for _, a := range assignments {
+		if a.Status != store.AssignmentReleased || a.Reason != trashReleaseReason || open[a.Reviewer] {
+			continue
+		}
+		err := tx.Assignments.Create(ctx, &store.ReviewAssignment{
+			MaterialId: m.ID,
+			Round:      m.ReviewRound,
+			Reviewer:   a.Reviewer,
+			AssignedBy: actor,
+		})
+		if err != nil {
+			return err
+		}
+		open[a.Reviewer] = true
+	}
+	return nil
+}
+
+// PurgeExpired 永久删除超过保留期的材料，并清理不再被任何材料、版本或申诉引用的上传文件及其 file_map 记录
+// 返回删除的材料数与文件数
+func (s *Server) PurgeExpired(ctx context.Context) (int, int, error) {
+	cutoff := store.FormatTime(time.Now().Add(-s.cfg.Upload.TrashRetention))
+	expired, err := s.store.Materials.List(ctx, store.MaterialFilter{Deleted: true, DeletedBefore: cutoff})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 永久删除前收集材料各版本与申诉引用过的文件
+	candidates := make(map[string]bool)
+	// 永久删除已通过的材料会同时删除其加分记录，完成后重算这些学生所在专业的排名
+	uploaders := make(map[string]bool)
+	purged := 0
+	for _, m := range expired {
+		lists := []string{m.Files}
+		revisions, err := s.store.Materials.Revisions(ctx, m.ID)
+		if err != nil {
+			return purged, 0, err
+		}
+		for _, rev := range revisions {
+			lists = append(lists, rev.Files)
+		}
+		appeals, err := s.store.Appeals.ListByMaterial(ctx, m.ID)
+		if err != nil {
+			return purged, 0, err
+		}
+		for _, a := range appeals {
+			lists = append(lists, a.Files)
+		}
+
+		err = s.store.Materials.Purge(ctx, m.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			// 已被恢复或已被其他任务删除
+			continue
+		}
+		if err != nil {
+			return purged, 0, err
+		}
+		purged++
+		if workflow.State(m.Status) == workflow.Approved {
+			uploaders[m.Uploader] = true
+		}
+		for _, files := range lists {
+			for _, name := range uploadNames(files) {
+				candidates[name] = true
+			}
+		}
+	}
+	for uploader := range uploaders {
+		s.refreshStudentRanking(ctx, uploader)
+	}
+	if len(candidates) == 0 {
+		return purged, 0, nil
+	}
+
+	lists, err := s.store.Materials.FileLists(ctx)
+	if err != nil {
+		return purged, 0, err
+	}
+	for _, files := range lists {
+		for _, name := range uploadNames(files) {
+			delete(candidates, name)
+		}
+	}
+
+	removed := 0
+	for name := range candidates {
+		if err := os.Remove(s.uploadPath(name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[回收站] 删除文件 %s 失败: %v", name, err)
+			continue
+		}
+		if _, err := s.store.FileMaps.DeleteByFileId(ctx, name); err != nil {
+			return purged, removed, fmt.Errorf("删除文件映射 %s: %w", name, err)
+		}
+		removed++
+	}
+	return purged, removed, nil
+}
+
+// StartPurgeJob 按配置的间隔定期执行 PurgeExpired，服务关闭时退出
+func (s *Server) StartPurgeJob() {
+	s.goBackground("trash-purge", func(ctx context.Context) {
+		ticker := time.NewTicker(s.cfg.Upload.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			materials, files, err := s.PurgeExpired(ctx)
+			if err != nil {
+				log.Printf("[回收站] 清理失败: %v", err)
+				continue
+			}
+			if materials > 0 {
+				log.Printf("[回收站] 已永久删除 %d 份材料、%d 个文件", materials, files)
+			}
+		}
+	})
+}
+
+// PurgeTrashHandler 立即清理一次回收站中超过保留期的材料
+func (s *Server) PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	materials, files, err := s.PurgeExpired(r.Context())
+	if err != nil {
+		http.Error(w, "Purge error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"materials": materials, "files": files},
+	})
+}
+
+// RegisterMaterialTrashRoutes 注册回收站、恢复与清理路由
+func (s *Server) RegisterMaterialTrashRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/trash", s.TrashHandler)
+	mux.HandleFunc("/api/material/restore", s.RestoreMaterialHandler)
+	mux.HandleFunc("/api/material/trash/purge", s.PurgeTrashHandler)
+}
@@ -1,9 +1,10 @@
 package api
 
 import (
+	"context"
 	"crypto/md5"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,7 +15,8 @@ import (
 	"os"
 	"path/filepath"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
 )
 
 type FileCheckRequest struct {
@@ -30,37 +32,16 @@ type FileCheckResponse struct {
 }
 
 // UploadCheckHandler - 检查文件是否已存在
-func UploadCheckHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UploadCheckHandler(w http.ResponseWriter, r *http.Request) {
 	var req FileCheckRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
-	// 打开数据库
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 确保 file_map 表存在
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS file_map (
-		md5 TEXT PRIMARY KEY,
-		filename TEXT,
-		file_id TEXT,
-		url TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to ensure table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	// 查询是否存在
-	var fileID, url string
-	err = db.QueryRow(`SELECT file_id, url FROM file_map WHERE md5 = ? AND filename = ?`, req.Md5, req.Filename).Scan(&fileID, &url)
-	if err == sql.ErrNoRows {
+	mapping, err := s.store.FileMaps.Find(r.Context(), req.Md5, req.Filename)
+	if errors.Is(err, store.ErrNotFound) {
 		resp := FileCheckResponse{Exists: false}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -76,8 +57,8 @@ func UploadCheckHandler(w http.ResponseWriter, r *http.Request) {
 
 	resp := FileCheckResponse{
 		Exists: true,
-		FileID: fileID,
-		URL:    url,
+		FileID: mapping.FileId,
+		URL:    mapping.URL,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -88,7 +69,7 @@ func UploadCheckHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // UploadFileHandler - 文件上传接口
-func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseMultipartForm(20 << 20); err != nil {
 		http.Error(w, "Failed to parse form data", http.StatusBadRequest)
 		return
@@ -101,8 +82,8 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// 直接保存到当前目录下的 upload 文件夹
-	uploadDir := filepath.Join(".", "upload")
+	// 保存到配置的上传目录
+	uploadDir := s.cfg.Upload.Dir
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
 		http.Error(w, "Cannot create upload dir", http.StatusInternalServerError)
 		return
@@ -132,14 +113,12 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 写入映射关系到数据库
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-	_, err = db.Exec(`INSERT OR REPLACE INTO file_map (md5, filename, file_id, url) VALUES (?, ?, ?, ?)`,
-		md5Str, handler.Filename, finalName, "/upload/"+finalName)
+	err = s.store.FileMaps.Save(r.Context(), &store.FileMapping{
+		Md5:      md5Str,
+		Filename: handler.Filename,
+		FileId:   finalName,
+		URL:      "/upload/" + finalName,
+	})
 	if err != nil {
 		http.Error(w, "Failed to insert file map: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -159,7 +138,7 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // MaterialLLMFillHandler - 材料自动填充接口（改为数据库操作）
-func MaterialLLMFillHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MaterialLLMFillHandler(w http.ResponseWriter, r *http.Request) {
 	var req map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
@@ -171,46 +150,42 @@ func MaterialLLMFillHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := sql.Open("sqlite3", "./hci.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 确保 materials 表存在
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		category TEXT,
-		status TEXT,
-		fileSize INTEGER,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to ensure table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// 更新材料状态为已填充
-	_, err = db.Exec(`UPDATE materials SET status = 'filled' WHERE id = ?`, materialId)
+	// 自动填充不改变审核状态，状态只能经由状态机流转
+	m, err := s.store.Materials.Get(r.Context(), materialId)
 	if err != nil {
-		http.Error(w, "Update error: "+err.Error(), http.StatusInternalServerError)
+		writeWorkflowError(w, err)
 		return
 	}
 
 	resp := map[string]interface{}{
 		"materialId": materialId,
-		"status":     "filled",
+		"status":     workflow.State(m.Status).PublicStatus(),
+		"state":      m.Status,
 		"message":    "Material auto-filled successfully",
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// filesJSON 将已上传的文件名转为 materials.files 存储的 JSON 数组
+func (s *Server) filesJSON(names []string) string {
+	var filesArr []map[string]interface{}
+	for _, fname := range names {
+		info, err := os.Stat(s.uploadPath(fname))
+		var fSize int64
+		if err == nil {
+			fSize = info.Size()
+		}
+		filesArr = append(filesArr, map[string]interface{}{
+			"fileUrl":  "/upload/" + fname,
+			"fileName": fname,
+			"fileSize": fSize,
+		})
+	}
+	b, _ := json.Marshal(filesArr)
+	return string(b)
+}
+
 type MaterialUploadRequest struct {
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
@@ -218,16 +193,49 @@ type MaterialUploadRequest struct {
 	Tags        []string `json:"tags"`
 	Files       []string `json:"files"`
 	AccountId   string   `json:"accountId"`
+	// AwardDate 成果取得时间，未填写时使用模型从材料中识别的时间
+	AwardDate string `json:"awardDate"`
+}
+
+// checkCutoff 确定成果取得时间并与条例截止日期比较，awardDate 为空时使用模型识别的时间
+// 成果晚于截止日期的材料在进入审核前标记，审核人可直接看到原因
+func (s *Server) checkCutoff(ctx context.Context, accountId, awardDate string, score *LLMCalculateResult) (string, string, error) {
+	awardDate = strings.TrimSpace(awardDate)
+	if awardDate == "" {
+		awardDate = strings.TrimSpace(score.AwardDate)
+	}
+	reg, err := s.regulationFor(ctx, accountId)
+	if err != nil {
+		return "", "", err
+	}
+	cutoffFlag := reg.cutoffNote(awardDate)
+	if cutoffFlag != "" {
+		score.AiRiskLevel = "high"
+		score.AiSuggestions = strings.TrimSuffix(cutoffFlag+","+score.AiSuggestions, ",")
+	}
+	return awardDate, cutoffFlag, nil
 }
 
 // MaterialUploadHandler - 材料上传完成接口（改为数据库操作）
-func MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
 	var req MaterialUploadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
 		return
 	}
 
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	// 上传者以会话身份为准，忽略客户端传入的 accountId
+	req.AccountId = principal.AccountId
+
+	if err := checkSubmissionOpen(r.Context(), s.store); err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
 	id := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%v-%v", time.Now().UnixNano(), req.Title))))
 	title := req.Title
 	description := req.Description
@@ -239,38 +247,6 @@ func MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 使用 user_info.db 以保持与材料列表数据一致
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 确保 materials 表存在，并包含完整的前端需求字段
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS materials (
-		id TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		category TEXT,
-		tags TEXT,
-		files TEXT, -- 存储多文件信息的JSON字符串
-		status TEXT,
-		uploader TEXT,
-		uploadTime TEXT,
-		reviewer TEXT,
-		reviewTime TEXT,
-		reviewComment TEXT,
-		aiScore REAL,
-		aiConfidence REAL,
-		aiSuggestions TEXT,
-		aiRiskLevel TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to ensure table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	// tags数组转为字符串
 	var tagsStr string
 	var tagsArr []string
@@ -280,38 +256,45 @@ func MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
 	tagsStr = strings.Join(tagsArr, ",")
 
 	// files数组转为JSON字符串存储
-	var filesJSON string
-	var filesArr []map[string]interface{}
-	for _, fname := range req.Files {
-		info, err := os.Stat(filepath.Join("upload", fname))
-		var fSize int64
-		if err == nil {
-			fSize = info.Size()
-		}
-		filesArr = append(filesArr, map[string]interface{}{
-			"fileUrl":  "/upload/" + fname,
-			"fileName": fname,
-			"fileSize": fSize,
-		})
-	}
-	b, _ := json.Marshal(filesArr)
-	filesJSON = string(b)
+	filesJSON := s.filesJSON(req.Files)
 
-	score, err := CalculateScore(&req)
+	score, err := s.CalculateScore(&req)
 	if err != nil {
 		http.Error(w, "Score calculation error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	fmt.Println("Calculated LLM score:", score)
 
-	// 插入材料记录到数据库, 状态设为pending，初始化 reviewer 为 "" 表示未审核
-	_, err = db.Exec(`INSERT INTO materials (
-		id, title, description, category, tags, files, status, uploader, uploadTime,
-		reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel
-	) VALUES (?, ?, ?, ?, ?, ?, 'pending', ?, datetime('now'),
-		'', '', '', ?, ?, ?, ?)`,
-		id, title, description, category, tagsStr, filesJSON, uploader,
-		score.AiScore, score.AiConfidence, score.AiSuggestions, score.AiRiskLevel)
+	awardDate, cutoffFlag, err := s.checkCutoff(r.Context(), uploader, req.AwardDate, score)
+	if err != nil {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 插入材料记录到数据库，状态为已提交，初始化 reviewer 为 "" 表示未审核
+	m := &store.Material{
+		ID:            id,
+		Title:         title,
+		Description:   description,
+		Category:      category,
+		Tags:          tagsStr,
+		Files:         filesJSON,
+		Status:        string(workflow.Submitted),
+		Uploader:      uploader,
+		AiScore:       score.AiScore,
+		AiConfidence:  score.AiConfidence,
+		AiSuggestions: score.AiSuggestions,
+		AiRiskLevel:   score.AiRiskLevel,
+		AwardDate:     awardDate,
+		CutoffFlag:    cutoffFlag,
+	}
+	err = s.store.InTx(r.Context(), func(tx *store.Store) error {
+		if err := tx.Materials.Create(r.Context(), m); err != nil {
+			return err
+		}
+		_, err := s.assignReviewers(r.Context(), tx, m, uploader)
+		return err
+	})
 	if err != nil {
 		http.Error(w, "Insert error: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -324,9 +307,12 @@ func MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
 		"category":    category,
 		"tags":        strings.Split(tagsStr, ","),
 		"files":       json.RawMessage(filesJSON),
-		"status":      "pending",
+		"status":      workflow.Submitted.PublicStatus(),
+		"state":       workflow.Submitted,
 		"uploader":    uploader,
 		"uploadTime":  time.Now().Format(time.RFC3339),
+		"awardDate":   awardDate,
+		"cutoffFlag":  cutoffFlag,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -337,7 +323,7 @@ func MaterialUploadHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // RegisterMaterialUploadRoutes 注册所有上传相关接口
-func ServeUploadFileHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) ServeUploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	// 获取文件名
 	filename := strings.TrimPrefix(r.URL.Path, "/upload/")
 	filename = strings.TrimSpace(filename)
@@ -348,7 +334,7 @@ func ServeUploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 拼接路径并检查文件是否存在
-	filePath := filepath.Join("upload", filename)
+	filePath := s.uploadPath(filename)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		http.NotFound(w, r)
 		return
@@ -358,10 +344,10 @@ func ServeUploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, filePath)
 }
 
-func RegisterMaterialUploadRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/upload/check", UploadCheckHandler)
-	mux.HandleFunc("/api/upload/file", UploadFileHandler)
-	mux.HandleFunc("/api/material/llm-fill", MaterialLLMFillHandler)
-	mux.HandleFunc("/api/material/upload", MaterialUploadHandler)
-	mux.HandleFunc("/upload/", ServeUploadFileHandler)
+func (s *Server) RegisterMaterialUploadRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/upload/check", s.UploadCheckHandler)
+	mux.HandleFunc("/api/upload/file", s.UploadFileHandler)
+	mux.HandleFunc("/api/material/llm-fill", s.MaterialLLMFillHandler)
+	mux.HandleFunc("/api/material/upload", s.MaterialUploadHandler)
+	mux.HandleFunc("/upload/", s.ServeUploadFileHandler)
 }
@@ -0,0 +1,465 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// errAlreadyReviewed 审核人在本轮已给出过通过或驳回
+var errAlreadyReviewed = errors.New("你已在本轮审核中给出过结论，不能重复审核")
+
+// errStaleRevision 审核人审核的不是材料的最新版本
+var errStaleRevision = errors.New("材料已有更新的版本，请审核最新版本")
+
+// errNotUploader 只有上传者本人可以执行该操作
+var errNotUploader = errors.New("只有材料上传者可以执行该操作")
+
+// applyAction 校验并执行一次状态变更，返回目标状态；m.Status 同步更新
+// 材料进入新一轮审核时分配审核人，退出审核时收回未处理的分配
+// 必须在事务中调用，m 应由 GetForUpdate 取得
+func (s *Server) applyAction(ctx context.Context, tx *store.Store, m *store.Material, action workflow.Action, actor, reason string) (workflow.State, error) {
+	from := workflow.State(m.Status)
+	to, err := workflow.Next(from, action)
+	if err != nil {
+		return "", err
+	}
+	newRound := workflow.NewRound(from, action)
+	err = tx.Materials.Transition(ctx, &store.MaterialTransition{
+		MaterialId: m.ID,
+		From:       string(from),
+		To:         string(to),
+		Action:     string(action),
+		Actor:      actor,
+		Reason:     reason,
+		NextRound:  newRound,
+	})
+	if err != nil {
+		return "", err
+	}
+	m.Status = string(to)
+	if newRound {
+		m.ReviewRound++
+	}
+
+	switch {
+	case to == workflow.Submitted || newRound:
+		if _, err := s.assignReviewers(ctx, tx, m, actor); err != nil {
+			return "", err
+		}
+	case !to.InReview():
+		if _, err := tx.Assignments.ReleaseByMaterial(ctx, m.ID, fmt.Sprintf("材料状态变为 %s", to)); err != nil {
+			return "", err
+		}
+	}
+	return to, nil
+}
+
+// tallyRound 统计指定轮次中针对指定版本的通过与驳回票数，旧版本上的结论不计入
+func tallyRound(reviews []store.MaterialReview, round, revision int) workflow.Tally {
+	var t workflow.Tally
+	for _, rv := range reviews {
+		if rv.Round != round || rv.Revision != revision {
+			continue
+		}
+		switch workflow.Decision(rv.Decision) {
+		case workflow.DecisionApprove:
+			t.Approvals++
+		case workflow.DecisionReject:
+			t.Rejections++
+		}
+	}
+	return t
+}
+
+// recordReview 写入一条审核意见，并在形成结论时推进材料状态
+// revision 为审核人看到的版本，不为 0 且已有更新的版本时拒绝
+// 审核意见、状态变更与展示字段在同一事务中写入
+func (s *Server) recordReview(ctx context.Context, materialId, reviewer string, decision workflow.Decision, comment string, revision int) (*store.Material, error) {
+	var result *store.Material
+	err := s.store.InTx(ctx, func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(ctx, materialId)
+		if err != nil {
+			return err
+		}
+		state := workflow.State(m.Status)
+		if !state.InReview() {
+			return &workflow.TransitionError{From: state, Action: workflow.Action(decision)}
+		}
+		if err := checkConflict(ctx, tx, reviewer, m.Uploader); err != nil {
+			return err
+		}
+		if revision != 0 && revision != m.Revision {
+			return fmt.Errorf("%w（当前为第 %d 版）", errStaleRevision, m.Revision)
+		}
+
+		reviews, err := tx.MaterialReviews.ListByMaterial(ctx, m.ID)
+		if err != nil {
+			return err
+		}
+		if decision != workflow.DecisionComment {
+			for _, rv := range reviews {
+				if rv.Round == m.ReviewRound && rv.Revision == m.Revision && rv.Reviewer == reviewer && workflow.Decision(rv.Decision) != workflow.DecisionComment {
+					return errAlreadyReviewed
+				}
+			}
+		}
+
+		rv := store.MaterialReview{
+			MaterialId: m.ID,
+			Reviewer:   reviewer,
+			Decision:   string(decision),
+			Comment:    comment,
+			Round:      m.ReviewRound,
+			Revision:   m.Revision,
+		}
+		if err := tx.MaterialReviews.Create(ctx, &rv); err != nil {
+			return err
+		}
+		if decision != workflow.DecisionComment {
+			if err := completeAssignment(ctx, tx, m, reviewer); err != nil {
+				return err
+			}
+		}
+		reviews = append(reviews, rv)
+
+		if state == workflow.Submitted {
+			if _, err := s.applyAction(ctx, tx, m, workflow.ActionStartReview, reviewer, ""); err != nil {
+				return err
+			}
+		}
+		policy, err := resolveReviewPolicy(ctx, tx, m.Category)
+		if err != nil {
+			return err
+		}
+		if action := policy.Evaluate(tallyRound(reviews, m.ReviewRound, m.Revision)); action != "" {
+			reason := comment
+			if action == workflow.ActionEscalate {
+				reason = "审核意见平票，等待管理员裁决"
+			}
+			if _, err := s.applyAction(ctx, tx, m, action, reviewer, reason); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Materials.UpdateReviewSummary(ctx, m.ID, reviewer, rv.CreatedAt, comment); err != nil {
+			return err
+		}
+		m.Reviewer, m.ReviewTime, m.ReviewComment = reviewer, rv.CreatedAt, comment
+		result = m
+		return nil
+	})
+	return result, err
+}
+
+// afterApproved 审核通过后由模型整理加分记录并重算上传者所在专业的排名，关闭服务时等待其完成
+func (s *Server) afterApproved(materialId string) {
+	s.goBackground("material-record "+materialId, func(context.Context) {
+		if err := s.DealMaterialToRecord(materialId); err != nil {
+			fmt.Println("模型写入记录错误", err)
+		}
+		ctx := context.Background()
+		m, err := s.store.Materials.Get(ctx, materialId)
+		if err != nil {
+			log.Printf("[专业排名] 查询材料 %s 失败: %v", materialId, err)
+			return
+		}
+		s.refreshStudentRanking(ctx, m.Uploader)
+	})
+}
+
+// writeWorkflowError 将状态机与仓储错误转换为 HTTP 响应
+func writeWorkflowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Material not found", http.StatusNotFound)
+	case errors.Is(err, workflow.ErrIllegalTransition), errors.Is(err, errAlreadyReviewed), errors.Is(err, errStaleRevision):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrConflict):
+		http.Error(w, "材料状态已被他人修改，请刷新后重试", http.StatusConflict)
+	case errors.Is(err, errNotUploader), errors.Is(err, errNotAssigned), errors.Is(err, errConflictOfInterest),
+		errors.Is(err, errSubmissionClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, errNotAssignable):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// uploaderAction 处理上传者对自己材料的状态操作（提交、撤回）
+func (s *Server) uploaderAction(w http.ResponseWriter, r *http.Request, action workflow.Action) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MaterialId string `json:"materialId"`
+		Reason     string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+
+	var state workflow.State
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(r.Context(), req.MaterialId)
+		if err != nil {
+			return err
+		}
+		if m.Uploader != principal.AccountId {
+			return errNotUploader
+		}
+		if action == workflow.ActionSubmit {
+			if err := checkSubmissionOpen(r.Context(), tx); err != nil {
+				return err
+			}
+		}
+		state, err = s.applyAction(r.Context(), tx, m, action, principal.AccountId, req.Reason)
+		return err
+	})
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"id":     req.MaterialId,
+			"state":  state,
+			"status": state.PublicStatus(),
+		},
+	})
+}
+
+// SubmitMaterialHandler 提交草稿或重新提交已撤回的材料
+func (s *Server) SubmitMaterialHandler(w http.ResponseWriter, r *http.Request) {
+	s.uploaderAction(w, r, workflow.ActionSubmit)
+}
+
+// WithdrawMaterialHandler 上传者在审核结论形成前撤回材料
+func (s *Server) WithdrawMaterialHandler(w http.ResponseWriter, r *http.Request) {
+	s.uploaderAction(w, r, workflow.ActionWithdraw)
+}
+
+// ListEscalatedMaterialsHandler 返回等待管理员裁决的平票材料
+func (s *Server) ListEscalatedMaterialsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	materials, err := s.store.Materials.List(r.Context(), store.MaterialFilter{Statuses: []string{string(workflow.Escalated)}})
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := make([]map[string]interface{}, 0, len(materials))
+	for _, m := range materials {
+		data = append(data, materialResponse(m))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// ResolveEscalationHandler 管理员对平票材料作出最终裁决，裁决记入状态变更历史
+func (s *Server) ResolveEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MaterialId string `json:"materialId"`
+		Decision   string `json:"decision"`
+		Comment    string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+	var action workflow.Action
+	switch decision, _ := workflow.ParseDecision(req.Decision); decision {
+	case workflow.DecisionApprove:
+		action = workflow.ActionApprove
+	case workflow.DecisionReject:
+		action = workflow.ActionReject
+	default:
+		http.Error(w, "decision 必须为 approve 或 reject", http.StatusBadRequest)
+		return
+	}
+
+	var state workflow.State
+	err := s.store.InTx(r.Context(), func(tx *store.Store) error {
+		m, err := tx.Materials.GetForUpdate(r.Context(), req.MaterialId)
+		if err != nil {
+			return err
+		}
+		if workflow.State(m.Status) != workflow.Escalated {
+			return &workflow.TransitionError{From: workflow.State(m.Status), Action: action}
+		}
+		if state, err = s.applyAction(r.Context(), tx, m, action, principal.AccountId, req.Comment); err != nil {
+			return err
+		}
+		return tx.Materials.UpdateReviewSummary(r.Context(), m.ID, principal.AccountId, store.Now(), req.Comment)
+	})
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	if state == workflow.Approved {
+		s.afterApproved(req.MaterialId)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"id":     req.MaterialId,
+			"state":  state,
+			"status": state.PublicStatus(),
+		},
+	})
+}
+
+// timelineEvent 审核时间线中的一项：审核意见或状态变更
+type timelineEvent struct {
+	Kind     string `json:"kind"`
+	Time     string `json:"time"`
+	Actor    string `json:"actor"`
+	Round    int    `json:"round,omitempty"`
+	Revision int    `json:"revision,omitempty"`
+	Decision string `json:"decision,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Action   string `json:"action,omitempty"`
+}
+
+// MaterialTimelineHandler 返回材料完整的审核时间线，按时间先后排列
+// 普通用户只能查看自己上传的材料
+func (s *Server) MaterialTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		http.Error(w, "Missing material ID", http.StatusBadRequest)
+		return
+	}
+
+	m, err := s.store.Materials.Get(r.Context(), id)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	if principal.Role == roleUser && m.Uploader != principal.AccountId {
+		http.Error(w, "Forbidden: not the uploader of this material", http.StatusForbidden)
+		return
+	}
+
+	reviews, err := s.store.MaterialReviews.ListByMaterial(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Query reviews error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	history, err := s.store.Materials.History(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Query history error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	revisions, err := s.store.Materials.Revisions(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Query revisions error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]timelineEvent, 0, len(reviews)+len(history)+len(revisions))
+	for _, rv := range reviews {
+		events = append(events, timelineEvent{
+			Kind: "review", Time: rv.CreatedAt, Actor: rv.Reviewer, Round: rv.Round, Revision: rv.Revision,
+			Decision: rv.Decision, Comment: rv.Comment,
+		})
+	}
+	// 第一版随材料创建写入，不单独列出
+	for _, rev := range revisions {
+		if rev.Revision > 1 {
+			events = append(events, timelineEvent{
+				Kind: "revision", Time: rev.CreatedAt, Actor: rev.Editor, Revision: rev.Revision, Comment: rev.Note,
+			})
+		}
+	}
+	for _, h := range history {
+		events = append(events, timelineEvent{
+			Kind: "transition", Time: h.CreatedAt, Actor: h.Actor, Comment: h.Reason,
+			From: h.From, To: h.To, Action: h.Action,
+		})
+	}
+	// 同一秒内的审核意见排在其触发的状态变更之前，同类事件保持写入顺序
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time != events[j].Time {
+			return events[i].Time < events[j].Time
+		}
+		return events[i].Kind == "review" && events[j].Kind != "review"
+	})
+
+	state := workflow.State(m.Status)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"materialId":  m.ID,
+			"state":       state,
+			"status":      state.PublicStatus(),
+			"reviewRound": m.ReviewRound,
+			"revision":    m.Revision,
+			"events":      events,
+		},
+	})
+}
+
+// RegisterMaterialWorkflowRoutes 注册材料状态流转与审核时间线路由
+func (s *Server) RegisterMaterialWorkflowRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/material/submit", s.SubmitMaterialHandler)
+	mux.HandleFunc("/api/material/withdraw", s.WithdrawMaterialHandler)
+	mux.HandleFunc("/api/material/timeline", s.MaterialTimelineHandler)
+	mux.HandleFunc("/api/material/escalated", s.ListEscalatedMaterialsHandler)
+	mux.HandleFunc("/api/material/escalation/resolve", s.ResolveEscalationHandler)
+}
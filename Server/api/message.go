@@ -5,24 +5,21 @@ import (
 	"net/http"
 )
 
-// MessageListRequest 定义消息列表请求体
-// 当前仅校验 accountId 是否存在，未来可接入数据库或其他数据源
-// 暂时返回空数据占位
-func MessageListHandler(w http.ResponseWriter, r *http.Request) {
+// MessageListHandler 返回当前登录用户的消息列表，按时间倒序
+func (s *Server) MessageListHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountID string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
-	if req.AccountID == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
+
+	messages, err := s.store.Messages.ListByAccount(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Failed to query messages: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -30,35 +27,39 @@ func MessageListHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 0,
 		"msg":  "请求成功",
-		"data": []interface{}{},
+		"data": messages,
 	})
 }
 
-// MessageReadHandler 处理消息已读请求
-// 当前阶段不做任何状态修改，仅校验参数并返回固定成功
-func MessageReadHandler(w http.ResponseWriter, r *http.Request) {
+// MessageReadHandler 将当前登录用户的指定消息标记为已读
+func (s *Server) MessageReadHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		AccountID string  `json:"accountId"`
-		IDs       []int64 `json:"ids"`
+		IDs []int64 `json:"ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	if req.AccountID == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
-		return
-	}
 	if len(req.IDs) == 0 {
 		http.Error(w, "ids is required", http.StatusBadRequest)
 		return
 	}
 
+	if _, err := s.store.Messages.MarkRead(r.Context(), principal.AccountId, req.IDs); err != nil {
+		http.Error(w, "Failed to update messages: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 0,
@@ -68,7 +69,7 @@ func MessageReadHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // RegisterMessageRoutes 注册消息中心路由
-func RegisterMessageRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/message/list", MessageListHandler)
-	mux.HandleFunc("/api/message/read", MessageReadHandler)
+func (s *Server) RegisterMessageRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/message/list", s.MessageListHandler)
+	mux.HandleFunc("/api/message/read", s.MessageReadHandler)
 }
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength 本地账号密码最小长度
+const minPasswordLength = 8
+
+// passwordChangeAllowedPaths 被要求修改密码的会话仍可访问的路由
+var passwordChangeAllowedPaths = map[string]bool{
+	"/api/user/password": true,
+	"/api/user/logout":   true,
+	"/api/user/info":     true,
+}
+
+// hashPassword 使用 bcrypt 生成带盐哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// verifyPassword 校验密码，needsRehash 表示存储的是旧版明文，需要在登录成功后重新哈希
+func verifyPassword(stored, password string) (ok bool, needsRehash bool) {
+	if stored == "" {
+		return false, false
+	}
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+	}
+	ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	return ok, ok
+}
+
+// generateInitialPassword 生成一次性初始密码
+func generateInitialPassword() (string, error) {
+	result := make([]byte, 16)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(aesChars))))
+		if err != nil {
+			return "", err
+		}
+		result[i] = aesChars[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// BootstrapUsers 首次启动时创建管理员账号，并强制旧版默认口令（密码等于用户名）的账号修改密码
+func (s *Server) BootstrapUsers() error {
+	ctx := context.Background()
+
+	// 旧版示例账号的密码与用户名相同，统一要求下次登录后修改
+	if _, err := s.store.Users.FlagDefaultPasswords(ctx); err != nil {
+		return err
+	}
+
+	admins, err := s.store.Users.CountByRole(ctx, roleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	password, err := generateInitialPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	err = s.store.Users.Create(ctx, &store.User{
+		Username:           "admin",
+		Password:           hash,
+		Name:               "管理员",
+		AccountId:          "1",
+		Certification:      1,
+		Role:               roleAdmin,
+		MustChangePassword: true,
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[初始化] 已创建管理员账号 admin，初始密码: %s（首次登录后必须修改）", password)
+	return nil
+}
+
+// ChangePasswordHandler 修改当前登录用户的密码
+func (s *Server) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		OldPassword string `json:"oldPassword"`
+		NewPassword string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		http.Error(w, "新密码长度不能少于 8 位", http.StatusBadRequest)
+		return
+	}
+	if req.NewPassword == req.OldPassword || req.NewPassword == principal.Username {
+		http.Error(w, "新密码不能与旧密码或用户名相同", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.store.Users.GetByAccountId(r.Context(), principal.AccountId)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to query user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if user.Password == "" {
+		http.Error(w, "统一身份认证账号请在学校平台修改密码", http.StatusBadRequest)
+		return
+	}
+	if ok, _ := verifyPassword(user.Password, req.OldPassword); !ok {
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	if err := s.store.Users.SetPassword(r.Context(), principal.AccountId, hash, false); err != nil {
+		http.Error(w, "Failed to update password: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 修改密码后吊销该账号的其他会话
+	_, _ = s.store.Sessions.RevokeOthers(r.Context(), principal.AccountId, hashSessionToken(principal.Token))
+
+	writeJSON(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "密码修改成功",
+		"data": true,
+	})
+}
+
+// CreateAccountHandler 管理员创建本地账号，返回一次性初始密码
+func (s *Server) CreateAccountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Username  string `json:"username"`
+		Name      string `json:"name"`
+		AccountId string `json:"accountId"`
+		Role      string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.AccountId = strings.TrimSpace(req.AccountId)
+	if req.Username == "" || req.AccountId == "" {
+		http.Error(w, "username 和 accountId 不能为空", http.StatusBadRequest)
+		return
+	}
+	if _, ok := rolePermissions[req.Role]; !ok {
+		http.Error(w, "未知角色: "+req.Role, http.StatusBadRequest)
+		return
+	}
+
+	password, err := generateInitialPassword()
+	if err != nil {
+		http.Error(w, "Failed to generate password", http.StatusInternalServerError)
+		return
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+
+	err = s.store.Users.Create(r.Context(), &store.User{
+		Username:           req.Username,
+		Password:           hash,
+		Name:               req.Name,
+		AccountId:          req.AccountId,
+		Role:               req.Role,
+		MustChangePassword: true,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create account: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "账号已创建，请将初始密码告知用户，首次登录后必须修改",
+		"data": map[string]string{
+			"username":        req.Username,
+			"accountId":       req.AccountId,
+			"role":            req.Role,
+			"initialPassword": password,
+		},
+	})
+}
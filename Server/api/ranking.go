@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// computeMajorRanking 重算一个专业的推免综合成绩排名并替换已保存的排名
+// 名单中未录入推免绩点或按成绩单不具备推免资格的学生不参与排名；每名学生的加分按其适用的条例版本汇总
+func (s *Server) computeMajorRanking(ctx context.Context, major string) ([]store.Ranking, error) {
+	s.rankingMu.Lock()
+	defer s.rankingMu.Unlock()
+
+	roster, err := s.store.Students.Roster(ctx, major)
+	if err != nil {
+		return nil, err
+	}
+	standings := make([]scoring.Standing, 0, len(roster))
+	students := make(map[string]store.Student, len(roster))
+	codes := make(map[string]string, len(roster))
+	for _, st := range roster {
+		if st.GPA <= 0 {
+			continue
+		}
+		reg, err := s.regulationFor(ctx, st.StudentId)
+		if err != nil {
+			return nil, err
+		}
+		eligibility, err := s.eligibilityFor(ctx, st.StudentId, reg)
+		if err != nil {
+			return nil, err
+		}
+		if !eligibility.Eligible {
+			continue
+		}
+		records, err := s.store.MaterialRecords.ListByAccount(ctx, st.StudentId)
+		if err != nil {
+			return nil, err
+		}
+		totals := s.bonusTotals(records, reg)
+		standings = append(standings, reg.rules.Standing(scoring.Candidate{
+			StudentId:     st.StudentId,
+			GPA:           st.GPA,
+			Academic:      totals.Academic.Total,
+			Comprehensive: totals.Comprehensive.Total,
+		}))
+		students[st.StudentId], codes[st.StudentId] = st, reg.set.Code
+	}
+	scoring.RankStandings(standings)
+
+	rows := make([]store.Ranking, 0, len(standings))
+	for _, sd := range standings {
+		rows = append(rows, store.Ranking{
+			StudentId:          sd.StudentId,
+			Name:               students[sd.StudentId].Name,
+			GPA:                sd.GPA,
+			AcademicScore:      sd.AcademicScore,
+			AcademicBonus:      sd.Academic,
+			ComprehensiveBonus: sd.Comprehensive,
+			Composite:          sd.Composite,
+			Rank:               sd.Rank,
+			Total:              len(standings),
+			Tied:               sd.Tied,
+			RegulationCode:     codes[sd.StudentId],
+		})
+	}
+	if err := s.store.Rankings.ReplaceMajor(ctx, major, rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// recomputeRankings 重算指定专业的排名，majors 为空时重算名单中的全部专业
+// 返回各专业参与排名的人数
+func (s *Server) recomputeRankings(ctx context.Context, majors []string) (map[string]int, error) {
+	if len(majors) == 0 {
+		roster, err := s.store.Students.Roster(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range roster {
+			if st.Major != "" && !containsString(majors, st.Major) {
+				majors = append(majors, st.Major)
+			}
+		}
+	}
+	counts := make(map[string]int, len(majors))
+	for _, major := range majors {
+		rows, err := s.computeMajorRanking(ctx, major)
+		if err != nil {
+			return counts, fmt.Errorf("重算专业 %s 的排名失败: %w", major, err)
+		}
+		counts[major] = len(rows)
+	}
+	return counts, nil
+}
+
+// refreshStudentRanking 学生的加分变化后重算其所在专业的排名，名单中没有该学生时不处理
+func (s *Server) refreshStudentRanking(ctx context.Context, studentId string) {
+	st, err := s.store.Students.Get(ctx, studentId)
+	if errors.Is(err, store.ErrNotFound) {
+		return
+	}
+	if err == nil && st.Major != "" {
+		_, err = s.computeMajorRanking(ctx, st.Major)
+	}
+	if err != nil {
+		log.Printf("[专业排名] 重算学生 %s 所在专业的排名失败: %v", studentId, err)
+	}
+}
+
+// RankingMajorsHandler 返回已有排名的专业及参与排名的人数
+func (s *Server) RankingMajorsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	majors, err := s.store.Rankings.Majors(r.Context())
+	if err != nil {
+		http.Error(w, "Query ranking error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": majors,
+	})
+}
+
+// RankingListHandler 返回一个专业按名次排列的推免综合成绩排名表
+func (s *Server) RankingListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	major := strings.TrimSpace(r.URL.Query().Get("major"))
+	if major == "" {
+		http.Error(w, "Missing major", http.StatusBadRequest)
+		return
+	}
+	list, err := s.store.Rankings.ListByMajor(r.Context(), major)
+	if err != nil {
+		http.Error(w, "Query ranking error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"major": major,
+			"total": len(list),
+			"list":  list,
+		},
+	})
+}
+
+// MyRankingHandler 返回当前学生的推免综合成绩与专业内名次
+func (s *Server) MyRankingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	rk, err := s.store.Rankings.Get(r.Context(), principal.AccountId)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "尚未参与专业排名，请确认学生名单中已录入推免绩点", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Query ranking error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": rk,
+	})
+}
+
+// RankingGPAHandler 录入学生的推免绩点（保留 4 位小数），并重算涉及专业的排名
+func (s *Server) RankingGPAHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Items []struct {
+			StudentId string  `json:"studentId"`
+			GPA       float64 `json:"gpa"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Items) == 0 {
+		http.Error(w, "Missing items", http.StatusBadRequest)
+		return
+	}
+
+	// 先校验全部绩点，避免只录入一部分
+	for i := range req.Items {
+		item := &req.Items[i]
+		item.StudentId = strings.TrimSpace(item.StudentId)
+		if item.StudentId == "" || item.GPA <= 0 {
+			http.Error(w, fmt.Sprintf("学号 %q 的推免绩点 %v 无效", item.StudentId, item.GPA), http.StatusBadRequest)
+			return
+		}
+		reg, err := s.regulationFor(r.Context(), item.StudentId)
+		if err != nil {
+			http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if scale := reg.rules.GPAScale; scale > 0 && item.GPA > scale {
+			http.Error(w, fmt.Sprintf("学号 %s 的推免绩点 %v 超过满绩点 %v", item.StudentId, item.GPA, scale), http.StatusBadRequest)
+			return
+		}
+	}
+
+	updated := 0
+	missing := make([]string, 0)
+	majors := make([]string, 0)
+	for _, item := range req.Items {
+		id := item.StudentId
+		st, err := s.store.Students.Get(r.Context(), id)
+		if errors.Is(err, store.ErrNotFound) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			http.Error(w, "Query student error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := s.store.Students.SetGPA(r.Context(), id, scoring.Round(item.GPA)); err != nil {
+			http.Error(w, "Save GPA error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		updated++
+		if st.Major != "" && !containsString(majors, st.Major) {
+			majors = append(majors, st.Major)
+		}
+	}
+
+	counts := map[string]int{}
+	if len(majors) > 0 {
+		var err error
+		if counts, err = s.recomputeRankings(r.Context(), majors); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"updated": updated,
+			"missing": missing,
+			"majors":  counts,
+		},
+	})
+}
+
+// RankingRecomputeHandler 手动重算指定专业的排名，major 为空时重算全部专业
+func (s *Server) RankingRecomputeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Major string `json:"major"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var majors []string
+	if major := strings.TrimSpace(req.Major); major != "" {
+		majors = append(majors, major)
+	}
+	counts, err := s.recomputeRankings(r.Context(), majors)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"majors": counts},
+	})
+}
+
+// RegisterRankingRoutes 注册推免综合成绩排名的查询、绩点录入与重算路由
+func (s *Server) RegisterRankingRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/ranking/majors", s.RankingMajorsHandler)
+	mux.HandleFunc("/api/ranking/list", s.RankingListHandler)
+	mux.HandleFunc("/api/ranking/mine", s.MyRankingHandler)
+	mux.HandleFunc("/api/ranking/gpa", s.RankingGPAHandler)
+	mux.HandleFunc("/api/ranking/recompute", s.RankingRecomputeHandler)
+}
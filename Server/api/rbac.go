@@ -0,0 +1,236 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+const (
+	roleAdmin    = "admin"
+	roleReviewer = "reviewer"
+	roleUser     = "user"
+	// roleAppealReviewer 申诉审核人，独立于普通审核人，只处理申诉
+	roleAppealReviewer = "appeal_reviewer"
+)
+
+// 权限点
+const (
+	permMaterialRead   = "material:read"
+	permMaterialUpload = "material:upload"
+	permMaterialDelete = "material:delete"
+	permMaterialReview = "material:review"
+	permBonusRead      = "bonus:read"
+	permExport         = "export"
+	permInfoImport     = "info:import"
+	permUserManage     = "user:manage"
+	permReviewAdmin    = "review:admin"
+	permAppealReview   = "appeal:review"
+	permMaterialPurge  = "material:purge"
+	permRegulation     = "regulation:manage"
+	permRankingRead    = "ranking:read"
+	permRanking        = "ranking:manage"
+)
+
+// rolePermissions 角色到权限点的映射
+var rolePermissions = map[string][]string{
+	roleAdmin: {
+		permMaterialRead, permMaterialUpload, permMaterialDelete, permMaterialReview,
+		permBonusRead, permExport, permInfoImport, permUserManage, permReviewAdmin,
+		permAppealReview, permMaterialPurge, permRegulation, permRankingRead, permRanking,
+	},
+	roleReviewer: {
+		permMaterialRead, permMaterialReview, permBonusRead, permExport, permRankingRead,
+	},
+	roleUser: {
+		permMaterialRead, permMaterialUpload, permMaterialDelete, permBonusRead,
+	},
+	roleAppealReviewer: {
+		permMaterialRead, permAppealReview,
+	},
+}
+
+// routePolicy 声明访问某个路由所需的权限
+// Method 为空表示任意方法；Prefix 为 true 时按前缀匹配 Path
+type routePolicy struct {
+	Method     string
+	Path       string
+	Prefix     bool
+	Permission string
+}
+
+// routePolicies 路由权限表，未列出的路由仅要求登录
+var routePolicies = []routePolicy{
+	// RegisterUserSettingRoutes
+	{Path: "/api/user/admin/create", Permission: permUserManage},
+
+	// RegisterMaterialListRoutes
+	{Path: "/api/material/list", Permission: permMaterialRead},
+	{Path: "/api/material/pending", Permission: permMaterialRead},
+	{Path: "/api/material/statistics", Permission: permMaterialRead},
+	{Path: "/api/material/review", Permission: permMaterialReview},
+	{Method: http.MethodDelete, Path: "/api/material/", Prefix: true, Permission: permMaterialDelete},
+
+	// RegisterMaterialWorkflowRoutes
+	{Path: "/api/material/submit", Permission: permMaterialUpload},
+	{Path: "/api/material/withdraw", Permission: permMaterialUpload},
+	{Path: "/api/material/timeline", Permission: permMaterialRead},
+	{Path: "/api/material/escalated", Permission: permReviewAdmin},
+	{Path: "/api/material/escalation/resolve", Permission: permReviewAdmin},
+
+	// RegisterAssignmentRoutes
+	{Path: "/api/material/my-queue", Permission: permMaterialReview},
+	{Path: "/api/assignment/", Prefix: true, Permission: permReviewAdmin},
+
+	// RegisterSLARoutes
+	{Path: "/api/material/sla", Permission: permMaterialRead},
+	{Path: "/api/review-phase/", Prefix: true, Permission: permReviewAdmin},
+	{Path: "/api/sla/", Prefix: true, Permission: permReviewAdmin},
+
+	// RegisterMaterialRevisionRoutes
+	{Path: "/api/material/edit", Permission: permMaterialUpload},
+	{Path: "/api/material/revisions", Permission: permMaterialRead},
+	{Path: "/api/material/revision/diff", Permission: permMaterialRead},
+
+	// RegisterMaterialTrashRoutes
+	{Path: "/api/material/trash", Permission: permMaterialDelete},
+	{Path: "/api/material/restore", Permission: permMaterialDelete},
+	{Path: "/api/material/trash/purge", Permission: permMaterialPurge},
+
+	// RegisterAppealRoutes
+	{Path: "/api/material/appeal", Permission: permMaterialUpload},
+	{Path: "/api/material/appeals", Permission: permMaterialRead},
+	{Path: "/api/appeal/", Prefix: true, Permission: permAppealReview},
+
+	// RegisterReviewPolicyRoutes
+	{Path: "/api/review-policy/", Prefix: true, Permission: permReviewAdmin},
+
+	// RegisterMaterialUploadRoutes
+	{Path: "/api/upload/check", Permission: permMaterialUpload},
+	{Path: "/api/upload/file", Permission: permMaterialUpload},
+	{Path: "/api/material/llm-fill", Permission: permMaterialUpload},
+	{Path: "/api/material/upload", Permission: permMaterialUpload},
+
+	// RegisterLLMRoutes
+	{Path: "/api/llm/form", Permission: permMaterialUpload},
+
+	// RegisterSubmitMaterialBatchRoutes
+	{Path: "/api/submit-material/batch-list", Permission: permMaterialRead},
+
+	// RegisterBonusRoutes
+	{Path: "/api/bonus/academic/list", Permission: permBonusRead},
+	{Path: "/api/bonus/comprehensive/list", Permission: permBonusRead},
+	{Path: "/api/bonus/summary", Permission: permBonusRead},
+
+	// RegisterScoringRoutes
+	{Path: "/api/bonus/rules", Permission: permBonusRead},
+	{Path: "/api/bonus/score", Permission: permBonusRead},
+	{Path: "/api/bonus/record/facts", Permission: permReviewAdmin},
+
+	// RegisterRegulationRoutes
+	{Path: "/api/regulation/list", Permission: permRegulation},
+	{Path: "/api/regulation/detail", Permission: permRegulation},
+	{Path: "/api/regulation/save", Permission: permRegulation},
+	{Path: "/api/regulation/student/bind", Permission: permRegulation},
+
+	// RegisterCatalogueRoutes
+	{Path: "/api/catalogue/list", Permission: permBonusRead},
+	{Path: "/api/catalogue/lookup", Permission: permBonusRead},
+	{Path: "/api/catalogue/save", Permission: permRegulation},
+	{Path: "/api/catalogue/delete", Permission: permRegulation},
+
+	// RegisterRankingRoutes
+	{Path: "/api/ranking/majors", Permission: permRankingRead},
+	{Path: "/api/ranking/list", Permission: permRankingRead},
+	{Path: "/api/ranking/mine", Permission: permBonusRead},
+	{Path: "/api/ranking/gpa", Permission: permRanking},
+	{Path: "/api/ranking/recompute", Permission: permRanking},
+
+	// RegisterEligibilityRoutes
+	{Path: "/api/eligibility/list", Permission: permRankingRead},
+	{Path: "/api/eligibility/mine", Permission: permBonusRead},
+
+	// RegisterExportRoutes
+	{Path: "/api/export/", Prefix: true, Permission: permExport},
+	{Path: "/api/export/report", Permission: permBonusRead},
+
+	// RegisterInfoImportRoutes
+	{Path: "/api/info/import/", Prefix: true, Permission: permInfoImport},
+}
+
+// hasPermission 判断角色是否拥有指定权限
+func hasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[strings.ToLower(strings.TrimSpace(role))] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupRoutePolicy 查找请求对应的权限要求，精确匹配优先于前缀匹配
+func lookupRoutePolicy(method, path string) (routePolicy, bool) {
+	for _, p := range routePolicies {
+		if !p.Prefix && p.Path == path && (p.Method == "" || p.Method == method) {
+			return p, true
+		}
+	}
+	var best routePolicy
+	found := false
+	for _, p := range routePolicies {
+		if p.Prefix && strings.HasPrefix(path, p.Path) && (p.Method == "" || p.Method == method) {
+			if !found || len(p.Path) > len(best.Path) {
+				best = p
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// recordAudit 写入审计日志，失败时只记录日志不影响请求
+func (s *Server) recordAudit(p *Principal, r *http.Request, permission, decision string) {
+	err := s.store.AuditLog.Append(r.Context(), &store.AuditEntry{
+		AccountId:  p.AccountId,
+		Role:       p.Role,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Permission: permission,
+		Decision:   decision,
+		IP:         r.RemoteAddr,
+	})
+	if err != nil {
+		log.Printf("[审计] 写入审计日志失败: %v", err)
+	}
+}
+
+// RBACMiddleware 按路由权限表校验当前用户角色，拒绝时返回 403 并写入审计日志
+// 需放在 AuthMiddleware 之后，以便从上下文取得当前用户
+func (s *Server) RBACMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy, ok := lookupRoutePolicy(r.Method, r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := requirePrincipal(w, r)
+		if !ok {
+			return
+		}
+		if !hasPermission(principal.Role, policy.Permission) {
+			s.recordAudit(principal, r, policy.Permission, "denied")
+			http.Error(w, "Forbidden: missing permission "+policy.Permission, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
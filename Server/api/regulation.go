@@ -0,0 +1,498 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// defaultCutoff 条例未写明时成果取得的截止日
+const defaultCutoff = "08-31"
+
+var (
+	regulationCohortPattern = regexp.MustCompile(`自\s*(\d{4})\s*届`)
+	regulationCutoffPattern = regexp.MustCompile(`截止推免当年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*日`)
+	regulationYearPattern   = regexp.MustCompile(`(\d{4})\s*年`)
+)
+
+// parseRegulationDoc 将 docs/保研条例.md 拆分为条例正文与两个附件，并读出适用届别与截止日
+// 加分表使用 rules，不从文本中解析
+func parseRegulationDoc(doc string, rules *scoring.Rules) (*store.RegulationSet, error) {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	set := &store.RegulationSet{Code: "default", Cutoff: defaultCutoff, Rules: string(data)}
+
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			set.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			break
+		}
+	}
+	if set.Title == "" {
+		set.Title = "保研条例"
+	}
+	if m := regulationYearPattern.FindStringSubmatch(set.Title); m != nil {
+		set.Code = m[1]
+	}
+
+	set.Guidelines = doc
+	if i := strings.Index(doc, "\n## 附件"); i >= 0 {
+		set.Guidelines = doc[:i]
+		appendix := doc[i:]
+		if j := strings.Index(appendix, "\n### 附件 2"); j >= 0 {
+			set.Journals, set.Competitions = strings.TrimSpace(appendix[:j]), strings.TrimSpace(appendix[j:])
+		} else {
+			set.Journals = strings.TrimSpace(appendix)
+		}
+	}
+	set.Guidelines = strings.TrimSpace(set.Guidelines)
+
+	if m := regulationCohortPattern.FindStringSubmatch(set.Guidelines); m != nil {
+		set.CohortFrom, _ = strconv.Atoi(m[1])
+	}
+	if m := regulationCutoffPattern.FindStringSubmatch(set.Guidelines); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		set.Cutoff = fmt.Sprintf("%02d-%02d", month, day)
+	}
+	return set, nil
+}
+
+// SeedRegulationSets 数据库中还没有任何条例版本时，将启动时加载的条例写入为第一个版本
+// 并为还没有目录的条例从附件导入期刊会议目录与竞赛项目库
+func (s *Server) SeedRegulationSets() error {
+	ctx := context.Background()
+	current, err := s.store.Regulations.Current(ctx)
+	if err != nil {
+		return fmt.Errorf("查询保研条例版本失败: %w", err)
+	}
+	if len(current) == 0 {
+		set := *s.regulationDoc
+		set.CreatedBy = "system"
+		set.Note = "由 保研条例.md 导入"
+		if err := s.store.Regulations.Create(ctx, &set); err != nil {
+			return fmt.Errorf("写入保研条例失败: %w", err)
+		}
+		log.Printf("[保研条例] 已导入 %s（适用于 %d 届起）", set.Title, set.CohortFrom)
+		s.regulationDoc = &set
+		current = append(current, set)
+	}
+	for i := range current {
+		if _, err := s.seedCatalogue(ctx, &current[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// regulation 一名学生适用的保研条例与解析后的加分表
+type regulation struct {
+	set   *store.RegulationSet
+	rules *scoring.Rules
+	// cohort 学生的毕业年份，名单中没有时为 0
+	cohort int
+	// matched 为 false 表示没有适用于该学生的条例版本，使用启动时加载的条例
+	matched bool
+}
+
+// newRegulation 解析条例版本中的加分表
+func newRegulation(set *store.RegulationSet, cohort int, matched bool) (*regulation, error) {
+	var rules scoring.Rules
+	if err := json.Unmarshal([]byte(set.Rules), &rules); err != nil {
+		return nil, fmt.Errorf("条例 %s 第 %d 版加分表无效: %w", set.Code, set.Version, err)
+	}
+	return &regulation{set: set, rules: &rules, cohort: cohort, matched: matched}, nil
+}
+
+// regulationFor 返回学号对应学生适用的条例：按名单中的毕业年份匹配条例版本
+// 毕业年份未知或没有适用版本时使用启动时加载的条例
+func (s *Server) regulationFor(ctx context.Context, accountId string) (*regulation, error) {
+	cohort, err := s.store.Students.GraduationYear(ctx, accountId)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if cohort > 0 {
+		set, err := s.store.Regulations.ForCohort(ctx, cohort)
+		if err == nil {
+			return newRegulation(set, cohort, true)
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return &regulation{set: s.regulationDoc, rules: s.rules, cohort: cohort}, nil
+}
+
+// cutoffDate 成果取得的截止日期（推免当年即毕业前一年），毕业年份未知时返回空
+func (g *regulation) cutoffDate() string {
+	if g.cohort <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d-%s", g.cohort-1, g.set.Cutoff)
+}
+
+// afterCutoff 报告成果取得时间是否晚于截止日期，时间无法识别或毕业年份未知时不作判断
+func (g *regulation) afterCutoff(date string) bool {
+	cutoff := g.cutoffDate()
+	return cutoff != "" && scoring.AfterCutoff(date, cutoff)
+}
+
+// cutoffNote 成果取得时间晚于截止日期时返回不予加分的说明，否则返回空
+func (g *regulation) cutoffNote(date string) string {
+	if !g.afterCutoff(date) {
+		return ""
+	}
+	return fmt.Sprintf("成果取得时间 %s 晚于条例截止日期 %s，不予加分", strings.TrimSpace(date), g.cutoffDate())
+}
+
+// prompt 附在大模型提示词中的条例全文，包括附件目录与适用届别的截止日期
+func (g *regulation) prompt() string {
+	var b strings.Builder
+	b.WriteString("保研条例如下，请严格按照条例要求进行材料填写，否则不予通过。\n")
+	b.WriteString(g.set.Guidelines)
+	for _, appendix := range []string{g.set.Journals, g.set.Competitions} {
+		if appendix != "" {
+			b.WriteString("\n\n")
+			b.WriteString(appendix)
+		}
+	}
+	if cutoff := g.cutoffDate(); cutoff != "" {
+		fmt.Fprintf(&b, "\n\n该学生为 %d 届毕业生，各类成果的取得截止 %s。", g.cohort, cutoff)
+	}
+	return b.String()
+}
+
+// RegulationSummary 条例版本的概要，不含正文
+type RegulationSummary struct {
+	ID         int64  `json:"id"`
+	Code       string `json:"code"`
+	Version    int    `json:"version"`
+	Title      string `json:"title"`
+	CohortFrom int    `json:"cohortFrom"`
+	CohortTo   int    `json:"cohortTo"`
+	Cutoff     string `json:"cutoff"`
+	Note       string `json:"note"`
+	CreatedBy  string `json:"createdBy"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+func regulationSummary(set *store.RegulationSet) RegulationSummary {
+	return RegulationSummary{
+		ID:         set.ID,
+		Code:       set.Code,
+		Version:    set.Version,
+		Title:      set.Title,
+		CohortFrom: set.CohortFrom,
+		CohortTo:   set.CohortTo,
+		Cutoff:     set.Cutoff,
+		Note:       set.Note,
+		CreatedBy:  set.CreatedBy,
+		CreatedAt:  set.CreatedAt,
+	}
+}
+
+func regulationSummaries(sets []store.RegulationSet) []RegulationSummary {
+	list := make([]RegulationSummary, 0, len(sets))
+	for i := range sets {
+		list = append(list, regulationSummary(&sets[i]))
+	}
+	return list
+}
+
+// RegulationListHandler 返回每套条例的最新版本，?code= 时返回该条例的全部版本
+func (s *Server) RegulationListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		sets []store.RegulationSet
+		err  error
+	)
+	if code := strings.TrimSpace(r.URL.Query().Get("code")); code != "" {
+		sets, err = s.store.Regulations.Versions(r.Context(), code)
+	} else {
+		sets, err = s.store.Regulations.Current(r.Context())
+	}
+	if err != nil {
+		http.Error(w, "Query regulations error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": regulationSummaries(sets),
+	})
+}
+
+// RegulationDetailHandler 返回指定版本条例的全文与加分表
+func (s *Server) RegulationDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+	set, err := s.store.Regulations.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Regulation not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Query regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"regulation":   regulationSummary(set),
+			"guidelines":   set.Guidelines,
+			"journals":     set.Journals,
+			"competitions": set.Competitions,
+			"rules":        json.RawMessage(set.Rules),
+		},
+	})
+}
+
+// RegulationSaveRequest 保存条例新版本的请求
+// code 已存在时为该条例的新版本，未填写的字段沿用最新版本；rules 只需包含要修改的加分表字段
+type RegulationSaveRequest struct {
+	Code         string          `json:"code"`
+	Title        string          `json:"title"`
+	CohortFrom   int             `json:"cohortFrom"`
+	CohortTo     *int            `json:"cohortTo"`
+	Cutoff       string          `json:"cutoff"`
+	Guidelines   string          `json:"guidelines"`
+	Journals     *string         `json:"journals"`
+	Competitions *string         `json:"competitions"`
+	Rules        json.RawMessage `json:"rules"`
+	Note         string          `json:"note"`
+}
+
+// RegulationSaveHandler 保存条例的新版本，已有版本不会被修改
+func (s *Server) RegulationSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req RegulationSaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" {
+		http.Error(w, "Missing code", http.StatusBadRequest)
+		return
+	}
+
+	set := &store.RegulationSet{Code: req.Code, Cutoff: defaultCutoff}
+	versions, err := s.store.Regulations.Versions(r.Context(), req.Code)
+	if err != nil {
+		http.Error(w, "Query regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(versions) > 0 {
+		latest := versions[0]
+		latest.ID, latest.Version, latest.Note = 0, 0, ""
+		set = &latest
+	}
+	if t := strings.TrimSpace(req.Title); t != "" {
+		set.Title = t
+	}
+	if req.CohortFrom != 0 {
+		set.CohortFrom = req.CohortFrom
+	}
+	if req.CohortTo != nil {
+		set.CohortTo = *req.CohortTo
+	}
+	if c := strings.TrimSpace(req.Cutoff); c != "" {
+		set.Cutoff = c
+	}
+	if strings.TrimSpace(req.Guidelines) != "" {
+		set.Guidelines = req.Guidelines
+	}
+	if req.Journals != nil {
+		set.Journals = *req.Journals
+	}
+	if req.Competitions != nil {
+		set.Competitions = *req.Competitions
+	}
+	if set.Rules == "" {
+		set.Rules = s.regulationDoc.Rules
+	}
+	set.Note = req.Note
+	set.CreatedBy = principal.AccountId
+	set.CreatedAt = ""
+
+	// 提交的加分表只需包含要修改的字段，其余沿用上一版本
+	rules, err := mergeRules(set.Rules, req.Rules)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	set.Rules = rules
+	if err := validateRegulation(set); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.store.Regulations.Create(r.Context(), set); err != nil {
+		http.Error(w, "Save regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 新条例第一次保存时由附件生成目录，之后以目录接口维护的数据为准
+	if _, err := s.seedCatalogue(r.Context(), set); err != nil {
+		http.Error(w, "Seed catalogue error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": regulationSummary(set),
+	})
+}
+
+// validateRegulation 校验条例版本的届别范围、截止日与加分表
+func validateRegulation(set *store.RegulationSet) error {
+	if set.Title == "" || strings.TrimSpace(set.Guidelines) == "" {
+		return errors.New("条例标题与正文不能为空")
+	}
+	if set.CohortFrom <= 0 {
+		return errors.New("cohortFrom 必须为毕业年份")
+	}
+	if set.CohortTo != 0 && set.CohortTo < set.CohortFrom {
+		return errors.New("cohortTo 不能早于 cohortFrom")
+	}
+	if _, err := time.Parse("01-02", set.Cutoff); err != nil {
+		return fmt.Errorf("截止日 %q 格式应为 MM-DD", set.Cutoff)
+	}
+	return nil
+}
+
+// mergeRules 将 patch 中的字段覆盖到 base 加分表上，patch 含未知字段时返回错误
+func mergeRules(base string, patch json.RawMessage) (string, error) {
+	var rules scoring.Rules
+	if err := json.Unmarshal([]byte(base), &rules); err != nil {
+		return "", fmt.Errorf("上一版本加分表无效: %w", err)
+	}
+	if len(patch) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(patch))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rules); err != nil {
+			return "", fmt.Errorf("加分表无效: %w", err)
+		}
+	}
+	data, err := json.Marshal(&rules)
+	return string(data), err
+}
+
+// MyRegulationHandler 返回当前用户适用的条例版本与成果截止日期
+func (s *Server) MyRegulationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	reg, err := s.regulationFor(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"regulation":     regulationSummary(reg.set),
+			"graduationYear": reg.cohort,
+			"cutoffDate":     reg.cutoffDate(),
+			"matched":        reg.matched,
+		},
+	})
+}
+
+// BindGraduationYearHandler 设置名单中学生的毕业年份，学生据此使用对应届别的条例
+func (s *Server) BindGraduationYearHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		StudentIds     []string `json:"studentIds"`
+		GraduationYear int      `json:"graduationYear"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.StudentIds) == 0 || req.GraduationYear <= 0 {
+		http.Error(w, "Missing studentIds or graduationYear", http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.store.Students.SetGraduationYear(r.Context(), req.StudentIds, req.GraduationYear)
+	if err != nil {
+		http.Error(w, "Update students error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var applied *RegulationSummary
+	set, err := s.store.Regulations.ForCohort(r.Context(), req.GraduationYear)
+	if err == nil {
+		summary := regulationSummary(set)
+		applied = &summary
+	} else if !errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"updated":    n,
+			"regulation": applied,
+		},
+	})
+}
+
+// RegisterRegulationRoutes 注册保研条例版本管理与学生届别绑定路由
+func (s *Server) RegisterRegulationRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/regulation/list", s.RegulationListHandler)
+	mux.HandleFunc("/api/regulation/detail", s.RegulationDetailHandler)
+	mux.HandleFunc("/api/regulation/save", s.RegulationSaveHandler)
+	mux.HandleFunc("/api/regulation/mine", s.MyRegulationHandler)
+	mux.HandleFunc("/api/regulation/student/bind", s.BindGraduationYearHandler)
+}
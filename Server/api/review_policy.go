@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// policyFromRecord 将数据库中的规则转换为状态机使用的规则
+func policyFromRecord(p *store.ReviewPolicy) workflow.Policy {
+	return workflow.Policy{
+		RequiredApprovals: p.RequiredApprovals,
+		Quorum:            p.Quorum,
+		Rejection:         workflow.RejectionMode(p.RejectionMode),
+		Rule:              workflow.VoteRule(p.VoteRule),
+		TieBreak:          workflow.TieBreak(p.TieBreak),
+	}
+}
+
+// resolveReviewPolicy 返回材料类别适用的审核规则
+// 类别未单独配置时使用默认规则，默认规则被删除时回退到 workflow.DefaultPolicy
+func resolveReviewPolicy(ctx context.Context, st *store.Store, category string) (workflow.Policy, error) {
+	for _, c := range []string{category, store.DefaultPolicyCategory} {
+		p, err := st.ReviewPolicies.Get(ctx, c)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return workflow.Policy{}, err
+		}
+		return policyFromRecord(p), nil
+	}
+	return workflow.DefaultPolicy, nil
+}
+
+// ListReviewPoliciesHandler 返回全部审核规则，category 为 * 的是默认规则
+func (s *Server) ListReviewPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	policies, err := s.store.ReviewPolicies.List(r.Context())
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": policies,
+	})
+}
+
+// SaveReviewPolicyHandler 新增或修改某一材料类别的审核规则，对之后写入的审核意见生效
+func (s *Server) SaveReviewPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req store.ReviewPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Category = strings.TrimSpace(req.Category)
+	if req.Category == "" {
+		http.Error(w, "Missing category", http.StatusBadRequest)
+		return
+	}
+	if req.TieBreak == "" {
+		req.TieBreak = string(workflow.TieEscalate)
+	}
+	if err := policyFromRecord(&req).Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UpdatedBy = principal.AccountId
+	req.UpdatedAt = ""
+
+	if err := s.store.ReviewPolicies.Save(r.Context(), &req); err != nil {
+		http.Error(w, "Save error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": req,
+	})
+}
+
+// DeleteReviewPolicyHandler 删除某一材料类别的审核规则，该类别此后使用默认规则
+// 默认规则不能删除，只能修改
+func (s *Server) DeleteReviewPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Category string `json:"category"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Category) == "" {
+		http.Error(w, "Missing category", http.StatusBadRequest)
+		return
+	}
+	if req.Category == store.DefaultPolicyCategory {
+		http.Error(w, "默认审核规则不能删除", http.StatusBadRequest)
+		return
+	}
+
+	err := s.store.ReviewPolicies.Delete(r.Context(), req.Category)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Policy not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Delete error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+	})
+}
+
+// RegisterReviewPolicyRoutes 注册审核规则管理路由
+func (s *Server) RegisterReviewPolicyRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/review-policy/list", s.ListReviewPoliciesHandler)
+	mux.HandleFunc("/api/review-policy/save", s.SaveReviewPolicyHandler)
+	mux.HandleFunc("/api/review-policy/delete", s.DeleteReviewPolicyHandler)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// applyScore 按材料所有者适用的条例计算加分记录的学院核定加分与加分依据，并保存按目录修正后的计分要素
+// 要素无效或不在目录中时记 0 分并在加分依据中注明需人工核定；成果晚于条例截止日期时记 0 分；均不返回错误
+func (s *Server) applyScore(ctx context.Context, rec *MaterialRecord, facts scoring.Facts, reg *regulation) error {
+	facts, result, err := s.scoreFacts(ctx, reg, facts)
+	if err != nil && !errors.Is(err, scoring.ErrInvalidFacts) {
+		return err
+	}
+	data, merr := json.Marshal(facts)
+	if merr != nil {
+		return merr
+	}
+	rec.Facts = string(data)
+
+	if errors.Is(err, scoring.ErrInvalidFacts) {
+		rec.CollegeScore = 0
+		rec.ScoreBasis = "需人工核定：" + err.Error()
+		return nil
+	}
+	rec.Category = result.Section
+	rec.CollegeScore = result.Points
+	rec.ScoreBasis = result.Basis
+	if note := reg.cutoffNote(rec.AwardDate); note != "" {
+		rec.CollegeScore = 0
+		rec.ScoreBasis = note
+	}
+	return nil
+}
+
+// recordFacts 解析加分记录中保存的计分要素，旧记录没有要素时返回零值
+func recordFacts(rec *MaterialRecord) scoring.Facts {
+	var facts scoring.Facts
+	_ = json.Unmarshal([]byte(rec.Facts), &facts)
+	return facts
+}
+
+// principalRegulation 返回当前用户适用的条例，失败时已写入错误响应
+func (s *Server) principalRegulation(w http.ResponseWriter, r *http.Request) (*regulation, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return nil, false
+	}
+	reg, err := s.regulationFor(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return reg, true
+}
+
+// ScoringRulesHandler 返回当前用户适用的保研条例加分表
+func (s *Server) ScoringRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reg, ok := s.principalRegulation(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": reg.rules,
+	})
+}
+
+// ScorePreviewHandler 按计分要素试算分值，不写入任何记录，供学生自评与审核人核对
+// 论文期刊会议与竞赛名称不在当前用户适用条例的目录中时返回 400
+func (s *Server) ScorePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var facts scoring.Facts
+	if err := json.NewDecoder(r.Body).Decode(&facts); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	reg, ok := s.principalRegulation(w, r)
+	if !ok {
+		return
+	}
+	_, result, err := s.scoreFacts(r.Context(), reg, facts)
+	if errors.Is(err, scoring.ErrInvalidFacts) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Score error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": result,
+	})
+}
+
+// CorrectRecordFactsHandler 审核管理员修正已通过材料的计分要素并重新计分
+// 材料尚无加分记录（如模型整理失败）时以材料信息新建记录
+func (s *Server) CorrectRecordFactsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		MaterialId string        `json:"materialId"`
+		Facts      scoring.Facts `json:"facts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.MaterialId) == "" {
+		http.Error(w, "Missing materialId", http.StatusBadRequest)
+		return
+	}
+	m, err := s.store.Materials.Get(r.Context(), req.MaterialId)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	reg, err := s.regulationFor(r.Context(), m.Uploader)
+	if err != nil {
+		http.Error(w, "Resolve regulation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, _, err := s.scoreFacts(r.Context(), reg, req.Facts); errors.Is(err, scoring.ErrInvalidFacts) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Score error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if workflow.State(m.Status) != workflow.Approved {
+		http.Error(w, "只有审核通过的材料才有加分记录", http.StatusConflict)
+		return
+	}
+
+	rec, err := s.store.MaterialRecords.Get(r.Context(), m.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		rec = &MaterialRecord{MaterialId: m.ID, AccountId: m.Uploader, Type: m.Category, Project: m.Title}
+	} else if err != nil {
+		http.Error(w, "Query record error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.applyScore(r.Context(), rec, req.Facts, reg); err != nil {
+		http.Error(w, "Score error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.store.MaterialRecords.Save(r.Context(), rec); err != nil {
+		http.Error(w, "Save record error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.refreshStudentRanking(r.Context(), rec.AccountId)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"materialId": rec.MaterialId,
+			"category":   rec.Category,
+			"record":     convertToBonusRecord(*rec),
+			"facts":      recordFacts(rec),
+		},
+	})
+}
+
+// RegisterScoringRoutes 注册加分表查询、试算与计分要素修正路由
+func (s *Server) RegisterScoringRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/bonus/rules", s.ScoringRulesHandler)
+	mux.HandleFunc("/api/bonus/score", s.ScorePreviewHandler)
+	mux.HandleFunc("/api/bonus/record/facts", s.CorrectRecordFactsHandler)
+}
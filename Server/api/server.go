@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vintcessun/HCIBGA/Server/config"
+	"github.com/vintcessun/HCIBGA/Server/scoring"
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// Server 持有各接口共享的依赖，由 main 在启动时注入
+type Server struct {
+	store   *store.Store
+	cfg     *config.Config
+	prompts prompts
+	// rules 内置的保研条例加分表，与 regulationDoc 一起用于没有适用条例版本的学生
+	rules *scoring.Rules
+	// regulationDoc 由 保研条例.md 解析出的条例，首次启动时写入为第一个条例版本
+	regulationDoc *store.RegulationSet
+	// sessionKey 会话令牌的签名密钥，启动时从 server.sessionKeyFile 读取
+	sessionKey []byte
+	// rankingMu 串行化专业排名的重算，避免并发重算时较旧的结果覆盖较新的结果
+	rankingMu sync.Mutex
+
+	// stopCtx 在开始关闭时取消，可中断的后台任务据此提前退出
+	stopCtx context.Context
+	stop    context.CancelFunc
+
+	jobsMu   sync.Mutex
+	jobs     sync.WaitGroup
+	stopping bool
+}
+
+// prompts 大模型调用使用的提示词与保研条例
+type prompts struct {
+	form       string
+	guidelines string
+	calculate  string
+	analyze    string
+	// facts 计分要素的格式说明，附在需要模型提取要素的提示词之后
+	facts string
+}
+
+// NewServer 使用已打开的数据库和已校验的配置创建接口服务
+func NewServer(st *store.Store, cfg *config.Config) (*Server, error) {
+	p, err := loadPrompts(cfg.LLM.PromptDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cfg.Upload.Dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("创建上传目录 %s 失败: %w", cfg.Upload.Dir, err)
+	}
+	sessionKey, err := loadSessionKey(cfg.Server.SessionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	rules := scoring.DefaultRules()
+	doc, err := parseRegulationDoc(p.guidelines, rules)
+	if err != nil {
+		return nil, fmt.Errorf("解析保研条例失败: %w", err)
+	}
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &Server{store: st, cfg: cfg, prompts: p, sessionKey: sessionKey, rules: rules, regulationDoc: doc, stopCtx: stopCtx, stop: stop}, nil
+}
+
+// goBackground 启动受跟踪的后台任务，Shutdown 会等待其结束
+// fn 收到的 ctx 在开始关闭时取消；不可中断的任务可以忽略它
+// 关闭开始后再提交的任务在当前请求中同步执行，保证不丢失
+func (s *Server) goBackground(name string, fn func(ctx context.Context)) {
+	s.jobsMu.Lock()
+	if s.stopping {
+		s.jobsMu.Unlock()
+		log.Printf("[后台任务] 服务正在关闭，同步执行 %s", name)
+		fn(s.stopCtx)
+		return
+	}
+	s.jobs.Add(1)
+	s.jobsMu.Unlock()
+
+	go func() {
+		defer s.jobs.Done()
+		fn(s.stopCtx)
+	}()
+}
+
+// Drain 标记服务开始关闭：就绪检查返回 503，可中断的后台任务收到取消信号
+// 可重复调用；应在 http.Server.Shutdown 之前调用，让负载均衡尽早摘除流量
+func (s *Server) Drain() {
+	s.jobsMu.Lock()
+	s.stopping = true
+	s.jobsMu.Unlock()
+	s.stop()
+}
+
+// Draining 报告服务是否已开始关闭
+func (s *Server) Draining() bool {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	return s.stopping
+}
+
+// Shutdown 等待后台任务结束，超过 ctx 期限时返回错误
+// 应在 http.Server.Shutdown 排空请求之后调用，此后不再接受新的后台任务
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
+
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待后台任务结束超时: %w", ctx.Err())
+	}
+}
+
+func loadPrompts(dir string) (prompts, error) {
+	var p prompts
+	files := []struct {
+		name string
+		dst  *string
+	}{
+		{"识别文件.md", &p.form},
+		{"保研条例.md", &p.guidelines},
+		{"审核信息.md", &p.calculate},
+		{"信息分析.md", &p.analyze},
+		{"计分要素.md", &p.facts},
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.name))
+		if err != nil {
+			return prompts{}, fmt.Errorf("读取提示词 %s 失败: %w", f.name, err)
+		}
+		*f.dst = string(data)
+	}
+	return p, nil
+}
+
+// uploadPath 返回上传文件在本地磁盘上的路径
+func (s *Server) uploadPath(name string) string {
+	return filepath.Join(s.cfg.Upload.Dir, name)
+}
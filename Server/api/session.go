@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+)
+
+// sessionTTL 会话有效期
+const sessionTTL = 7 * 24 * time.Hour
+
+// Principal 表示当前请求已认证的用户身份
+type Principal struct {
+	AccountId string `json:"accountId"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	Token     string `json:"-"`
+	// MustChangePassword 首次登录或使用默认口令的账号需先修改密码
+	MustChangePassword bool `json:"mustChangePassword"`
+}
+
+type principalContextKey struct{}
+
+// publicPaths 无需登录即可访问的路由
+var publicPaths = map[string]bool{
+	"/api/user/auth":           true,
+	"/api/user/qr-code":        true,
+	"/api/user/qr-status":      true,
+	"/api/user/qr-auth-result": true,
+	"/healthz":                 true,
+	"/readyz":                  true,
+}
+
+// publicPrefixes 无需登录即可访问的路由前缀（图片等静态资源由 <img> 直接加载，无法附带请求头）
+var publicPrefixes = []string{
+	"/upload/",
+}
+
+var errInvalidSession = errors.New("invalid or expired session")
+
+// loadSessionKey 读取 server.sessionKeyFile 中的会话签名密钥，文件不存在或为空时生成新密钥并写入
+func loadSessionKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return []byte(strings.TrimSpace(string(data))), nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("读取会话密钥 %s 失败: %w", path, err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建会话密钥目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		return nil, fmt.Errorf("写入会话密钥 %s 失败: %w", path, err)
+	}
+	return []byte(key), nil
+}
+
+func signSessionID(key []byte, id string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueSession 为指定账号签发新的会话令牌并写入服务端会话表
+func (s *Server) issueSession(ctx context.Context, accountId, username, role, ip string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(buf)
+	token := id + "." + signSessionID(s.sessionKey, id)
+	expiresAt := time.Now().UTC().Add(sessionTTL)
+
+	err := s.store.Sessions.Create(ctx, &store.Session{
+		TokenHash: hashSessionToken(token),
+		AccountId: accountId,
+		Username:  username,
+		Role:      role,
+		IP:        ip,
+		ExpiresAt: store.FormatTime(expiresAt),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// resolveSession 校验令牌签名，并确认服务端会话未过期、未被吊销
+func (s *Server) resolveSession(ctx context.Context, token string) (*Principal, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(signSessionID(s.sessionKey, id))) {
+		return nil, errInvalidSession
+	}
+
+	sess, err := s.store.Sessions.Active(ctx, hashSessionToken(token))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errInvalidSession
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &Principal{Token: token, AccountId: sess.AccountId, Username: sess.Username, Role: sess.Role}
+
+	// 角色以用户表为准，便于管理员调整角色后立即生效
+	if u, err := s.store.Users.GetByAccountId(ctx, p.AccountId); err == nil {
+		if u.Role != "" {
+			p.Role = u.Role
+		}
+		p.MustChangePassword = u.MustChangePassword
+	}
+	return p, nil
+}
+
+// revokeSession 吊销指定令牌
+func (s *Server) revokeSession(ctx context.Context, token string) error {
+	return s.store.Sessions.Revoke(ctx, hashSessionToken(token))
+}
+
+func bearerToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func isPublicPath(path string) bool {
+	if publicPaths[path] {
+		return true
+	}
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthMiddleware 从 Authorization 头解析会话令牌，并将当前用户写入请求上下文
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			http.Error(w, "Unauthorized: missing token", http.StatusUnauthorized)
+			return
+		}
+
+		p, err := s.resolveSession(r.Context(), token)
+		if errors.Is(err, errInvalidSession) {
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Session lookup failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if p.MustChangePassword && !passwordChangeAllowedPaths[r.URL.Path] {
+			http.Error(w, "Forbidden: password change required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, p)))
+	})
+}
+
+// CurrentPrincipal 返回当前请求的登录用户
+func CurrentPrincipal(r *http.Request) (*Principal, bool) {
+	p, ok := r.Context().Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// requirePrincipal 获取当前登录用户，未登录时直接写入 401
+func requirePrincipal(w http.ResponseWriter, r *http.Request) (*Principal, bool) {
+	p, ok := CurrentPrincipal(r)
+	if !ok || p.AccountId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return p, true
+}
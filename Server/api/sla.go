@@ -0,0 +1,576 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vintcessun/HCIBGA/Server/store"
+	"github.com/vintcessun/HCIBGA/Server/workflow"
+)
+
+// errSubmissionClosed 提交窗口已关闭，不再接受上传与提交
+var errSubmissionClosed = errors.New("材料提交窗口已关闭")
+
+// phaseTimeLayouts 截止时间接受的格式；不带时区的时间按 UTC 解释，与数据库时间列一致
+var phaseTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// loadPhase 返回阶段配置，未配置时返回不设截止时间与时限的空配置
+func loadPhase(ctx context.Context, st *store.Store, phase string) (*store.ReviewPhase, error) {
+	p, err := st.ReviewPhases.Get(ctx, phase)
+	if errors.Is(err, store.ErrNotFound) {
+		return &store.ReviewPhase{Phase: phase}, nil
+	}
+	return p, err
+}
+
+// checkSubmissionOpen 提交窗口截止后返回 errSubmissionClosed
+func checkSubmissionOpen(ctx context.Context, st *store.Store) error {
+	p, err := loadPhase(ctx, st, store.PhaseSubmission)
+	if err != nil || p.ClosesAt == "" {
+		return err
+	}
+	closes, err := store.ParseTime(p.ClosesAt)
+	if err != nil {
+		return fmt.Errorf("提交窗口截止时间 %q 无效: %w", p.ClosesAt, err)
+	}
+	if time.Now().After(closes) {
+		return fmt.Errorf("%w（截止时间 %s UTC）", errSubmissionClosed, p.ClosesAt)
+	}
+	return nil
+}
+
+// reviewDeadline 审核阶段的截止时间与单条分配的处理时限，零值表示不限
+type reviewDeadline struct {
+	closes time.Time
+	sla    time.Duration
+}
+
+func loadReviewDeadline(ctx context.Context, st *store.Store) (reviewDeadline, error) {
+	p, err := loadPhase(ctx, st, store.PhaseReview)
+	if err != nil {
+		return reviewDeadline{}, err
+	}
+	d := reviewDeadline{sla: time.Duration(p.SLAHours) * time.Hour}
+	if p.ClosesAt != "" {
+		if d.closes, err = store.ParseTime(p.ClosesAt); err != nil {
+			return reviewDeadline{}, fmt.Errorf("审核窗口截止时间 %q 无效: %w", p.ClosesAt, err)
+		}
+	}
+	return d, nil
+}
+
+// due 返回从 start 开始计时的截止时间：处理时限与审核窗口截止时间中较早者，均未设置时返回零值
+func (d reviewDeadline) due(start time.Time) time.Time {
+	var due time.Time
+	if d.sla > 0 {
+		due = start.Add(d.sla)
+	}
+	if !d.closes.IsZero() && (due.IsZero() || d.closes.Before(due)) {
+		due = d.closes
+	}
+	return due
+}
+
+// dueInfo 截止时间的展示字段，未设置截止时间时只返回 overdue=false
+func dueInfo(due, now time.Time) map[string]interface{} {
+	info := map[string]interface{}{"overdue": false}
+	if due.IsZero() {
+		return info
+	}
+	info["dueAt"] = store.FormatTime(due)
+	if now.After(due) {
+		info["overdue"] = true
+		info["overdueHours"] = hours(now.Sub(due))
+	}
+	return info
+}
+
+// hours 将时长换算为保留一位小数的小时数
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*10) / 10
+}
+
+// timeInState 根据状态变更历史统计材料在各状态累计停留的时长，并返回进入当前状态的时间
+func timeInState(history []store.MaterialTransition, now time.Time) (map[string]time.Duration, time.Time) {
+	durations := make(map[string]time.Duration)
+	var entered time.Time
+	for i, h := range history {
+		start, err := store.ParseTime(h.CreatedAt)
+		if err != nil {
+			continue
+		}
+		end := now
+		if i+1 < len(history) {
+			if next, err := store.ParseTime(history[i+1].CreatedAt); err == nil {
+				end = next
+			}
+		}
+		if end.After(start) {
+			durations[h.To] += end.Sub(start)
+		}
+		entered = start
+	}
+	return durations, entered
+}
+
+// MaterialSLAHandler 返回材料在各状态的停留时长、当前状态的截止时间与本轮各审核分配的处理期限
+// 普通用户只能查看自己上传的材料
+func (s *Server) MaterialSLAHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if id == "" {
+		http.Error(w, "Missing material ID", http.StatusBadRequest)
+		return
+	}
+	m, err := s.store.Materials.Get(r.Context(), id)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	if principal.Role == roleUser && m.Uploader != principal.AccountId {
+		http.Error(w, "Forbidden: not the uploader of this material", http.StatusForbidden)
+		return
+	}
+
+	history, err := s.store.Materials.History(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Query history error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	assignments, err := s.store.Assignments.ListByMaterial(r.Context(), id, m.ReviewRound)
+	if err != nil {
+		http.Error(w, "Query assignments error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	deadline, err := loadReviewDeadline(r.Context(), s.store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	durations, entered := timeInState(history, now)
+	byState := make(map[string]float64, len(durations))
+	for st, d := range durations {
+		byState[st] = hours(d)
+	}
+
+	state := workflow.State(m.Status)
+	data := map[string]interface{}{
+		"materialId":  m.ID,
+		"state":       state,
+		"status":      state.PublicStatus(),
+		"reviewRound": m.ReviewRound,
+		"timeInState": byState,
+	}
+	if !entered.IsZero() {
+		data["enteredAt"] = store.FormatTime(entered)
+		data["hoursInState"] = hours(now.Sub(entered))
+		if state.InReview() || state == workflow.Escalated {
+			for k, v := range dueInfo(deadline.due(entered), now) {
+				data[k] = v
+			}
+		}
+	}
+
+	items := make([]map[string]interface{}, 0, len(assignments))
+	for _, a := range assignments {
+		item := map[string]interface{}{
+			"id":          a.ID,
+			"reviewer":    a.Reviewer,
+			"status":      a.Status,
+			"assignedAt":  a.AssignedAt,
+			"closedAt":    a.ClosedAt,
+			"escalatedAt": a.EscalatedAt,
+		}
+		if assigned, err := store.ParseTime(a.AssignedAt); err == nil && a.Status == store.AssignmentActive {
+			for k, v := range dueInfo(deadline.due(assigned), now) {
+				item[k] = v
+			}
+		}
+		items = append(items, item)
+	}
+	data["assignments"] = items
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// overdueAssignment 超过处理期限的待处理分配
+type overdueAssignment struct {
+	assignment store.ReviewAssignment
+	due        time.Time
+}
+
+// overdueAssignments 返回全部超过处理期限的待处理分配
+func overdueAssignments(ctx context.Context, st *store.Store, now time.Time) ([]overdueAssignment, error) {
+	deadline, err := loadReviewDeadline(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+	active, err := st.Assignments.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overdue := make([]overdueAssignment, 0)
+	for _, a := range active {
+		assigned, err := store.ParseTime(a.AssignedAt)
+		if err != nil {
+			continue
+		}
+		if due := deadline.due(assigned); !due.IsZero() && now.After(due) {
+			overdue = append(overdue, overdueAssignment{assignment: a, due: due})
+		}
+	}
+	return overdue, nil
+}
+
+// OverdueDashboardHandler 按审核人列出超过处理期限的材料，另列出审核中但没有待处理分配且已超期的材料
+func (s *Server) OverdueDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	overdue, err := overdueAssignments(ctx, s.store, now)
+	if err != nil {
+		http.Error(w, "Query overdue error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	statuses := append([]string{string(workflow.Escalated)}, reviewableStatuses...)
+	materials, err := s.store.Materials.List(ctx, store.MaterialFilter{Statuses: statuses})
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byID := make(map[string]store.Material, len(materials))
+	for _, m := range materials {
+		byID[m.ID] = m
+	}
+
+	names := make(map[string]string)
+	if reviewers, err := s.store.Users.ListByRole(ctx, roleReviewer); err == nil {
+		for _, u := range reviewers {
+			names[u.AccountId] = u.Name
+		}
+	}
+
+	// 按审核人分组，保持分配先后顺序
+	order := make([]string, 0)
+	grouped := make(map[string][]map[string]interface{})
+	for _, o := range overdue {
+		a := o.assignment
+		m, ok := byID[a.MaterialId]
+		if !ok {
+			continue
+		}
+		if _, seen := grouped[a.Reviewer]; !seen {
+			order = append(order, a.Reviewer)
+		}
+		item := map[string]interface{}{
+			"assignmentId": a.ID,
+			"materialId":   m.ID,
+			"title":        m.Title,
+			"state":        m.Status,
+			"round":        a.Round,
+			"assignedAt":   a.AssignedAt,
+			"escalatedAt":  a.EscalatedAt,
+		}
+		for k, v := range dueInfo(o.due, now) {
+			item[k] = v
+		}
+		grouped[a.Reviewer] = append(grouped[a.Reviewer], item)
+	}
+	reviewers := make([]map[string]interface{}, 0, len(order))
+	for _, reviewer := range order {
+		reviewers = append(reviewers, map[string]interface{}{
+			"reviewer": reviewer,
+			"name":     names[reviewer],
+			"count":    len(grouped[reviewer]),
+			"overdue":  grouped[reviewer],
+		})
+	}
+
+	// 没有待处理分配的超期材料：无人可分配、等待管理员裁决或已全部表态但尚未形成结论
+	deadline, err := loadReviewDeadline(ctx, s.store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	assigned := make(map[string]bool)
+	if active, err := s.store.Assignments.ListActive(ctx); err == nil {
+		for _, a := range active {
+			assigned[a.MaterialId] = true
+		}
+	}
+	unassigned := make([]map[string]interface{}, 0)
+	for _, m := range materials {
+		if assigned[m.ID] {
+			continue
+		}
+		history, err := s.store.Materials.History(ctx, m.ID)
+		if err != nil {
+			http.Error(w, "Query history error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, entered := timeInState(history, now)
+		due := deadline.due(entered)
+		if entered.IsZero() || due.IsZero() || !now.After(due) {
+			continue
+		}
+		item := map[string]interface{}{
+			"materialId": m.ID,
+			"title":      m.Title,
+			"state":      m.Status,
+			"enteredAt":  store.FormatTime(entered),
+		}
+		for k, v := range dueInfo(due, now) {
+			item[k] = v
+		}
+		unassigned = append(unassigned, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"reviewers":  reviewers,
+			"unassigned": unassigned,
+		},
+	})
+}
+
+// 消息标签，与前端消息列表的 messageType 对应
+const (
+	messagePlain      = -1 // 不显示标签
+	messageInProgress = 2  // 进行中
+	messageDueSoon    = 3  // 即将到期
+)
+
+// notify 发送一条站内消息，发送失败只记录日志，不影响所在的业务操作
+func notify(ctx context.Context, tx *store.Store, msg store.Message) {
+	if err := tx.Messages.Create(ctx, &msg); err != nil {
+		log.Printf("[消息] 通知 %s 失败: %v", msg.AccountId, err)
+	}
+}
+
+// EscalateOverdue 升级超过处理期限的审核分配：提醒原审核人并追加一名审核人，无人可追加时通知全部管理员
+// 每条分配只升级一次，返回本次升级的分配数
+func (s *Server) EscalateOverdue(ctx context.Context, actor string) (int, error) {
+	overdue, err := overdueAssignments(ctx, s.store, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, o := range overdue {
+		a := o.assignment
+		if a.EscalatedAt != "" {
+			continue
+		}
+		err := s.store.InTx(ctx, func(tx *store.Store) error {
+			if err := tx.Assignments.MarkEscalated(ctx, a.ID); errors.Is(err, store.ErrNotFound) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			m, err := tx.Materials.GetForUpdate(ctx, a.MaterialId)
+			if err != nil {
+				return err
+			}
+			escalated++
+
+			dueAt := store.FormatTime(o.due)
+			extra := ""
+			if workflow.State(m.Status).InReview() && m.ReviewRound == a.Round {
+				if extra, err = s.addReviewer(ctx, tx, m, actor); err != nil {
+					return err
+				}
+			}
+			notify(ctx, tx, store.Message{
+				AccountId: a.Reviewer, Type: "todo", Title: "审核任务已超时", SubTitle: m.Title, MessageType: messageDueSoon,
+				Content: fmt.Sprintf("材料《%s》的审核期限为 %s UTC，现已超时，请尽快处理", m.Title, dueAt),
+			})
+			if extra != "" {
+				notify(ctx, tx, store.Message{
+					AccountId: extra, Type: "todo", Title: "新的审核任务", SubTitle: m.Title, MessageType: messageInProgress,
+					Content: fmt.Sprintf("材料《%s》原审核人 %s 超时未处理，已追加你为审核人", m.Title, a.Reviewer),
+				})
+				return nil
+			}
+			admins, err := tx.Users.ListByRole(ctx, roleAdmin)
+			if err != nil {
+				return err
+			}
+			for _, admin := range admins {
+				notify(ctx, tx, store.Message{
+					AccountId: admin.AccountId, Type: "todo", Title: "审核超时需要处理", SubTitle: m.Title, MessageType: messageDueSoon,
+					Content: fmt.Sprintf("材料《%s》的审核人 %s 超时未处理，且没有可追加的审核人，请改派或直接处理", m.Title, a.Reviewer),
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return escalated, fmt.Errorf("升级分配 %d 失败: %w", a.ID, err)
+		}
+	}
+	return escalated, nil
+}
+
+// StartEscalationJob 按配置的间隔定期执行 EscalateOverdue，服务关闭时退出
+func (s *Server) StartEscalationJob() {
+	s.goBackground("sla-escalation", func(ctx context.Context) {
+		ticker := time.NewTicker(s.cfg.Review.EscalationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			n, err := s.EscalateOverdue(ctx, "system")
+			if err != nil {
+				log.Printf("[审核超时] 升级失败: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("[审核超时] 已升级 %d 条超时分配", n)
+			}
+		}
+	})
+}
+
+// RunEscalationHandler 立即执行一次超时升级
+func (s *Server) RunEscalationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := s.EscalateOverdue(r.Context(), principal.AccountId)
+	if err != nil {
+		http.Error(w, "Escalation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{"escalated": n},
+	})
+}
+
+// ListReviewPhasesHandler 返回提交窗口与审核窗口的配置
+func (s *Server) ListReviewPhasesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	phases, err := s.store.ReviewPhases.List(r.Context())
+	if err != nil {
+		http.Error(w, "Query error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": phases,
+	})
+}
+
+// SaveReviewPhaseHandler 修改阶段截止时间与处理时限
+// closesAt 为空表示不设截止时间，不带时区的时间按 UTC 解释
+func (s *Server) SaveReviewPhaseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var req store.ReviewPhase
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Phase != store.PhaseSubmission && req.Phase != store.PhaseReview {
+		http.Error(w, "phase 必须为 submission 或 review", http.StatusBadRequest)
+		return
+	}
+	if req.SLAHours < 0 {
+		http.Error(w, "slaHours 不能为负数", http.StatusBadRequest)
+		return
+	}
+	if req.ClosesAt = strings.TrimSpace(req.ClosesAt); req.ClosesAt != "" {
+		var closes time.Time
+		var err error
+		for _, layout := range phaseTimeLayouts {
+			if closes, err = time.ParseInLocation(layout, req.ClosesAt, time.UTC); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			http.Error(w, "closesAt 格式应为 2006-01-02 15:04:05 或 RFC3339", http.StatusBadRequest)
+			return
+		}
+		req.ClosesAt = store.FormatTime(closes)
+	}
+	req.UpdatedBy = principal.AccountId
+	req.UpdatedAt = ""
+
+	if err := s.store.ReviewPhases.Save(r.Context(), &req); err != nil {
+		http.Error(w, "Save error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": req,
+	})
+}
+
+// RegisterSLARoutes 注册阶段截止时间、审核时限与超时看板路由
+func (s *Server) RegisterSLARoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/review-phase/list", s.ListReviewPhasesHandler)
+	mux.HandleFunc("/api/review-phase/save", s.SaveReviewPhaseHandler)
+	mux.HandleFunc("/api/material/sla", s.MaterialSLAHandler)
+	mux.HandleFunc("/api/sla/overdue", s.OverdueDashboardHandler)
+	mux.HandleFunc("/api/sla/escalate", s.RunEscalationHandler)
+}
@@ -1,8 +1,9 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -13,7 +14,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/store"
 )
 
 // UserSetting 表示用户设置信息
@@ -24,7 +25,7 @@ type UserSetting struct {
 }
 
 // SaveUserInfoHandler 保存用户信息（真实数据逻辑）
-func SaveUserInfoHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) SaveUserInfoHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -46,58 +47,24 @@ func SaveUserInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 创建 users 表（如果不存在）
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT UNIQUE,
-		password TEXT,
-		name TEXT,
-		avatar TEXT,
-		job TEXT,
-		organization TEXT,
-		location TEXT,
-		email TEXT,
-		introduction TEXT,
-		personalWebsite TEXT,
-		jobName TEXT,
-		organizationName TEXT,
-		locationName TEXT,
-		phone TEXT,
-		registrationDate TEXT,
-		accountId TEXT UNIQUE,
-		certification INTEGER,
-		role TEXT,
-		updateTime DATETIME DEFAULT (datetime('now'))
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create users table", http.StatusInternalServerError)
+	// 只允许修改当前登录用户自己的信息
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
+	setting.AccountId = principal.AccountId
 
-	// 根据 accountId 更新用户信息，updateTime 自动由 SQLite datetime('now') 生成
-	// 修正字段映射顺序以匹配 users 表结构
-	// 检查 accountId 是否存在
-	var exists int
-	_ = db.QueryRow(`SELECT COUNT(1) FROM users WHERE accountId = ?`, setting.AccountId).Scan(&exists)
-	if exists == 0 {
-		// 如果不存在则插入新记录
-		_, err = db.Exec(`INSERT INTO users (accountId, name, email, avatar, jobName, locationName, organizationName, introduction, updateTime) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
-			setting.AccountId, setting.Name, setting.Email, setting.Avatar, setting.Nickname, setting.Area, setting.CountryRegion, setting.Profile)
-	} else {
-		// 如果存在则更新记录
-		_, err = db.Exec(`UPDATE users 
-			SET name=?, email=?, avatar=?, jobName=?, locationName=?, organizationName=?, introduction=?, updateTime=datetime('now') 
-			WHERE accountId=?`,
-			setting.Name, setting.Email, setting.Avatar, setting.Nickname, setting.Area, setting.CountryRegion, setting.Profile, setting.AccountId)
-	}
+	// 账号不存在时新建，存在时更新资料；updateTime 由仓储写入
+	err := s.store.Users.SaveSettings(r.Context(), &store.User{
+		AccountId:        setting.AccountId,
+		Name:             setting.Name,
+		Email:            setting.Email,
+		Avatar:           setting.Avatar,
+		JobName:          setting.Nickname,
+		LocationName:     setting.Area,
+		OrganizationName: setting.CountryRegion,
+		Introduction:     setting.Profile,
+	})
 	if err != nil {
 		http.Error(w, "Failed to insert or update record: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -112,8 +79,8 @@ func SaveUserInfoHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // UserAuthHandler 用户认证
-func thirdDealer(username, password string) error {
-	wsURL := "ws://localhost:8081/ws"
+func (s *Server) thirdDealer(username, password string) error {
+	wsURL := s.cfg.Bridge.URL
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if err != nil {
 		return err
@@ -190,45 +157,27 @@ func thirdDealer(username, password string) error {
 				return err
 			}
 
-			db, err := sql.Open("sqlite3", "./user_info.db")
-			if err != nil {
-				return err
-			}
-			defer db.Close()
-
-			_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				username TEXT UNIQUE,
-				password TEXT,
-				name TEXT,
-				avatar TEXT,
-				job TEXT,
-				organization TEXT,
-				location TEXT,
-				email TEXT,
-				introduction TEXT,
-				personalWebsite TEXT,
-				jobName TEXT,
-				organizationName TEXT,
-				locationName TEXT,
-				phone TEXT,
-				registrationDate TEXT,
-				accountId TEXT UNIQUE,
-				certification INTEGER,
-				role TEXT,
-				updateTime DATETIME DEFAULT (datetime('now'))
-			)`)
-			if err != nil {
-				return err
-			}
-
-			_, err = db.Exec(`INSERT OR REPLACE INTO users (
-				username, password, name, avatar, job, organization, location, email, introduction, personalWebsite,
-				jobName, organizationName, locationName, phone, registrationDate, accountId, certification, role, updateTime
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-				username, password, profile.Name, profile.Avatar, profile.Job, profile.Organization, profile.Location, profile.Email,
-				profile.Introduction, profile.PersonalWebsite, profile.JobName, profile.OrganizationName, profile.LocationName,
-				profile.Phone, profile.RegistrationDate, profile.AccountId, profile.Certification, profile.Role, profile.UpdateTime)
+			// 统一身份认证密码只用于本次校验，不落库；已存在的账号只同步资料，保留本地角色
+			err := s.store.Users.UpsertProfile(context.Background(), &store.User{
+				Username:         username,
+				Name:             profile.Name,
+				Avatar:           profile.Avatar,
+				Job:              profile.Job,
+				Organization:     profile.Organization,
+				Location:         profile.Location,
+				Email:            profile.Email,
+				Introduction:     profile.Introduction,
+				PersonalWebsite:  profile.PersonalWebsite,
+				JobName:          profile.JobName,
+				OrganizationName: profile.OrganizationName,
+				LocationName:     profile.LocationName,
+				Phone:            profile.Phone,
+				RegistrationDate: profile.RegistrationDate,
+				AccountId:        profile.AccountId,
+				Certification:    profile.Certification,
+				Role:             profile.Role,
+				UpdateTime:       profile.UpdateTime,
+			})
 			return err
 		} else if prefix == "Error" {
 			return fmt.Errorf("profile error: %s", content)
@@ -236,7 +185,7 @@ func thirdDealer(username, password string) error {
 	}
 }
 
-func UserAuthHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UserAuthHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -251,119 +200,76 @@ func UserAuthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 使用 database/sql 记录登录信息
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+	// 查询本地账号；密码列为空表示统一身份认证账号，不在本地保存密码
+	user, err := s.store.Users.GetByUsername(r.Context(), req.Username)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Failed to query user", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
-
-	// 验证用户是否存在且密码正确并初始化示例数据
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT UNIQUE,
-		password TEXT,
-		name TEXT,
-		avatar TEXT,
-		job TEXT,
-		organization TEXT,
-		location TEXT,
-		email TEXT,
-		introduction TEXT,
-		personalWebsite TEXT,
-		jobName TEXT,
-		organizationName TEXT,
-		locationName TEXT,
-		phone TEXT,
-		registrationDate TEXT,
-		accountId TEXT UNIQUE,
-		certification INTEGER,
-		role TEXT,
-		updateTime DATETIME DEFAULT (datetime('now'))
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create users table", http.StatusInternalServerError)
-		return
-	}
-
-	var accountId string
 
-	// 插入示例数据：admin、reviewer、user，密码为用户名，包含 updateTime
-	db.Exec(`INSERT OR IGNORE INTO users (
-		username, password, name, avatar, job, organization, location, email, introduction, personalWebsite,
-		jobName, organizationName, locationName, phone, registrationDate, accountId, certification, role, updateTime
-	) VALUES 
-		('admin', 'admin', '管理员', 'https://example.com/admin.png', '系统管理员', 'HCIBGA', '上海',
-		'admin@example.com', '系统管理员账户', 'https://hcibga.example.com', '管理员', 'HCIBGA公司', '上海市',
-		'13800138000', '2025-01-01', '1', 1, 'admin', datetime('now')),
-
-		('reviewer', 'reviewer', '审核员', 'https://example.com/reviewer.png', '资料审核员', 'HCIBGA', '北京',
-		'reviewer@example.com', '负责审核材料的账户', 'https://hcibga.example.com', '审核员', 'HCIBGA公司', '北京市',
-		'13900139000', '2025-01-02', '2', 1, 'reviewer', datetime('now')),
-
-		('user', 'user', '普通用户', 'https://example.com/user.png', '工程师', 'HCIBGA', '广州',
-		'user@example.com', '普通用户账户', 'https://hcibga.example.com', '前端工程师', 'HCIBGA公司', '广州市',
-		'13700137000', '2025-01-03', '3', 0, 'user', datetime('now'))`)
-
-	// 插入后再次查询 accountId 和 role
-	var role string
-	err = db.QueryRow(`SELECT accountId, role FROM users WHERE username = ?`, req.Username).Scan(&accountId, &role)
-	if err != nil {
-		if tdErr := thirdDealer(req.Username, req.Password); tdErr != nil {
-			http.Error(w, "thirdDealer failed: "+tdErr.Error(), http.StatusInternalServerError)
+	if user == nil || user.Password == "" {
+		// 交由统一身份认证校验账号密码，成功后同步资料
+		if tdErr := s.thirdDealer(req.Username, req.Password); tdErr != nil {
+			http.Error(w, "thirdDealer failed: "+tdErr.Error(), http.StatusUnauthorized)
 			return
 		}
-		_ = db.QueryRow(`SELECT accountId, role FROM users WHERE username = ?`, req.Username).Scan(&accountId, &role)
-	}
-
-	// 验证密码
-	var dbPassword string
-	if err := db.QueryRow(`SELECT password FROM users WHERE username = ?`, req.Username).Scan(&dbPassword); err != nil {
-		http.Error(w, "Invalid username", http.StatusUnauthorized)
-		return
-	}
-	if dbPassword != req.Password {
-		http.Error(w, "Invalid password", http.StatusUnauthorized)
-		return
-	}
-
-	// 创建表（如果不存在）
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS login_records (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT,
-		ip TEXT,
-		login_time DATETIME,
-		role TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create table", http.StatusInternalServerError)
-		return
+		if user, err = s.store.Users.GetByUsername(r.Context(), req.Username); err != nil {
+			http.Error(w, "Invalid username", http.StatusUnauthorized)
+			return
+		}
+	} else {
+		// 验证密码
+		ok, needsRehash := verifyPassword(user.Password, req.Password)
+		if !ok {
+			http.Error(w, "Invalid password", http.StatusUnauthorized)
+			return
+		}
+		// 旧版明文密码在登录成功后透明升级为哈希
+		if needsRehash {
+			if hash, err := hashPassword(req.Password); err == nil {
+				_ = s.store.Users.SetPassword(r.Context(), user.AccountId, hash, user.MustChangePassword)
+			}
+		}
 	}
+	accountId, role := user.AccountId, user.Role
 
 	ip := r.RemoteAddr
-	_, err = db.Exec(`INSERT INTO login_records (username, ip, login_time, role) VALUES (?, ?, datetime('now'), ?)`, req.Username, ip, role)
-	if err != nil {
+	login := &store.LoginRecord{Username: req.Username, IP: ip, Role: role}
+	if err := s.store.LoginRecords.Create(r.Context(), login); err != nil {
 		http.Error(w, "Failed to insert record", http.StatusInternalServerError)
 		return
 	}
 
 	// 查询刚插入的记录返回
-	row := db.QueryRow(`SELECT id, username, ip, login_time, role FROM login_records ORDER BY id DESC LIMIT 1`)
+	saved, err := s.store.LoginRecords.Get(r.Context(), login.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch record", http.StatusInternalServerError)
+		return
+	}
 	var record struct {
 		ID        string `json:"id"`
 		Username  string `json:"username"`
 		IP        string `json:"ip"`
 		LoginTime string `json:"login_time"`
 		Role      string `json:"role"`
+		Token     string `json:"token"`
+		ExpiresAt string `json:"expiresAt"`
+		// MustChangePassword 为 true 时仅允许调用修改密码接口
+		MustChangePassword bool `json:"mustChangePassword"`
 	}
-	// 将返回的 id 替换为 accountId
-	if err := row.Scan(&record.ID, &record.Username, &record.IP, &record.LoginTime, &record.Role); err != nil {
-		http.Error(w, "Failed to fetch record", http.StatusInternalServerError)
+	// 返回的 id 为 accountId 而非记录编号
+	record.ID = accountId
+	record.Username, record.IP, record.LoginTime, record.Role = saved.Username, saved.IP, saved.LoginTime, saved.Role
+
+	// 签发会话令牌，后续请求通过 Authorization: Bearer <token> 识别身份
+	token, expiresAt, err := s.issueSession(r.Context(), accountId, req.Username, role, ip)
+	if err != nil {
+		http.Error(w, "Failed to issue session: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	record.ID = accountId
+	record.Token = token
+	record.ExpiresAt = expiresAt.Format(time.RFC3339)
+	record.MustChangePassword = user.MustChangePassword
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -374,7 +280,7 @@ func UserAuthHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // UploadUserFileHandler 上传用户文件
-func UploadUserFileHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UploadUserFileHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -409,42 +315,24 @@ func UploadUserFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 将上传记录保存到数据库
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS upload_records (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		filename TEXT,
-		path TEXT,
-		upload_time DATETIME
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create table", http.StatusInternalServerError)
+	upload := &store.UploadRecord{Filename: handler.Filename, Path: savePath}
+	if err := s.store.UploadRecords.Create(r.Context(), upload); err != nil {
+		http.Error(w, "Failed to insert upload record", http.StatusInternalServerError)
 		return
 	}
 
-	_, err = db.Exec(`INSERT INTO upload_records (filename, path, upload_time) VALUES (?, ?, datetime('now'))`, handler.Filename, savePath)
+	// 查询刚插入的记录返回
+	saved, err := s.store.UploadRecords.Get(r.Context(), upload.ID)
 	if err != nil {
-		http.Error(w, "Failed to insert upload record", http.StatusInternalServerError)
+		http.Error(w, "Failed to fetch upload record", http.StatusInternalServerError)
 		return
 	}
-
-	// 查询刚插入的记录返回
-	row := db.QueryRow(`SELECT id, filename, path, upload_time FROM upload_records ORDER BY id DESC LIMIT 1`)
-	var record struct {
-		ID         int    `json:"id"`
+	record := struct {
+		ID         int64  `json:"id"`
 		Filename   string `json:"filename"`
 		Path       string `json:"path"`
 		UploadTime string `json:"upload_time"`
-	}
-	if err := row.Scan(&record.ID, &record.Filename, &record.Path, &record.UploadTime); err != nil {
-		http.Error(w, "Failed to fetch upload record", http.StatusInternalServerError)
-		return
-	}
+	}{saved.ID, saved.Filename, saved.Path, saved.UploadTime}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -455,62 +343,22 @@ func UploadUserFileHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // RegisterUserSettingRoutes 注册用户设置相关路由
-func UserLatestActivityHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UserLatestActivityHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	if req.AccountId == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
-		return
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 创建或确保存在活动表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS user_activities (
-		id TEXT PRIMARY KEY,
-		userId INTEGER,
-		type TEXT,
-		title TEXT,
-		timestamp TEXT,
-		description TEXT,
-		status TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create activities table: "+err.Error(), http.StatusInternalServerError)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	// 初始化示例活动数据（仅用于测试） - 已按要求注释
-	/*
-		 db.Exec(`INSERT OR IGNORE INTO user_activities (
-			id, userId, type, title, timestamp, description, status
-		) VALUES
-		('act_001', ?, 'material', '提交了材料《项目计划书》', '2025-11-14T10:00:00Z', '提交了项目计划书用于评审', 'completed'),
-		('act_002', ?, 'review', '审核了材料《设计文档》', '2025-11-13T15:30:00Z', '设计文档审核通过', 'approved')`, req.AccountId, req.AccountId)
-	*/
-
-	rows, err := db.Query(`SELECT id, type, title, timestamp, description, status 
-		FROM user_activities WHERE userId = ? ORDER BY timestamp DESC`, req.AccountId)
+	rows, err := s.store.Profiles.Activities(r.Context(), principal.AccountId)
 	if err != nil {
 		http.Error(w, "Query failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
 	type Activity struct {
 		ID          string `json:"id"`
@@ -521,19 +369,9 @@ func UserLatestActivityHandler(w http.ResponseWriter, r *http.Request) {
 		Status      string `json:"status"`
 	}
 
-	activities := make([]Activity, 0)
-	for rows.Next() {
-		var a Activity
-		if err := rows.Scan(&a.ID, &a.Type, &a.Title, &a.Timestamp, &a.Description, &a.Status); err != nil {
-			http.Error(w, "Row scan failed: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		activities = append(activities, a)
-	}
-
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Rows iteration error: "+err.Error(), http.StatusInternalServerError)
-		return
+	activities := make([]Activity, 0, len(rows))
+	for _, a := range rows {
+		activities = append(activities, Activity{a.ID, a.Type, a.Title, a.Timestamp, a.Description, a.Status})
 	}
 
 	resp := map[string]interface{}{
@@ -554,110 +392,39 @@ type MyProjectRecord struct {
 	Contributors []UserSetting `json:"contributors"`
 }
 
-func MyProjectListHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MyProjectListHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	if req.AccountId == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
-		return
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 创建或确保存在项目表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS projects (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		description TEXT,
-		peopleNumber INTEGER,
-		ownerAccountId TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create projects table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// 创建或确保存在贡献者表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS project_contributors (
-		projectId INTEGER,
-		name TEXT,
-		email TEXT,
-		avatar TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create contributors table: "+err.Error(), http.StatusInternalServerError)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	// 在查询前先写入一些示例数据 - 已按要求注释
-	/*
-		db.Exec(`INSERT OR REPLACE INTO projects (id, name, description, peopleNumber, ownerAccountId) VALUES
-			(1, '项目A', '这是项目A的描述', 5, ?),
-			(2, '项目B', '这是项目B的描述', 3, ?)`, req.AccountId, req.AccountId)
-	*/
-
-	/*
-		db.Exec(`INSERT OR REPLACE INTO project_contributors (projectId, name, email, avatar) VALUES
-			(1, '张三', 'zhangsan@example.com', 'https://example.com/avatar1.png'),
-			(1, '李四', 'lisi@example.com', 'https://example.com/avatar2.png')`)
-	*/
-
-	// 查询项目
-	rows, err := db.Query(`SELECT id, name, description, peopleNumber FROM projects WHERE ownerAccountId = ?`, req.AccountId)
+	// 查询项目及其贡献者
+	rows, err := s.store.Profiles.Projects(r.Context(), principal.AccountId)
 	if err != nil {
 		http.Error(w, "Query projects failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	projects := make([]MyProjectRecord, 0)
-	for rows.Next() {
-		var p MyProjectRecord
-		p.Contributors = make([]UserSetting, 0)
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.PeopleNumber); err != nil {
-			http.Error(w, "Row scan failed: "+err.Error(), http.StatusInternalServerError)
-			return
+	projects := make([]MyProjectRecord, 0, len(rows))
+	for _, row := range rows {
+		p := MyProjectRecord{
+			ID:           int(row.ID),
+			Name:         row.Name,
+			Description:  row.Description,
+			PeopleNumber: row.PeopleNumber,
+			Contributors: make([]UserSetting, 0, len(row.Contributors)),
 		}
-
-		// 查询贡献者
-		cRows, err := db.Query(`SELECT name, email, avatar FROM project_contributors WHERE projectId = ?`, p.ID)
-		if err != nil {
-			http.Error(w, "Query contributors failed: "+err.Error(), http.StatusInternalServerError)
-			return
+		for _, c := range row.Contributors {
+			p.Contributors = append(p.Contributors, UserSetting{Name: c.Name, Email: c.Email, Avatar: c.Avatar})
 		}
-		for cRows.Next() {
-			var c UserSetting
-			if err := cRows.Scan(&c.Name, &c.Email, &c.Avatar); err != nil {
-				http.Error(w, "Contributor scan failed: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-			p.Contributors = append(p.Contributors, c)
-		}
-		cRows.Close()
-
 		projects = append(projects, p)
 	}
 
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Rows iteration error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	resp := map[string]interface{}{
 		"code":    0,
 		"message": "success",
@@ -675,72 +442,27 @@ type MyTeamRecord struct {
 	PeopleNumber int    `json:"peopleNumber"`
 }
 
-func MyTeamListHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) MyTeamListHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	if req.AccountId == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
-		return
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 创建或确保存在团队表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS teams (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		avatar TEXT,
-		name TEXT,
-		peopleNumber INTEGER,
-		ownerAccountId TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create teams table: "+err.Error(), http.StatusInternalServerError)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	// 在查询前先写入一些示例数据 - 已按要求注释
-	/*
-		db.Exec(`INSERT OR REPLACE INTO teams (id, avatar, name, peopleNumber, ownerAccountId) VALUES
-			(101, 'https://example.com/team1.png', '研发一组', 8, ?),
-			(102, 'https://example.com/team2.png', '设计团队', 5, ?)`, req.AccountId, req.AccountId)
-	*/
-
 	// 查询团队
-	rows, err := db.Query(`SELECT id, avatar, name, peopleNumber FROM teams WHERE ownerAccountId = ?`, req.AccountId)
+	rows, err := s.store.Profiles.Teams(r.Context(), principal.AccountId)
 	if err != nil {
 		http.Error(w, "Query teams failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	teams := make([]MyTeamRecord, 0)
-	for rows.Next() {
-		var t MyTeamRecord
-		if err := rows.Scan(&t.ID, &t.Avatar, &t.Name, &t.PeopleNumber); err != nil {
-			http.Error(w, "Row scan failed: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		teams = append(teams, t)
-	}
-
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Rows iteration error: "+err.Error(), http.StatusInternalServerError)
-		return
+	teams := make([]MyTeamRecord, 0, len(rows))
+	for _, t := range rows {
+		teams = append(teams, MyTeamRecord{ID: int(t.ID), Avatar: t.Avatar, Name: t.Name, PeopleNumber: t.PeopleNumber})
 	}
 
 	resp := map[string]interface{}{
@@ -775,102 +497,34 @@ type UnitCertification struct {
 	Record         []CertificationRecord        `json:"record"`
 }
 
-func UserCertificationHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UserCertificationHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		AccountId string `json:"accountId"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	if req.AccountId == "" {
-		http.Error(w, "accountId is required", http.StatusBadRequest)
-		return
-	}
-
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		http.Error(w, "Database connection failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	// 创建或确保存在企业认证表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS enterprise_certification (
-		accountId TEXT PRIMARY KEY,
-		accountType INTEGER,
-		status INTEGER,
-		time TEXT,
-		legalPerson TEXT,
-		certificateType TEXT,
-		authenticationNumber TEXT,
-		enterpriseName TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create enterprise_certification table: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// 创建或确保存在认证记录表
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS certification_records (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		accountId TEXT,
-		certificationType INTEGER,
-		certificationContent TEXT,
-		status INTEGER,
-		time TEXT
-	)`)
-	if err != nil {
-		http.Error(w, "Failed to create certification_records table: "+err.Error(), http.StatusInternalServerError)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	// 插入示例数据 - 已按要求注释
-	/*
-		db.Exec(`INSERT OR REPLACE INTO enterprise_certification (accountId, accountType, status, time, legalPerson, certificateType, authenticationNumber, enterpriseName) VALUES
-			(?, 1, 2, '2025-11-14 10:00:00', '王五', '营业执照', '91320100MA1X0XXXX', '示例科技有限公司')`, req.AccountId)
-	*/
-
-	/*
-		db.Exec(`INSERT OR REPLACE INTO certification_records (accountId, certificationType, certificationContent, status, time) VALUES
-			(?, 1, '企业营业执照', 2, '2025-11-14 10:00:00'),
-			(?, 2, '税务登记证', 1, '2025-11-10 09:00:00')`, req.AccountId, req.AccountId)
-	*/
-
 	// 查询企业认证信息
-	var enterpriseInfo EnterpriseCertificationModel
-	row := db.QueryRow(`SELECT accountType, status, time, legalPerson, certificateType, authenticationNumber, enterpriseName FROM enterprise_certification WHERE accountId = ?`, req.AccountId)
-	if err := row.Scan(&enterpriseInfo.AccountType, &enterpriseInfo.Status, &enterpriseInfo.Time, &enterpriseInfo.LegalPerson, &enterpriseInfo.CertificateType, &enterpriseInfo.AuthenticationNumber, &enterpriseInfo.EnterpriseName); err != nil {
+	e, err := s.store.Profiles.Enterprise(r.Context(), principal.AccountId)
+	if err != nil {
 		http.Error(w, "Failed to fetch enterprise certification: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	enterpriseInfo := EnterpriseCertificationModel(*e)
 
 	// 查询认证记录
-	rows, err := db.Query(`SELECT certificationType, certificationContent, status, time FROM certification_records WHERE accountId = ?`, req.AccountId)
+	rows, err := s.store.Profiles.CertificationRecords(r.Context(), principal.AccountId)
 	if err != nil {
 		http.Error(w, "Failed to fetch certification records: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	records := make([]CertificationRecord, 0)
-	for rows.Next() {
-		var rec CertificationRecord
-		if err := rows.Scan(&rec.CertificationType, &rec.CertificationContent, &rec.Status, &rec.Time); err != nil {
-			http.Error(w, "Record scan failed: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		records = append(records, rec)
-	}
-
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Rows iteration error: "+err.Error(), http.StatusInternalServerError)
-		return
+	records := make([]CertificationRecord, 0, len(rows))
+	for _, rec := range rows {
+		records = append(records, CertificationRecord(rec))
 	}
 
 	resp := map[string]interface{}{
@@ -920,13 +574,13 @@ func randomString(length int) string {
 	return string(result)
 }
 
-func QRCodeHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) QRCodeHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	wsURL := "ws://localhost:8081/ws"
+	wsURL := s.cfg.Bridge.URL
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if err != nil {
 		http.Error(w, "Failed to connect websocket: "+err.Error(), http.StatusInternalServerError)
@@ -954,8 +608,11 @@ func QRCodeHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	go func() {
+	// 扫码结果监听可中断：关闭服务时断开桥接连接，二维码视为过期
+	s.goBackground("qr-watch "+qrId, func(ctx context.Context) {
 		defer conn.Close()
+		stopWatch := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stopWatch()
 		data := &qrData{Status: "pending"}
 		setQRData(qrId, data)
 
@@ -975,7 +632,7 @@ func QRCodeHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-	}()
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -988,7 +645,7 @@ func QRCodeHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func QRStatusHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) QRStatusHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -1019,7 +676,7 @@ func QRStatusHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -1048,7 +705,7 @@ func QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wsURL := "ws://localhost:8081/ws"
+	wsURL := s.cfg.Bridge.URL
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if err != nil {
 		http.Error(w, "Failed to connect websocket: "+err.Error(), http.StatusInternalServerError)
@@ -1100,54 +757,40 @@ func QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			db, err := sql.Open("sqlite3", "./user_info.db")
+			// 扫码登录账号不设置本地密码；已有账号只同步资料，保留本地角色
+			err = s.store.Users.UpsertProfile(r.Context(), &store.User{
+				Username:         profile.Username,
+				Name:             profile.Name,
+				Avatar:           profile.Avatar,
+				Job:              profile.Job,
+				Organization:     profile.Organization,
+				Location:         profile.Location,
+				Email:            profile.Email,
+				Introduction:     profile.Introduction,
+				PersonalWebsite:  profile.PersonalWebsite,
+				JobName:          profile.JobName,
+				OrganizationName: profile.OrganizationName,
+				LocationName:     profile.LocationName,
+				Phone:            profile.Phone,
+				RegistrationDate: profile.RegistrationDate,
+				AccountId:        profile.AccountId,
+				Certification:    profile.Certification,
+				Role:             profile.Role,
+				UpdateTime:       profile.UpdateTime,
+			})
 			if err != nil {
-				http.Error(w, "Failed to open database: "+err.Error(), http.StatusInternalServerError)
+				http.Error(w, "Failed to save profile: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
-			defer db.Close()
-
-			_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				username TEXT UNIQUE,
-				password TEXT,
-				name TEXT,
-				avatar TEXT,
-				job TEXT,
-				organization TEXT,
-				location TEXT,
-				email TEXT,
-				introduction TEXT,
-				personalWebsite TEXT,
-				jobName TEXT,
-				organizationName TEXT,
-				locationName TEXT,
-				phone TEXT,
-				registrationDate TEXT,
-				accountId TEXT UNIQUE,
-				certification INTEGER,
-				role TEXT,
-				updateTime DATETIME DEFAULT (datetime('now'))
-			)`)
+			user, err := s.store.Users.GetByUsername(r.Context(), profile.Username)
 			if err != nil {
-				http.Error(w, "Failed to create table: "+err.Error(), http.StatusInternalServerError)
+				http.Error(w, "Failed to query user: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
 
-			var existingId int
-			err = db.QueryRow(`SELECT id FROM users WHERE username = ?`, profile.Username).Scan(&existingId)
-			if err != nil && err != sql.ErrNoRows {
-				_, err = db.Exec(`INSERT OR REPLACE INTO users (
-				username, password, name, avatar, job, organization, location, email, introduction, personalWebsite,
-				jobName, organizationName, locationName, phone, registrationDate, accountId, certification, role, updateTime
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-					profile.Username, randomString(16), profile.Name, profile.Avatar, profile.Job, profile.Organization, profile.Location, profile.Email,
-					profile.Introduction, profile.PersonalWebsite, profile.JobName, profile.OrganizationName, profile.LocationName,
-					profile.Phone, profile.RegistrationDate, profile.AccountId, profile.Certification, profile.Role, profile.UpdateTime)
-				if err != nil {
-					http.Error(w, "Failed to insert profile: "+err.Error(), http.StatusInternalServerError)
-					return
-				}
+			token, expiresAt, err := s.issueSession(r.Context(), user.AccountId, user.Username, user.Role, r.RemoteAddr)
+			if err != nil {
+				http.Error(w, "Failed to issue session: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
 
@@ -1156,8 +799,10 @@ func QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
 				"code": 0,
 				"msg":  "QR auth success",
 				"data": map[string]string{
-					"token": profile.AccountId,
-					"role":  profile.Role,
+					"token":     token,
+					"expiresAt": expiresAt.Format(time.RFC3339),
+					"accountId": user.AccountId,
+					"role":      user.Role,
 				},
 			})
 			return
@@ -1168,15 +813,17 @@ func QRAuthResultHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func RegisterUserSettingRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/user/save-info", SaveUserInfoHandler)
-	mux.HandleFunc("/api/user/auth", UserAuthHandler)
-	mux.HandleFunc("/api/user/upload", UploadUserFileHandler)
-	mux.HandleFunc("/api/user/latest-activity", UserLatestActivityHandler)
-	mux.HandleFunc("/api/user/my-project/list", MyProjectListHandler)
-	mux.HandleFunc("/api/user/my-team/list", MyTeamListHandler)
-	mux.HandleFunc("/api/user/certification", UserCertificationHandler)
-	mux.HandleFunc("/api/user/qr-code", QRCodeHandler)
-	mux.HandleFunc("/api/user/qr-status", QRStatusHandler)
-	mux.HandleFunc("/api/user/qr-auth-result", QRAuthResultHandler)
+func (s *Server) RegisterUserSettingRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/user/save-info", s.SaveUserInfoHandler)
+	mux.HandleFunc("/api/user/auth", s.UserAuthHandler)
+	mux.HandleFunc("/api/user/password", s.ChangePasswordHandler)
+	mux.HandleFunc("/api/user/admin/create", s.CreateAccountHandler)
+	mux.HandleFunc("/api/user/upload", s.UploadUserFileHandler)
+	mux.HandleFunc("/api/user/latest-activity", s.UserLatestActivityHandler)
+	mux.HandleFunc("/api/user/my-project/list", s.MyProjectListHandler)
+	mux.HandleFunc("/api/user/my-team/list", s.MyTeamListHandler)
+	mux.HandleFunc("/api/user/certification", s.UserCertificationHandler)
+	mux.HandleFunc("/api/user/qr-code", s.QRCodeHandler)
+	mux.HandleFunc("/api/user/qr-status", s.QRStatusHandler)
+	mux.HandleFunc("/api/user/qr-auth-result", s.QRAuthResultHandler)
 }
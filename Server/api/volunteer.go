@@ -1,7 +1,7 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,17 +9,14 @@ import (
 	"strings"
 
 	"github.com/gorilla/websocket"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/vintcessun/HCIBGA/Server/store"
 )
 
-const volunteerWSURL = "ws://localhost:8081/ws"
-
 // VolunteerHoursRequest 请求体
-// username/password 来源于前端表单，accountId 由当前登录用户 token 附带
+// username/password 来源于前端表单，当前用户由会话令牌确定
 type VolunteerHoursRequest struct {
-	Username  string `json:"username"`
-	Password  string `json:"password"`
-	AccountID string `json:"accountId"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 // VolunteerHoursResponse 响应体（透传志愿汇字段）
@@ -30,7 +27,7 @@ type VolunteerHoursResponse struct {
 }
 
 // VolunteerCreditHandler 处理 /api/volunteer/credit
-func VolunteerCreditHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) VolunteerCreditHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
@@ -42,21 +39,25 @@ func VolunteerCreditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	req.Username = strings.TrimSpace(req.Username)
 	req.Password = strings.TrimSpace(req.Password)
-	req.AccountID = strings.TrimSpace(req.AccountID)
-	if req.Username == "" || req.Password == "" || req.AccountID == "" {
-		http.Error(w, "用户名、密码和 accountId 均不能为空", http.StatusBadRequest)
+	if req.Username == "" || req.Password == "" {
+		http.Error(w, "用户名和密码均不能为空", http.StatusBadRequest)
 		return
 	}
 
-	displayName, err := getDisplayNameByAccount(req.AccountID)
+	displayName, err := s.getDisplayNameByAccount(principal.AccountId)
 	if err != nil {
 		http.Error(w, "获取用户姓名失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	hours, err := fetchVolunteerHours(req.Username, req.Password, displayName)
+	hours, err := s.fetchVolunteerHours(req.Username, req.Password, displayName)
 	if err != nil {
 		http.Error(w, "查询志愿时数失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -71,16 +72,16 @@ func VolunteerCreditHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func fetchVolunteerHours(username, password, displayName string) (*VolunteerHoursResponse, error) {
-	token, err := loginVolunteer(username, password)
+func (s *Server) fetchVolunteerHours(username, password, displayName string) (*VolunteerHoursResponse, error) {
+	token, err := s.loginVolunteer(username, password)
 	if err != nil {
 		return nil, err
 	}
-	return queryVolunteerHours(token, displayName)
+	return s.queryVolunteerHours(token, displayName)
 }
 
-func loginVolunteer(username, password string) (string, error) {
-	conn, _, err := websocket.DefaultDialer.Dial(volunteerWSURL, nil)
+func (s *Server) loginVolunteer(username, password string) (string, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(s.cfg.Bridge.URL, nil)
 	if err != nil {
 		return "", err
 	}
@@ -106,8 +107,8 @@ func loginVolunteer(username, password string) (string, error) {
 	}
 }
 
-func queryVolunteerHours(token, displayName string) (*VolunteerHoursResponse, error) {
-	conn, _, err := websocket.DefaultDialer.Dial(volunteerWSURL, nil)
+func (s *Server) queryVolunteerHours(token, displayName string) (*VolunteerHoursResponse, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(s.cfg.Bridge.URL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -148,30 +149,23 @@ func splitVolunteerMessage(message string) (string, string) {
 	return prefix, content
 }
 
-func getDisplayNameByAccount(accountID string) (string, error) {
-	db, err := sql.Open("sqlite3", "./user_info.db")
-	if err != nil {
-		return "", fmt.Errorf("数据库连接失败: %w", err)
+func (s *Server) getDisplayNameByAccount(accountID string) (string, error) {
+	u, err := s.store.Users.GetByAccountId(context.Background(), accountID)
+	if errors.Is(err, store.ErrNotFound) {
+		return "", fmt.Errorf("未找到 accountId=%s 对应的用户", accountID)
 	}
-	defer db.Close()
-
-	var name string
-	err = db.QueryRow(`SELECT name FROM users WHERE accountId = ?`, accountID).Scan(&name)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("未找到 accountId=%s 对应的用户", accountID)
-		}
 		return "", fmt.Errorf("查询用户姓名失败: %w", err)
 	}
 
-	if strings.TrimSpace(name) == "" {
+	if strings.TrimSpace(u.Name) == "" {
 		return "", fmt.Errorf("当前账号未设置姓名，请先完善用户信息")
 	}
 
-	return strings.TrimSpace(name), nil
+	return strings.TrimSpace(u.Name), nil
 }
 
 // RegisterVolunteerRoutes 注册志愿接口
-func RegisterVolunteerRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/volunteer/credit", VolunteerCreditHandler)
+func (s *Server) RegisterVolunteerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/volunteer/credit", s.VolunteerCreditHandler)
 }
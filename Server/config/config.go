@@ -0,0 +1,380 @@
+// Package config 定义服务端的类型化配置，从 YAML 文件加载并允许环境变量覆盖
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath 未通过 -config 指定时尝试加载的配置文件，文件不存在时使用默认值
+const DefaultPath = "./config.yaml"
+
+// Config 服务端配置
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Bridge   BridgeConfig   `yaml:"bridge"`
+	Upload   UploadConfig   `yaml:"upload"`
+	LLM      LLMConfig      `yaml:"llm"`
+	Score    ScoreConfig    `yaml:"score"`
+	Review   ReviewConfig   `yaml:"review"`
+	Import   ImportConfig   `yaml:"import"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	// Listen 监听地址，如 127.0.0.1:8000
+	Listen string `yaml:"listen"`
+	// ReadTimeout 读取完整请求（含上传文件）的超时
+	ReadTimeout time.Duration `yaml:"readTimeout"`
+	// WriteTimeout 写出响应的超时，需覆盖同步调用大模型的耗时；导出在写出每名学生的内容前顺延该时长
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// IdleTimeout keep-alive 空闲连接超时
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout 收到退出信号后等待请求与后台任务结束的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// SessionKeyFile 会话签名密钥文件，不存在时首次启动自动生成；更换后已签发的会话全部失效
+	SessionKeyFile string `yaml:"sessionKeyFile"`
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	// Driver 数据库驱动：sqlite3 或 postgres
+	Driver string `yaml:"driver"`
+	// DSN SQLite 为文件路径，PostgreSQL 为 postgres:// 连接串
+	DSN string `yaml:"dsn"`
+}
+
+// BridgeConfig 统一身份认证 / 志愿平台桥接服务配置
+type BridgeConfig struct {
+	// URL 桥接服务的 WebSocket 地址
+	URL string `yaml:"url"`
+}
+
+// UploadConfig 上传文件配置
+type UploadConfig struct {
+	// Dir 上传文件保存目录
+	Dir string `yaml:"dir"`
+	// TrashRetention 删除的材料在回收站中保留的时长，期间可以恢复
+	TrashRetention time.Duration `yaml:"trashRetention"`
+	// PurgeInterval 永久删除过期材料及其不再引用的文件的间隔
+	PurgeInterval time.Duration `yaml:"purgeInterval"`
+}
+
+// LLMConfig 大模型接口配置
+type LLMConfig struct {
+	// BaseURL 模型接口地址，为空时读取 BaseURLFile
+	BaseURL string `yaml:"baseUrl"`
+	// BaseURLFile 兼容旧部署的 ./secret/BASE_URL 文件
+	BaseURLFile string `yaml:"baseUrlFile"`
+	// APIKey 模型接口密钥，为空时读取 APIKeyFile
+	APIKey string `yaml:"apiKey"`
+	// APIKeyFile 兼容旧部署的 ./secret/API_KEY 文件
+	APIKeyFile string `yaml:"apiKeyFile"`
+	// Model 模型名称
+	Model string `yaml:"model"`
+	// PromptDir 提示词与保研条例所在目录
+	PromptDir string `yaml:"promptDir"`
+}
+
+// ScoreConfig 加分上限配置
+type ScoreConfig struct {
+	// AcademicCap 学术专长加分上限
+	AcademicCap float64 `yaml:"academicCap"`
+	// ComprehensiveCap 综合素质加分上限
+	ComprehensiveCap float64 `yaml:"comprehensiveCap"`
+}
+
+// ReviewConfig 审核任务分配配置
+type ReviewConfig struct {
+	// ReviewersPerMaterial 每份材料每轮分配的审核人数，审核规则要求更多票数时以规则为准
+	ReviewersPerMaterial int `yaml:"reviewersPerMaterial"`
+	// AssignmentStrategy 分配策略：round_robin 轮询，least_loaded 最少负载优先
+	AssignmentStrategy string `yaml:"assignmentStrategy"`
+	// EscalationInterval 检查超时审核分配并升级的间隔
+	EscalationInterval time.Duration `yaml:"escalationInterval"`
+}
+
+// ImportConfig 学生名单导入的校验配置
+type ImportConfig struct {
+	// StudentIdPattern 学号需匹配的正则表达式
+	StudentIdPattern string `yaml:"studentIdPattern"`
+	// Majors 可导入的专业，为空时不校验专业
+	Majors []string `yaml:"majors"`
+}
+
+// Default 返回与旧版硬编码值一致的默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Listen:          "127.0.0.1:8000",
+			ReadTimeout:     60 * time.Second,
+			WriteTimeout:    180 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			SessionKeyFile:  "./secret/SESSION_KEY",
+		},
+		Database: DatabaseConfig{Driver: "sqlite3", DSN: "./hcibga.db"},
+		Bridge:   BridgeConfig{URL: "ws://localhost:8081/ws"},
+		Upload:   UploadConfig{Dir: "./upload", TrashRetention: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		LLM: LLMConfig{
+			BaseURLFile: "./secret/BASE_URL",
+			APIKeyFile:  "./secret/API_KEY",
+			Model:       "gemini-2.5-flash",
+			PromptDir:   "./docs",
+		},
+		Score:  ScoreConfig{AcademicCap: 15, ComprehensiveCap: 5},
+		Review: ReviewConfig{ReviewersPerMaterial: 3, AssignmentStrategy: "least_loaded", EscalationInterval: 15 * time.Minute},
+		Import: ImportConfig{StudentIdPattern: `^[0-9]{4,20}$`},
+	}
+}
+
+// Load 依次应用默认值、配置文件和环境变量，并校验结果
+// path 为空时尝试 DefaultPath，该文件不存在不视为错误；显式指定的文件必须存在
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envStrings 字符串类环境变量与配置项的对应关系
+func (c *Config) envStrings() map[string]*string {
+	return map[string]*string{
+		"HCIBGA_LISTEN":                    &c.Server.Listen,
+		"HCIBGA_SESSION_KEY_FILE":          &c.Server.SessionKeyFile,
+		"HCIBGA_DB_DRIVER":                 &c.Database.Driver,
+		"HCIBGA_DB_DSN":                    &c.Database.DSN,
+		"HCIBGA_BRIDGE_URL":                &c.Bridge.URL,
+		"HCIBGA_UPLOAD_DIR":                &c.Upload.Dir,
+		"HCIBGA_LLM_BASE_URL":              &c.LLM.BaseURL,
+		"HCIBGA_LLM_API_KEY":               &c.LLM.APIKey,
+		"HCIBGA_LLM_MODEL":                 &c.LLM.Model,
+		"HCIBGA_LLM_PROMPT_DIR":            &c.LLM.PromptDir,
+		"HCIBGA_REVIEW_STRATEGY":           &c.Review.AssignmentStrategy,
+		"HCIBGA_IMPORT_STUDENT_ID_PATTERN": &c.Import.StudentIdPattern,
+	}
+}
+
+// envFloats 数值类环境变量与配置项的对应关系
+func (c *Config) envFloats() map[string]*float64 {
+	return map[string]*float64{
+		"HCIBGA_SCORE_ACADEMIC_CAP":      &c.Score.AcademicCap,
+		"HCIBGA_SCORE_COMPREHENSIVE_CAP": &c.Score.ComprehensiveCap,
+	}
+}
+
+// envInts 整数类环境变量与配置项的对应关系
+func (c *Config) envInts() map[string]*int {
+	return map[string]*int{
+		"HCIBGA_REVIEWERS_PER_MATERIAL": &c.Review.ReviewersPerMaterial,
+	}
+}
+
+// envDurations 时长类环境变量与配置项的对应关系，格式如 30s、2m
+func (c *Config) envDurations() map[string]*time.Duration {
+	return map[string]*time.Duration{
+		"HCIBGA_READ_TIMEOUT":        &c.Server.ReadTimeout,
+		"HCIBGA_WRITE_TIMEOUT":       &c.Server.WriteTimeout,
+		"HCIBGA_IDLE_TIMEOUT":        &c.Server.IdleTimeout,
+		"HCIBGA_SHUTDOWN_TIMEOUT":    &c.Server.ShutdownTimeout,
+		"HCIBGA_ESCALATION_INTERVAL": &c.Review.EscalationInterval,
+		"HCIBGA_TRASH_RETENTION":     &c.Upload.TrashRetention,
+		"HCIBGA_PURGE_INTERVAL":      &c.Upload.PurgeInterval,
+	}
+}
+
+// applyEnv 使用 HCIBGA_ 前缀的环境变量覆盖配置文件中的值
+func (c *Config) applyEnv() error {
+	for name, dst := range c.envStrings() {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = strings.TrimSpace(v)
+		}
+	}
+	for name, dst := range c.envFloats() {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 不是合法数字: %q", name, v)
+		}
+		*dst = f
+	}
+	for name, dst := range c.envInts() {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 不是合法整数: %q", name, v)
+		}
+		*dst = n
+	}
+	for name, dst := range c.envDurations() {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("环境变量 %s 不是合法时长: %q", name, v)
+		}
+		*dst = d
+	}
+	return nil
+}
+
+// resolveSecrets 未直接配置模型地址或密钥时，从旧版 secret 文件读取
+func (c *Config) resolveSecrets() error {
+	var err error
+	if c.LLM.BaseURL == "" && c.LLM.BaseURLFile != "" {
+		if c.LLM.BaseURL, err = readSecret(c.LLM.BaseURLFile); err != nil {
+			return err
+		}
+	}
+	if c.LLM.APIKey == "" && c.LLM.APIKeyFile != "" {
+		if c.LLM.APIKey, err = readSecret(c.LLM.APIKeyFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取密钥文件 %s 失败: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Validate 检查配置是否完整可用，一次性返回全部问题
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		add("server.listen 无效 %q: %v", c.Server.Listen, err)
+	}
+	for _, t := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"review.escalationInterval", c.Review.EscalationInterval},
+		{"upload.trashRetention", c.Upload.TrashRetention},
+		{"upload.purgeInterval", c.Upload.PurgeInterval},
+	} {
+		if t.d <= 0 {
+			add("%s 必须大于 0，当前为 %s", t.name, t.d)
+		}
+	}
+
+	if c.Server.SessionKeyFile == "" {
+		add("server.sessionKeyFile 不能为空")
+	} else if info, err := os.Stat(c.Server.SessionKeyFile); err == nil && info.IsDir() {
+		add("server.sessionKeyFile 是目录: %q", c.Server.SessionKeyFile)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		add("server.sessionKeyFile 无法访问 %q: %v", c.Server.SessionKeyFile, err)
+	}
+
+	switch c.Database.Driver {
+	case "sqlite3", "postgres":
+	default:
+		add("database.driver 仅支持 sqlite3 或 postgres，当前为 %q", c.Database.Driver)
+	}
+	if c.Database.DSN == "" {
+		add("database.dsn 不能为空")
+	}
+
+	if u, err := url.Parse(c.Bridge.URL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		add("bridge.url 必须是 ws:// 或 wss:// 地址，当前为 %q", c.Bridge.URL)
+	}
+
+	if c.Upload.Dir == "" {
+		add("upload.dir 不能为空")
+	}
+
+	if c.LLM.BaseURL == "" {
+		add("llm.baseUrl 未配置（可设置 HCIBGA_LLM_BASE_URL 或提供 %s）", c.LLM.BaseURLFile)
+	} else if u, err := url.Parse(c.LLM.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		add("llm.baseUrl 不是合法地址: %q", c.LLM.BaseURL)
+	}
+	if c.LLM.APIKey == "" {
+		add("llm.apiKey 未配置（可设置 HCIBGA_LLM_API_KEY 或提供 %s）", c.LLM.APIKeyFile)
+	}
+	if c.LLM.Model == "" {
+		add("llm.model 不能为空")
+	}
+	if info, err := os.Stat(c.LLM.PromptDir); err != nil || !info.IsDir() {
+		add("llm.promptDir 目录不存在: %q", c.LLM.PromptDir)
+	}
+
+	if c.Score.AcademicCap < 0 {
+		add("score.academicCap 不能为负数")
+	}
+	if c.Score.ComprehensiveCap < 0 {
+		add("score.comprehensiveCap 不能为负数")
+	}
+
+	if c.Review.ReviewersPerMaterial < 1 {
+		add("review.reviewersPerMaterial 至少为 1，当前为 %d", c.Review.ReviewersPerMaterial)
+	}
+	switch c.Review.AssignmentStrategy {
+	case "round_robin", "least_loaded":
+	default:
+		add("review.assignmentStrategy 仅支持 round_robin 或 least_loaded，当前为 %q", c.Review.AssignmentStrategy)
+	}
+
+	if _, err := regexp.Compile(c.Import.StudentIdPattern); err != nil {
+		add("import.studentIdPattern 不是合法的正则表达式 %q: %v", c.Import.StudentIdPattern, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -1,14 +1,83 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/vintcessun/HCIBGA/Server/api"
+	"github.com/vintcessun/HCIBGA/Server/config"
+	"github.com/vintcessun/HCIBGA/Server/store"
 )
 
 func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认尝试 "+config.DefaultPath)
+	dbDriver := flag.String("db-driver", "", "覆盖配置中的数据库驱动：sqlite3 或 postgres")
+	dbPath := flag.String("db", "", "覆盖配置中的数据库连接串：SQLite 为文件路径，PostgreSQL 为 postgres:// DSN")
+	mergeLegacy := flag.Bool("merge-legacy", false, "将旧版 user_info.db / hci.db / app.db / students.db 合并到统一数据库后退出")
+	flag.Parse()
+
+	// 加载配置：默认值 < 配置文件 < 环境变量 < 命令行参数
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dbDriver != "" {
+		cfg.Database.Driver = *dbDriver
+	}
+	if *dbPath != "" {
+		cfg.Database.DSN = *dbPath
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	// 打开统一数据库并执行未应用的迁移
+	st, err := store.Open(store.Dialect(cfg.Database.Driver), cfg.Database.DSN)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer st.Close()
+
+	if *mergeLegacy {
+		counts, err := st.MergeLegacy(store.LegacyDatabases)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for table, n := range counts {
+			log.Printf("[合并] %s: %d 行", table, n)
+		}
+		return
+	}
+
+	srv, err := api.NewServer(st, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 首次启动创建管理员账号，并要求默认口令账号修改密码
+	if err := srv.BootstrapUsers(); err != nil {
+		log.Fatal(err)
+	}
+	// 首次启动将 保研条例.md 导入为第一个条例版本，并由附件生成目录
+	if err := srv.SeedRegulationSets(); err != nil {
+		log.Fatal(err)
+	}
+	// 为升级前的待审材料和审核人不足的材料补充分配
+	srv.StartAssignBacklog()
+	// 定期升级超过处理期限的审核分配
+	srv.StartEscalationJob()
+	srv.StartPurgeJob()
+
 	mux := http.NewServeMux()
+	// 会话认证中间件：解析 Authorization 头中的令牌并注入当前用户
+	// 权限中间件：按路由权限表校验角色
+	handler := srv.AuthMiddleware(srv.RBACMiddleware(mux))
 
 	// 添加全局日志中间件
 	muxWithLogging := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -25,26 +94,74 @@ func main() {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		mux.ServeHTTP(w, r)
+		handler.ServeHTTP(w, r)
 	})
 
 	// 注册各模块路由
-	api.RegisterSubmitMaterialBatchRoutes(mux)
-	api.RegisterUserInfoRoutes(mux)
-	api.RegisterUserSettingRoutes(mux)
+	srv.RegisterHealthRoutes(mux)
+	srv.RegisterSubmitMaterialBatchRoutes(mux)
+	srv.RegisterUserInfoRoutes(mux)
+	srv.RegisterUserSettingRoutes(mux)
 
 	// 注册其他 API 路由
-	api.RegisterExportRoutes(mux)
-	api.RegisterInfoImportRoutes(mux)
-	api.RegisterMaterialListRoutes(mux)
-	api.RegisterMaterialUploadRoutes(mux)
-	api.RegisterLLMRoutes(mux)
-	api.RegisterVolunteerRoutes(mux)
-	api.RegisterBonusRoutes(mux)
-	api.RegisterMessageRoutes(mux)
-
-	log.Println("Server started at :8000")
-	if err := http.ListenAndServe("127.0.0.1:8000", muxWithLogging); err != nil {
-		log.Fatal(err)
+	srv.RegisterExportRoutes(mux)
+	srv.RegisterInfoImportRoutes(mux)
+	srv.RegisterMaterialListRoutes(mux)
+	srv.RegisterMaterialWorkflowRoutes(mux)
+	srv.RegisterMaterialRevisionRoutes(mux)
+	srv.RegisterMaterialTrashRoutes(mux)
+	srv.RegisterReviewPolicyRoutes(mux)
+	srv.RegisterAssignmentRoutes(mux)
+	srv.RegisterSLARoutes(mux)
+	srv.RegisterAppealRoutes(mux)
+	srv.RegisterMaterialUploadRoutes(mux)
+	srv.RegisterLLMRoutes(mux)
+	srv.RegisterVolunteerRoutes(mux)
+	srv.RegisterBonusRoutes(mux)
+	srv.RegisterScoringRoutes(mux)
+	srv.RegisterRegulationRoutes(mux)
+	srv.RegisterCatalogueRoutes(mux)
+	srv.RegisterRankingRoutes(mux)
+	srv.RegisterEligibilityRoutes(mux)
+	srv.RegisterMessageRoutes(mux)
+
+	httpServer := &http.Server{
+		Addr:         cfg.Server.Listen,
+		Handler:      muxWithLogging,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server started at %s", cfg.Server.Listen)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+		return
+	case <-ctx.Done():
+	}
+	stop()
+
+	// 先标记不再就绪，再排空进行中的请求，最后等待后台任务
+	log.Printf("[关闭] 收到退出信号，最多等待 %s", cfg.Server.ShutdownTimeout)
+	srv.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[关闭] 排空请求未完成: %v", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[关闭] %v", err)
 	}
+	log.Println("[关闭] 服务已停止")
 }
@@ -0,0 +1,298 @@
+// Package pdf 纯 Go 实现的最小 PDF 生成器，只提供导出报告所需的文字、线条与图片
+// 中文使用阅读器内置的 STSong-Light 字体（Adobe-GB1 字符集），不需要嵌入字体文件，也不依赖外部程序
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+)
+
+// A4 纸张尺寸，单位为点（1/72 英寸）
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document 一份正在生成的 PDF 文档
+// 坐标以页面左上角为原点、向下为正，写出时换算为 PDF 的左下角坐标系
+type Document struct {
+	pages  []*bytes.Buffer
+	images []pdfImage
+}
+
+type pdfImage struct {
+	data          []byte
+	width, height int
+}
+
+// New 创建空文档，第一次写入内容前需调用 AddPage
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage 追加一页 A4 纸，之后的内容都写到该页
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount 当前页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// runeWidth 字符宽度占字号的比例，ASCII 为半角，其余按全角计算
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+// TextWidth 文字以 size 字号排版时的宽度
+func TextWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w * size
+}
+
+// Wrap 按宽度把文字拆成多行，遇到换行符时另起一行；空文字返回一个空行
+func Wrap(s string, size, width float64) []string {
+	lines := make([]string, 0, 1)
+	for _, para := range strings.Split(s, "\n") {
+		var (
+			line strings.Builder
+			w    float64
+		)
+		for _, r := range para {
+			rw := runeWidth(r) * size
+			if w+rw > width && line.Len() > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+				w = 0
+			}
+			line.WriteRune(r)
+			w += rw
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// encodeText 按 UniGB-UCS2-H 编码把文字转为十六进制字符串，基本平面以外的字符以问号代替
+func encodeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r > 0xFFFF:
+			r = '?'
+		case r < 0x20:
+			r = ' '
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// Text 在 (x, y) 处写一行文字，y 为基线位置
+func (d *Document) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.page(), "BT /F1 %.2f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, PageHeight-y, encodeText(s))
+}
+
+// Line 画一条线段
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Rect 画矩形边框，(x, y) 为左上角
+func (d *Document) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, PageHeight-y-h, w, h)
+}
+
+// AddImage 把图片按 JPEG 编码加入文档，返回供 DrawImage 使用的编号
+// 图片先铺在白色背景上转为 RGB：灰度图直接编码会得到单通道 JPEG，与 /DeviceRGB 不符；透明部分也不会变黑
+func (d *Document) AddImage(img image.Image) (int, error) {
+	bounds := img.Bounds()
+	rgb := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgb, rgb.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgb, rgb.Bounds(), img, bounds.Min, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgb, &jpeg.Options{Quality: 80}); err != nil {
+		return 0, err
+	}
+	d.images = append(d.images, pdfImage{data: buf.Bytes(), width: bounds.Dx(), height: bounds.Dy()})
+	return len(d.images) - 1, nil
+}
+
+// ImageSize 已加入图片的像素尺寸
+func (d *Document) ImageSize(id int) (int, int) {
+	img := d.images[id]
+	return img.width, img.height
+}
+
+// DrawImage 在左上角 (x, y) 处按 w×h 绘制已加入的图片
+func (d *Document) DrawImage(id int, x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, PageHeight-y-h, id)
+}
+
+// Thumbnail 把图片缩小到不超过 maxW×maxH 像素，较小的图片原样返回
+// 每个目标像素取对应源区域的平均颜色
+func Thumbnail(src image.Image, maxW, maxH int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= 0 || sh <= 0 || (sw <= maxW && sh <= maxH) {
+		return src
+	}
+	scale := float64(maxW) / float64(sw)
+	if s := float64(maxH) / float64(sh); s < scale {
+		scale = s
+	}
+	dw, dh := int(float64(sw)*scale), int(float64(sh)*scale)
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+(y+1)*sh/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+(x+1)*sw/dw
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+cr, g+cg, bl+cb, a+ca, n+1
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n>>8), uint8(g/n>>8), uint8(bl/n>>8), uint8(a/n>>8)
+		}
+	}
+	return dst
+}
+
+// countingWriter 记录已写出的字节数，用于生成交叉引用表
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// WriteTo 写出完整的 PDF 文件
+// 对象依次为：目录、页树、字体（Type0、CIDFont、字体描述）、图片，以及每页的页面与内容流
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		fontObj    = 3
+		cidFontObj = 4
+		descObj    = 5
+		firstImage = 6
+	)
+	firstPage := firstImage + len(d.images)
+	total := firstPage + 2*len(d.pages) - 1
+
+	cw := &countingWriter{w: w}
+	offsets := make([]int64, total+1)
+	begin := func(id int) {
+		offsets[id] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n", id)
+	}
+	end := func() { io.WriteString(cw, "endobj\n") }
+	stream := func(id int, dict string, data []byte) {
+		begin(id)
+		fmt.Fprintf(cw, "<<%s /Length %d>>\nstream\n", dict, len(data))
+		cw.Write(data)
+		io.WriteString(cw, "\nendstream\n")
+		end()
+	}
+
+	io.WriteString(cw, "%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	begin(catalogObj)
+	fmt.Fprintf(cw, "<</Type /Catalog /Pages %d 0 R>>\n", pagesObj)
+	end()
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	begin(pagesObj)
+	fmt.Fprintf(cw, "<</Type /Pages /Kids [%s] /Count %d>>\n", strings.Join(kids, " "), len(d.pages))
+	end()
+
+	begin(fontObj)
+	fmt.Fprintf(cw, "<</Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [%d 0 R]>>\n", cidFontObj)
+	end()
+	// Adobe-GB1 中 1-95 与 814-939 为半角字符
+	begin(cidFontObj)
+	fmt.Fprintf(cw, "<</Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo <</Registry (Adobe) /Ordering (GB1) /Supplement 2>> /FontDescriptor %d 0 R /DW 1000 /W [1 95 500 814 939 500]>>\n", descObj)
+	end()
+	begin(descObj)
+	io.WriteString(cw, "<</Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93>>\n")
+	end()
+
+	xobjects := make([]string, len(d.images))
+	for i, img := range d.images {
+		stream(firstImage+i, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode", img.width, img.height), img.data)
+		xobjects[i] = fmt.Sprintf("/Im%d %d 0 R", i, firstImage+i)
+	}
+	resources := fmt.Sprintf("<</Font <</F1 %d 0 R>> /XObject <<%s>>>>", fontObj, strings.Join(xobjects, " "))
+
+	for i, content := range d.pages {
+		pageObj := firstPage + 2*i
+		begin(pageObj)
+		fmt.Fprintf(cw, "<</Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R>>\n", pagesObj, PageWidth, PageHeight, resources, pageObj+1)
+		end()
+		stream(pageObj+1, "/Filter /FlateDecode", deflate(content.Bytes()))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", total+1)
+	for id := 1; id <= total; id++ {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offsets[id])
+	}
+	fmt.Fprintf(cw, "trailer\n<</Size %d /Root %d 0 R>>\nstartxref\n%d\n%%%%EOF\n", total+1, catalogObj, xref)
+	return cw.n, cw.err
+}
@@ -0,0 +1,278 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// parsed 按交叉引用表读出的 PDF 对象，键为对象编号，值为 obj 与 endobj 之间的内容
+type parsed struct {
+	size    int
+	objects map[int][]byte
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	sizePattern      = regexp.MustCompile(`/Size (\d+) /Root 1 0 R`)
+	lengthPattern    = regexp.MustCompile(`/Length (\d+)>>\nstream\n`)
+)
+
+// parse 校验文件头、startxref 与每个交叉引用偏移都指向对应对象，返回全部对象
+func parse(t *testing.T, data []byte) parsed {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("文件头错误: %q", data[:min(len(data), 16)])
+	}
+	m := startxrefPattern.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("缺少 startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d 未指向交叉引用表", xref)
+	}
+	lines := strings.Split(string(data[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	sm := sizePattern.FindSubmatch(data)
+	if sm == nil {
+		t.Fatal("trailer 缺少 /Size")
+	}
+	if size, _ := strconv.Atoi(string(sm[1])); size != count {
+		t.Fatalf("/Size %d 与交叉引用表的 %d 项不符", size, count)
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Fatalf("第 0 项交叉引用错误: %q", lines[2])
+	}
+
+	p := parsed{size: count, objects: make(map[int][]byte)}
+	for id := 1; id < count; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("第 %d 项交叉引用格式错误: %q", id, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		header := []byte(strconv.Itoa(id) + " 0 obj\n")
+		if !bytes.HasPrefix(data[offset:], header) {
+			t.Fatalf("对象 %d 的偏移 %d 指向 %q", id, offset, data[offset:min(len(data), offset+16)])
+		}
+		body := data[offset+len(header):]
+		if lm := lengthPattern.FindSubmatchIndex(body); lm != nil && lm[0] < bytes.Index(body, []byte("endobj")) {
+			// 流对象按 /Length 截取，流内容可能包含 endobj 字样
+			n, _ := strconv.Atoi(string(body[lm[2]:lm[3]]))
+			body = body[:lm[1]+n]
+		} else {
+			body = body[:bytes.Index(body, []byte("endobj"))]
+		}
+		p.objects[id] = body
+	}
+	if got := bytes.Count(data, []byte(" 0 obj\n")); got != count-1 {
+		t.Fatalf("文件中有 %d 个对象，交叉引用表为 %d 个", got, count-1)
+	}
+	return p
+}
+
+// streamData 返回流对象的内容，FlateDecode 的内容会解压
+func streamData(t *testing.T, obj []byte) []byte {
+	t.Helper()
+	i := bytes.Index(obj, []byte(">>\nstream\n"))
+	if i < 0 {
+		t.Fatalf("不是流对象: %q", obj)
+	}
+	data := obj[i+len(">>\nstream\n"):]
+	if !bytes.Contains(obj[:i], []byte("/FlateDecode")) {
+		return data
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// objectsOf 返回字典中含 marker 的对象编号，按编号排序
+func (p parsed) objectsOf(marker string) []int {
+	ids := make([]int, 0)
+	for id := 1; id < p.size; id++ {
+		if bytes.Contains(p.objects[id], []byte(marker)) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+var textPattern = regexp.MustCompile(`<([0-9A-F]*)> Tj`)
+
+// decodeText 把内容流中的 UCS-2 十六进制字符串还原为文字
+func decodeText(t *testing.T, content []byte) []string {
+	t.Helper()
+	texts := make([]string, 0)
+	for _, m := range textPattern.FindAllSubmatch(content, -1) {
+		raw, err := hex.DecodeString(string(m[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		}
+		texts = append(texts, string(utf16.Decode(units)))
+	}
+	return texts
+}
+
+func render(t *testing.T, d *Document) parsed {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo 返回 %d 字节，实际写出 %d", n, buf.Len())
+	}
+	return parse(t, buf.Bytes())
+}
+
+func TestWriteToStructure(t *testing.T) {
+	d := New()
+	d.Text(50, 50, 12, "第一页")
+	d.AddPage()
+	d.Text(50, 50, 12, "第二页")
+	d.AddPage()
+	d.Line(0, 0, 10, 10, 1)
+
+	p := render(t, d)
+	// 目录、页树、三个字体对象，加每页的页面与内容流
+	if want := 1 + 5 + 2*3; p.size != want {
+		t.Errorf("/Size = %d, want %d", p.size, want)
+	}
+	if !bytes.Contains(p.objects[2], []byte("/Count 3")) {
+		t.Errorf("页树: %s", p.objects[2])
+	}
+	if pages := p.objectsOf("/Type /Page "); len(pages) != 3 {
+		t.Errorf("页面对象 %v, want 3 个", pages)
+	}
+
+	// 空文档写出时补一页空白页
+	if p := render(t, New()); !bytes.Contains(p.objects[2], []byte("/Count 1")) {
+		t.Errorf("空文档页树: %s", p.objects[2])
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	lines := []string{"个人成果加分汇总表", "学号 2021001 姓名：张三", "ACM-ICPC (Asia) 金奖 10.5 分"}
+	d := New()
+	for i, s := range lines {
+		d.Text(50, 50+float64(i)*20, 12, s)
+	}
+	p := render(t, d)
+	pages := p.objectsOf("/Type /Page ")
+	if len(pages) != 1 {
+		t.Fatalf("页面对象 %v", pages)
+	}
+	got := decodeText(t, streamData(t, p.objects[pages[0]+1]))
+	if strings.Join(got, "|") != strings.Join(lines, "|") {
+		t.Errorf("文字 = %q, want %q", got, lines)
+	}
+}
+
+func TestImageRoundTrip(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 8, 6))
+	for i := range gray.Pix {
+		gray.Pix[i] = 0x40
+	}
+	// 左半透明、右半不透明红色
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 5; x < 10; x++ {
+			nrgba.SetNRGBA(x, y, color.NRGBA{R: 0xFF, A: 0xFF})
+		}
+	}
+
+	d := New()
+	for _, img := range []image.Image{gray, nrgba} {
+		id, err := d.AddImage(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, h := d.ImageSize(id)
+		d.DrawImage(id, 50, 50, float64(w), float64(h))
+	}
+
+	p := render(t, d)
+	ids := p.objectsOf("/Subtype /Image")
+	if len(ids) != 2 {
+		t.Fatalf("图片对象 %v, want 2 个", ids)
+	}
+	decoded := make([]image.Image, len(ids))
+	for i, id := range ids {
+		if !bytes.Contains(p.objects[id], []byte("/ColorSpace /DeviceRGB")) {
+			t.Errorf("图片 %d 的字典: %s", i, p.objects[id][:bytes.Index(p.objects[id], []byte("stream"))])
+		}
+		data := streamData(t, p.objects[id])
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("图片 %d 不是合法的 JPEG: %v", i, err)
+		}
+		// /DeviceRGB 要求三通道 JPEG，灰度图也需转换
+		if cfg.ColorModel != color.YCbCrModel {
+			t.Errorf("图片 %d 的颜色模型 %v, want YCbCr", i, cfg.ColorModel)
+		}
+		if decoded[i], err = jpeg.Decode(bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b := decoded[0].Bounds(); b.Dx() != 8 || b.Dy() != 6 {
+		t.Errorf("灰度图尺寸 %v", b)
+	}
+
+	near := func(c color.Color, r, g, b uint8) bool {
+		cr, cg, cb, _ := c.RGBA()
+		d := func(v uint32, want uint8) bool { return int(v>>8)-int(want) < 24 && int(want)-int(v>>8) < 24 }
+		return d(cr, r) && d(cg, g) && d(cb, b)
+	}
+	if c := decoded[0].At(4, 3); !near(c, 0x40, 0x40, 0x40) {
+		t.Errorf("灰度图颜色 %v", c)
+	}
+	// 透明部分铺白色背景，不能变黑
+	if c := decoded[1].At(1, 5); !near(c, 0xFF, 0xFF, 0xFF) {
+		t.Errorf("透明部分颜色 %v, want 白色", c)
+	}
+	if c := decoded[1].At(8, 5); !near(c, 0xFF, 0, 0) {
+		t.Errorf("不透明部分颜色 %v, want 红色", c)
+	}
+
+	pages := p.objectsOf("/Type /Page ")
+	if !bytes.Contains(p.objects[pages[0]], []byte("/Im0 ")) || !bytes.Contains(p.objects[pages[0]], []byte("/Im1 ")) {
+		t.Errorf("页面资源缺少图片: %s", p.objects[pages[0]])
+	}
+	content := streamData(t, p.objects[pages[0]+1])
+	if !bytes.Contains(content, []byte("/Im0 Do")) || !bytes.Contains(content, []byte("/Im1 Do")) {
+		t.Errorf("内容流: %s", content)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := Wrap("第一行\n成果加分汇总表abc", 10, 40)
+	want := []string{"第一行", "成果加分", "汇总表ab", "c"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Wrap = %q, want %q", lines, want)
+	}
+	if got := Wrap("", 10, 40); len(got) != 1 || got[0] != "" {
+		t.Errorf("Wrap(\"\") = %q", got)
+	}
+}
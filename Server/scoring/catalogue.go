@@ -0,0 +1,355 @@
+package scoring
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 目录种类：附件 1 学术会议和期刊目录、附件 2 学业竞赛项目库
+const (
+	CatalogueJournal     = "journal"
+	CatalogueCompetition = "competition"
+)
+
+// MatchThreshold 名称匹配的置信度不低于该值时视为目录内项目
+const MatchThreshold = 0.85
+
+// CatalogueEntry 目录中的一种期刊、会议或一项竞赛
+type CatalogueEntry struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Aliases 简称或其他写法，以 * 结尾的别名按前缀匹配，如 "Nature *" 匹配 Nature 的子刊
+	Aliases []string `json:"aliases"`
+	// Class 期刊：top/A/B/C；竞赛：A+/A/A-
+	Class string `json:"class"`
+	// Level 竞赛级别 national/provincial，期刊为空
+	Level string `json:"level"`
+	Host  string `json:"host"`
+	// External 是否为非信息学院的竞赛项目
+	External bool `json:"external"`
+}
+
+// Match 名称与目录条目的匹配结果
+type Match struct {
+	// Index 条目在传入切片中的下标
+	Index      int     `json:"-"`
+	Confidence float64 `json:"confidence"`
+	// MatchedBy 取得最高置信度的名称或别名
+	MatchedBy string `json:"matchedBy"`
+}
+
+var (
+	// catalogueItemPattern 附件 1 中的编号段落，如 "3. 高水平中文学术期刊"
+	catalogueItemPattern = regexp.MustCompile(`^\d+\.\s*(.+)$`)
+	// acronymPattern 竞赛名称开头的英文简称，如 "ICPC 国际大学生程序设计竞赛"
+	acronymPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*(?: [A-Za-z][A-Za-z0-9]*)*)\s+\p{Han}`)
+)
+
+// ParseJournalCatalogue 解析附件 1 学术会议和期刊目录
+// Nature、Science、Cell 及其子刊为 top 类；高水平中文学术期刊等价 B 类，信息与通信工程学科推荐国际学术期刊等价 A 类
+// CCF 推荐目录在附件中没有逐项列出，需由管理员另行录入
+func ParseJournalCatalogue(md string) []CatalogueEntry {
+	entries := make([]CatalogueEntry, 0)
+	class := ""
+	for _, line := range strings.Split(md, "\n") {
+		line = strings.TrimSpace(line)
+		if m := catalogueItemPattern.FindStringSubmatch(line); m != nil {
+			heading := m[1]
+			switch {
+			case strings.Contains(heading, "主刊"):
+				class = ""
+				names := heading[:strings.Index(heading, "主刊")]
+				for _, name := range strings.Split(names, "、") {
+					if name = strings.TrimSpace(name); name != "" {
+						entries = append(entries, CatalogueEntry{Kind: CatalogueJournal, Name: name, Aliases: []string{name + " *"}, Class: ClassTop})
+					}
+				}
+			case strings.Contains(heading, "中文"):
+				class = ClassB
+			case strings.Contains(heading, "国际学术期刊"):
+				class = ClassA
+			default:
+				class = ""
+			}
+			continue
+		}
+		if class == "" || !strings.HasPrefix(line, "* ") {
+			continue
+		}
+		if name := strings.TrimSpace(strings.TrimPrefix(line, "* ")); name != "" {
+			entries = append(entries, CatalogueEntry{Kind: CatalogueJournal, Name: name, Class: class})
+		}
+	}
+	return entries
+}
+
+// ParseCompetitionCatalogue 解析附件 2 学业竞赛项目库的表格
+// 一行中以 “/” 或 “、” 分隔的多项竞赛，第一项作为名称，其余作为别名
+func ParseCompetitionCatalogue(md string) []CatalogueEntry {
+	entries := make([]CatalogueEntry, 0)
+	for _, line := range strings.Split(md, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		if len(cells) < 5 {
+			continue
+		}
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		class := competitionClass(cells[3])
+		if class == "" {
+			continue // 表头与分隔行
+		}
+		names := strings.FieldsFunc(cells[1], func(r rune) bool { return r == '/' || r == '、' })
+		e := CatalogueEntry{Kind: CatalogueCompetition, Class: class, Level: competitionLevel(cells[4]), Host: cells[2]}
+		for i, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if i == 0 {
+				e.Name = name
+			} else {
+				e.Aliases = append(e.Aliases, name)
+			}
+			if m := acronymPattern.FindStringSubmatch(name); m != nil {
+				e.Aliases = append(e.Aliases, m[1])
+			}
+		}
+		if e.Name != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// competitionClass 将 “A + 类竞赛” 等写法转换为 A+/A/A-，无法识别时返回空字符串
+func competitionClass(cell string) string {
+	c := strings.ReplaceAll(cell, " ", "")
+	c = strings.TrimSuffix(strings.TrimSuffix(c, "竞赛"), "类")
+	switch c {
+	case ClassAPlus, ClassA, ClassAMinus:
+		return c
+	}
+	return ""
+}
+
+func competitionLevel(cell string) string {
+	switch strings.ReplaceAll(cell, " ", "") {
+	case "国际级":
+		return LevelInternational
+	case "国家级":
+		return LevelNational
+	case "省级":
+		return LevelProvincial
+	case "校级":
+		return LevelSchool
+	}
+	return ""
+}
+
+// MatchCatalogue 按名称相似度返回置信度从高到低的候选条目，最多 limit 条，limit 不大于 0 时不限
+func MatchCatalogue(query string, entries []CatalogueEntry, limit int) []Match {
+	matches := make([]Match, 0)
+	for i, e := range entries {
+		best := Match{Index: i}
+		// 竞赛名称常带届次、赛区等前后缀，期刊名称包含目录名称时往往是另一种刊物
+		partial := e.Kind == CatalogueCompetition
+		for _, name := range append([]string{e.Name}, e.Aliases...) {
+			if c := nameConfidence(query, name, partial); c > best.Confidence {
+				best.Confidence, best.MatchedBy = c, name
+			}
+		}
+		if best.Confidence > 0 {
+			best.Confidence = Round(best.Confidence)
+			matches = append(matches, best)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// nameConfidence 两个名称指向同一项目的置信度（0-1）
+// 规范化后相同为 1；前缀别名匹配为 0.95；英文简称作为独立单词出现为 0.9；
+// partial 时名称包含目录名称（目录名称加届次、赛区等前后缀）按目录名称的长度与长度比例计算，目录名称 6 个字以上时视为匹配；
+// 名称只是目录名称的一部分时低于 MatchThreshold，需管理员确认；
+// 其余按编辑距离计算相似度并低于 MatchThreshold，只作为候选供人工确认
+func nameConfidence(query, name string, partial bool) float64 {
+	if prefix := strings.TrimSuffix(name, "*"); prefix != name {
+		p := normalizeName(prefix)
+		q := normalizeName(query)
+		if p != "" && strings.HasPrefix(q, p) && len(q) > len(p) {
+			return 0.95
+		}
+		name = prefix
+	}
+	q, n := normalizeName(query), normalizeName(name)
+	if q == "" || n == "" {
+		return 0
+	}
+	if q == n {
+		return 1
+	}
+	if isAcronym(name) && containsWord(query, name) {
+		return 0.9
+	}
+	qr, nr := []rune(q), []rune(n)
+	if partial && len(nr) >= 3 && len(nr) < len(qr) && strings.Contains(q, n) {
+		// 名称带届次、赛区等前后缀
+		ratio := float64(len(nr)) / float64(len(qr))
+		if len(nr) >= 6 {
+			return 0.85 + 0.15*ratio
+		}
+		return 0.6 + 0.2*ratio
+	}
+	if partial && len(qr) >= 3 && len(qr) < len(nr) && strings.Contains(n, q) {
+		// 名称只是目录名称的一部分，如泛称的“程序设计竞赛”，不能确定是哪一项
+		return 0.6 + 0.2*float64(len(qr))/float64(len(nr))
+	}
+	long := len(qr)
+	if len(nr) > long {
+		long = len(nr)
+	}
+	return 0.8 * (1 - float64(editDistance(qr, nr))/float64(long))
+}
+
+// normalizeName 转小写并去掉空白、标点与引号，便于比较不同写法的名称
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isAcronym 名称是否为纯英文简称，如 ICPC、CCPC
+func isAcronym(name string) bool {
+	name = strings.TrimSpace(name)
+	if len(name) < 3 || strings.Contains(name, " ") {
+		return false
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsWord 报告 s 中是否出现独立的单词 word，不区分大小写
+func containsWord(s, word string) bool {
+	s, word = strings.ToLower(s), strings.ToLower(word)
+	for i := strings.Index(s, word); i >= 0; {
+		end := i + len(word)
+		before := i == 0 || !isASCIIAlnum(s[i-1])
+		after := end == len(s) || !isASCIIAlnum(s[end])
+		if before && after {
+			return true
+		}
+		next := strings.Index(s[i+1:], word)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isASCIIAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// classRank 竞赛类别由高到低的次序，数值越小类别越高
+var classRank = map[string]int{ClassAPlus: 0, ClassA: 1, ClassAMinus: 2}
+
+// ApplyCatalogue 以目录条目为准修正要素：名称取目录名称，论文类别取目录类别，竞赛是否非信息学院项目取目录标记
+// 竞赛类别以目录类别为上限，按条例降档计分的情况（如 ICPC 区域赛按 A 类）保留提取的较低类别
+func ApplyCatalogue(f Facts, e CatalogueEntry) Facts {
+	switch f.Kind {
+	case KindPaper:
+		f.Venue = e.Name
+		f.Class = e.Class
+	case KindCompetition:
+		f.Name = e.Name
+		f.External = e.External
+		if rank, ok := classRank[f.Class]; !ok || rank < classRank[e.Class] {
+			f.Class = e.Class
+		}
+	}
+	return f
+}
+
+// CatalogueQuery 返回要素需要在目录中核对的种类与名称，不需要核对时种类为空
+// CCF CSP 认证由条例单独规定加分标准，不要求在竞赛项目库中
+func CatalogueQuery(f Facts) (kind, name string) {
+	switch {
+	case f.Kind == KindPaper:
+		return CatalogueJournal, f.Venue
+	case f.Kind == KindCompetition && f.Percentile <= 0:
+		return CatalogueCompetition, f.Name
+	}
+	return "", ""
+}
+
+// ResolveCatalogue 在目录中核对论文的期刊会议或竞赛名称，并按匹配的条目修正要素
+// entries 为对应种类的目录；不需要核对的要素原样返回，名称缺失或不在目录中时返回 ErrInvalidFacts
+func ResolveCatalogue(f Facts, entries []CatalogueEntry) (Facts, *Match, error) {
+	kind, name := CatalogueQuery(f)
+	switch {
+	case kind == "":
+		return f, nil, nil
+	case strings.TrimSpace(name) == "" && kind == CatalogueJournal:
+		return f, nil, invalid("缺少论文发表的期刊或会议名称")
+	case strings.TrimSpace(name) == "":
+		return f, nil, invalid("缺少竞赛名称")
+	}
+	matches := MatchCatalogue(name, entries, 1)
+	if len(matches) == 0 || matches[0].Confidence < MatchThreshold {
+		if kind == CatalogueJournal {
+			return f, nil, invalid("「%s」不在学术会议和期刊目录中", name)
+		}
+		return f, nil, invalid("「%s」不在学业竞赛项目库中，不予认定加分", name)
+	}
+	m := matches[0]
+	return ApplyCatalogue(f, entries[m.Index]), &m, nil
+}
@@ -0,0 +1,141 @@
+package scoring
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// regulationCatalogue 从条例附件解析出的期刊目录与竞赛项目库
+func regulationCatalogue(t *testing.T) (journals, competitions []CatalogueEntry) {
+	t.Helper()
+	md, err := os.ReadFile("../docs/保研条例.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 与导入条例时相同，附件 1、附件 2 分别解析
+	doc := string(md)
+	appendix := doc[strings.Index(doc, "\n## 附件"):]
+	split := strings.Index(appendix, "\n### 附件 2")
+	journals, competitions = ParseJournalCatalogue(appendix[:split]), ParseCompetitionCatalogue(appendix[split:])
+	if len(journals) == 0 || len(competitions) != 22 {
+		t.Fatalf("解析得到 %d 种期刊、%d 项竞赛", len(journals), len(competitions))
+	}
+	return journals, competitions
+}
+
+func TestMatchCatalogueCompetition(t *testing.T) {
+	_, competitions := regulationCatalogue(t)
+	cases := []struct {
+		query string
+		// want 为空表示不应自动认定
+		want  string
+		class string
+	}{
+		{"福建省大学生程序设计竞赛", "福建省大学生程序设计竞赛", ClassAMinus},
+		{"第十二届福建省大学生程序设计竞赛（厦门赛区）", "福建省大学生程序设计竞赛", ClassAMinus},
+		{"2023 年全国大学生数学建模竞赛福建赛区", "全国大学生数学建模竞赛", ClassAMinus},
+		{"ICPC 亚洲区域赛（南京站）", "ICPC 国际大学生程序设计竞赛", ClassAPlus},
+		{"CCPC 中国大学生程序设计竞赛", "ICPC 国际大学生程序设计竞赛", ClassAPlus},
+		{"程序设计竞赛", "", ""},
+		{"数学建模竞赛", "", ""},
+		{"大学生程序设计竞赛", "", ""},
+		{"校园歌手大赛", "", ""},
+	}
+	for _, c := range cases {
+		matches := MatchCatalogue(c.query, competitions, 1)
+		accepted := len(matches) > 0 && matches[0].Confidence >= MatchThreshold
+		switch {
+		case c.want == "" && accepted:
+			t.Errorf("%q 不应自动认定，匹配到 %s（%v）", c.query, competitions[matches[0].Index].Name, matches[0].Confidence)
+		case c.want != "" && !accepted:
+			t.Errorf("%q 应认定为 %s，结果 %+v", c.query, c.want, matches)
+		case c.want != "":
+			if e := competitions[matches[0].Index]; e.Name != c.want || e.Class != c.class {
+				t.Errorf("%q 匹配到 %s（%s），want %s（%s）", c.query, e.Name, e.Class, c.want, c.class)
+			}
+		}
+	}
+}
+
+func TestMatchCatalogueCandidates(t *testing.T) {
+	_, competitions := regulationCatalogue(t)
+	// 泛称不能自动认定，但应给出候选供管理员确认
+	matches := MatchCatalogue("程序设计竞赛", competitions, 3)
+	if len(matches) == 0 {
+		t.Fatal("没有候选")
+	}
+	for i, m := range matches {
+		if m.Confidence >= MatchThreshold {
+			t.Errorf("候选 %s 置信度 %v 不应达到阈值", competitions[m.Index].Name, m.Confidence)
+		}
+		if i > 0 && m.Confidence > matches[i-1].Confidence {
+			t.Errorf("候选未按置信度排序: %+v", matches)
+		}
+	}
+}
+
+func TestMatchCatalogueJournal(t *testing.T) {
+	journals, _ := regulationCatalogue(t)
+	cases := []struct {
+		query string
+		class string
+	}{
+		{"Nature", ClassTop},
+		{"Nature Communications", ClassTop},
+		{"IEEE Transactions on Automatic Control", ClassA},
+		{"ieee transactions on automatic control", ClassA},
+		{"Journal of Nothing", ""},
+	}
+	for _, c := range cases {
+		matches := MatchCatalogue(c.query, journals, 1)
+		accepted := len(matches) > 0 && matches[0].Confidence >= MatchThreshold
+		switch {
+		case c.class == "" && accepted:
+			t.Errorf("%q 不应匹配，结果 %s", c.query, journals[matches[0].Index].Name)
+		case c.class != "" && !accepted:
+			t.Errorf("%q 应匹配 %s 类，结果 %+v", c.query, c.class, matches)
+		case c.class != "" && journals[matches[0].Index].Class != c.class:
+			t.Errorf("%q 匹配到 %s 类，want %s", c.query, journals[matches[0].Index].Class, c.class)
+		}
+	}
+}
+
+func TestResolveCatalogue(t *testing.T) {
+	journals, competitions := regulationCatalogue(t)
+
+	f, m, err := ResolveCatalogue(Facts{Kind: KindCompetition, Name: "第十二届福建省大学生程序设计竞赛", Class: ClassA, External: true}, competitions)
+	if err != nil || m == nil {
+		t.Fatalf("ResolveCatalogue: %v", err)
+	}
+	if f.Name != "福建省大学生程序设计竞赛" || f.Class != ClassAMinus || f.External {
+		t.Errorf("修正后的要素 %+v", f)
+	}
+
+	// ICPC 区域赛按条例降为 A 类计分，保留提取的较低类别
+	f, _, err = ResolveCatalogue(Facts{Kind: KindCompetition, Name: "ICPC 亚洲区域赛", Class: ClassA}, competitions)
+	if err != nil || f.Class != ClassA {
+		t.Errorf("ICPC 区域赛: %+v, %v", f, err)
+	}
+
+	for _, name := range []string{"程序设计竞赛", "数学建模竞赛", ""} {
+		if _, _, err := ResolveCatalogue(Facts{Kind: KindCompetition, Name: name}, competitions); !errors.Is(err, ErrInvalidFacts) {
+			t.Errorf("%q: err = %v, want ErrInvalidFacts", name, err)
+		}
+	}
+
+	f, _, err = ResolveCatalogue(Facts{Kind: KindPaper, Venue: "Nature Communications", Class: ClassC}, journals)
+	if err != nil || f.Class != ClassTop {
+		t.Errorf("论文: %+v, %v", f, err)
+	}
+	if _, _, err := ResolveCatalogue(Facts{Kind: KindPaper, Venue: "Journal of Nothing"}, journals); !errors.Is(err, ErrInvalidFacts) {
+		t.Errorf("目录外期刊: err = %v, want ErrInvalidFacts", err)
+	}
+
+	// CCF CSP 认证不需要核对项目库
+	csp := Facts{Kind: KindCompetition, Name: "CCF CSP 认证", Percentile: 5}
+	if f, m, err := ResolveCatalogue(csp, competitions); err != nil || m != nil || f != csp {
+		t.Errorf("CSP: %+v, %+v, %v", f, m, err)
+	}
+}
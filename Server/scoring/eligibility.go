@@ -0,0 +1,150 @@
+package scoring
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Course 成绩单中参与推免成绩计算的一门课程
+type Course struct {
+	Name   string
+	Credit float64
+	// Score 百分制成绩，Graded 为 false 表示两级计分等没有百分制成绩的课程，不参与绩点计算
+	Score  float64
+	Graded bool
+	// Retaken 是否为重修通过的成绩
+	Retaken bool
+}
+
+// Eligibility 按成绩单判断的推免申请资格
+type Eligibility struct {
+	Eligible bool `json:"eligible"`
+	// RetakenCount 计入限制的重修门次，RetakenCourses 为对应的课程名称
+	RetakenCount   int      `json:"retakenCount"`
+	RetakenCourses []string `json:"retakenCourses"`
+	RetakeLimit    int      `json:"retakeLimit"`
+	// GPA 重修课程按 RetakenScore 计算后的平均学分绩点，保留 4 位小数；Credits 为参与计算的学分
+	GPA     float64  `json:"gpa"`
+	Credits float64  `json:"credits"`
+	Reasons []string `json:"reasons"`
+}
+
+// 旧版本条例的加分表中没有资格参数时使用的默认值
+const (
+	defaultRetakenScore = 60
+	defaultRetakeLimit  = 3
+)
+
+// GradePoint 百分制成绩对应的学分绩点，低于最低档时为 0
+func (r *Rules) GradePoint(score float64) float64 {
+	table := r.GradePoints
+	if len(table) == 0 {
+		table = defaultGradePoints()
+	}
+	for _, g := range table {
+		if score >= g.MinScore {
+			return g.Point
+		}
+	}
+	return 0
+}
+
+// retakeExempt 课程是否不计入重修门次
+func (r *Rules) retakeExempt(course string) bool {
+	exempt := r.RetakeExempt
+	if exempt == nil {
+		exempt = []string{"游泳"}
+	}
+	for _, kw := range exempt {
+		if kw != "" && strings.Contains(course, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// TranscriptGPA 按条例第二条计算平均学分绩点：∑(课程绩点 × 学分) / ∑学分，保留 4 位小数
+// 重修通过的课程按 RetakenScore 分计算
+func (r *Rules) TranscriptGPA(courses []Course) (gpa, credits float64) {
+	retaken := r.RetakenScore
+	if retaken <= 0 {
+		retaken = defaultRetakenScore
+	}
+	var points float64
+	for _, c := range courses {
+		if !c.Graded || c.Credit <= 0 {
+			continue
+		}
+		score := c.Score
+		if c.Retaken {
+			score = retaken
+		}
+		points += r.GradePoint(score) * c.Credit
+		credits += c.Credit
+	}
+	if credits == 0 {
+		return 0, 0
+	}
+	return Round(points / credits), credits
+}
+
+// CheckEligibility 按条例第二条判断推免资格：曾重修通过的课程（不含游泳）达到 RetakeLimit 门次者不具备资格
+// 同一课程多次重修按多门次计
+func (r *Rules) CheckEligibility(courses []Course) Eligibility {
+	limit := r.RetakeLimit
+	if limit <= 0 {
+		limit = defaultRetakeLimit
+	}
+	e := Eligibility{Eligible: true, RetakeLimit: limit, RetakenCourses: make([]string, 0), Reasons: make([]string, 0)}
+	for _, c := range courses {
+		if c.Retaken && !r.retakeExempt(c.Name) {
+			e.RetakenCount++
+			e.RetakenCourses = append(e.RetakenCourses, c.Name)
+		}
+	}
+	if e.RetakenCount >= limit {
+		e.Eligible = false
+		e.Reasons = append(e.Reasons, fmt.Sprintf("曾重修通过 %d 门次课程（不含游泳），达到 %d 门次不具备推免资格", e.RetakenCount, limit))
+	}
+	e.GPA, e.Credits = r.TranscriptGPA(courses)
+	return e
+}
+
+// datePattern 年、月与可选的日，分隔符不限，如 2025-09-01、2025/9/1、2025年9月1日、2025.9
+var datePattern = regexp.MustCompile(`(\d{4})\D+(\d{1,2})(?:\D+(\d{1,2}))?`)
+
+// ParseDate 解析成果取得时间，未写明日期时按当月 1 日计；无法识别时 ok 为 false
+func ParseDate(s string) (date time.Time, ok bool) {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	year, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	day := 1
+	if m[3] != "" {
+		day, _ = strconv.Atoi(m[3])
+	}
+	date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// 拒绝 2025-13-01、2025-02-30 等不存在的日期
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// AfterCutoff 报告成果取得时间 date 是否晚于截止日期 cutoff，任一时间无法识别时不作判断
+func AfterCutoff(date, cutoff string) bool {
+	d, ok := ParseDate(date)
+	if !ok {
+		return false
+	}
+	c, ok := ParseDate(cutoff)
+	if !ok {
+		return false
+	}
+	return d.After(c)
+}
@@ -0,0 +1,130 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGradePoint(t *testing.T) {
+	r := DefaultRules()
+	for score, want := range map[float64]float64{100: 4, 90: 4, 89.9: 3.7, 85: 3.7, 78: 3, 64: 1.5, 60: 1, 59.9: 0} {
+		if got := r.GradePoint(score); got != want {
+			t.Errorf("GradePoint(%v) = %v, want %v", score, got, want)
+		}
+	}
+}
+
+func TestTranscriptGPA(t *testing.T) {
+	r := DefaultRules()
+	courses := []Course{
+		{Name: "高等数学", Credit: 4, Score: 95, Graded: true},
+		{Name: "程序设计", Credit: 3, Score: 86, Graded: true},
+		// 重修通过按 60 分计
+		{Name: "大学物理", Credit: 3, Score: 92, Graded: true, Retaken: true},
+		// 两级计分与零学分课程不参与计算
+		{Name: "社会实践", Credit: 2, Graded: false},
+		{Name: "讲座", Credit: 0, Score: 100, Graded: true},
+	}
+	gpa, credits := r.TranscriptGPA(courses)
+	if credits != 10 || gpa != Round((4*4+3.7*3+1*3)/10.0) {
+		t.Errorf("TranscriptGPA = %v, %v", gpa, credits)
+	}
+	if gpa, credits := r.TranscriptGPA(nil); gpa != 0 || credits != 0 {
+		t.Errorf("空成绩单 TranscriptGPA = %v, %v", gpa, credits)
+	}
+}
+
+func TestCheckEligibility(t *testing.T) {
+	r := DefaultRules()
+	retaken := func(names ...string) []Course {
+		courses := []Course{{Name: "高等数学", Credit: 4, Score: 90, Graded: true}}
+		for _, name := range names {
+			courses = append(courses, Course{Name: name, Credit: 1, Score: 70, Graded: true, Retaken: true})
+		}
+		return courses
+	}
+	cases := []struct {
+		name     string
+		courses  []Course
+		eligible bool
+		count    int
+	}{
+		{"没有重修", retaken(), true, 0},
+		{"重修两门", retaken("线性代数", "大学物理"), true, 2},
+		{"重修三门", retaken("线性代数", "大学物理", "离散数学"), false, 3},
+		{"游泳不计入", retaken("线性代数", "大学物理", "体育（游泳）"), true, 2},
+		{"同一课程多次重修按多门次计", retaken("线性代数", "线性代数", "线性代数"), false, 3},
+	}
+	for _, c := range cases {
+		e := r.CheckEligibility(c.courses)
+		if e.Eligible != c.eligible || e.RetakenCount != c.count || len(e.RetakenCourses) != c.count || e.RetakeLimit != 3 {
+			t.Errorf("%s: %+v", c.name, e)
+		}
+		if !c.eligible && (len(e.Reasons) != 1 || !strings.Contains(e.Reasons[0], "不具备推免资格")) {
+			t.Errorf("%s: reasons %q", c.name, e.Reasons)
+		}
+		if c.eligible && len(e.Reasons) != 0 {
+			t.Errorf("%s: reasons %q", c.name, e.Reasons)
+		}
+	}
+
+	// 旧版本条例没有资格参数时使用默认值
+	old := &Rules{}
+	if e := old.CheckEligibility(retaken("游泳", "a", "b", "c")); e.Eligible || e.RetakenCount != 3 || e.RetakeLimit != 3 {
+		t.Errorf("默认参数 CheckEligibility = %+v", e)
+	}
+	custom := &Rules{RetakeLimit: 1, RetakeExempt: []string{}}
+	if e := custom.CheckEligibility(retaken("游泳")); e.Eligible {
+		t.Errorf("RetakeLimit 1 且不豁免游泳时应不具备资格: %+v", e)
+	}
+}
+
+func TestAfterCutoff(t *testing.T) {
+	const cutoff = "2025-08-31"
+	cases := []struct {
+		date string
+		want bool
+	}{
+		{"2025-08-31", false},
+		{"2025-09-01", true},
+		{"2025-9-1", true},
+		{"2025/9/15", true},
+		{"2025/08/30", false},
+		{"2025.9.1", true},
+		{"2025年9月1日", true},
+		{"2025年8月31日", false},
+		{" 2024年12月3日 ", false},
+		// 只写到月份时按当月 1 日计
+		{"2025-09", true},
+		{"2025年8月", false},
+		{"2025年9月", true},
+		// 无法识别的时间不作判断
+		{"", false},
+		{"2025", false},
+		{"去年九月", false},
+		{"2025-13-01", false},
+		{"2025-02-30", false},
+	}
+	for _, c := range cases {
+		if got := AfterCutoff(c.date, cutoff); got != c.want {
+			t.Errorf("AfterCutoff(%q, %q) = %v, want %v", c.date, cutoff, got, c.want)
+		}
+	}
+	if AfterCutoff("2030-01-01", "") {
+		t.Error("截止日期未知时不应判断为晚于截止日期")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for in, want := range map[string]string{
+		"2025-09-01": "2025-09-01",
+		"2025-9-1":   "2025-09-01",
+		"2025年9月15日": "2025-09-15",
+		"2025/10":    "2025-10-01",
+	} {
+		got, ok := ParseDate(in)
+		if !ok || got.Format("2006-01-02") != want {
+			t.Errorf("ParseDate(%q) = %v, %v, want %s", in, got, ok, want)
+		}
+	}
+}
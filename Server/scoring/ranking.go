@@ -0,0 +1,85 @@
+package scoring
+
+import "sort"
+
+// Candidate 参与专业排名的一名学生
+type Candidate struct {
+	StudentId string
+	// GPA 推免绩点，保留 4 位小数
+	GPA float64
+	// Academic、Comprehensive 已按上限汇总的学术专长成绩与综合表现成绩
+	Academic      float64
+	Comprehensive float64
+}
+
+// Standing 学生的推免综合成绩与专业排名
+type Standing struct {
+	Candidate
+	// AcademicScore 由推免绩点换算的学业综合成绩（百分制）
+	AcademicScore float64
+	// Composite 推免综合成绩 = 学业综合成绩 × 80% + 学术专长成绩 + 综合表现成绩
+	Composite float64
+	Rank      int
+	// Tied 与其他学生并列同一名次
+	Tied bool
+}
+
+// 旧版本条例的加分表中没有换算参数时使用的默认值
+const (
+	defaultGPAScale       = 4
+	defaultAcademicWeight = 0.8
+)
+
+// AcademicScore 将推免绩点换算为百分制的学业综合成绩
+func (r *Rules) AcademicScore(gpa float64) float64 {
+	scale := r.GPAScale
+	if scale <= 0 {
+		scale = defaultGPAScale
+	}
+	return Round(gpa / scale * 100)
+}
+
+// Standing 按条例第一条计算学生的推免综合成绩，名次由 RankStandings 填写
+func (r *Rules) Standing(c Candidate) Standing {
+	weight := r.AcademicWeight
+	if weight <= 0 {
+		weight = defaultAcademicWeight
+	}
+	c.GPA = Round(c.GPA)
+	academic := r.AcademicScore(c.GPA)
+	return Standing{
+		Candidate:     c,
+		AcademicScore: academic,
+		Composite:     Round(academic*weight + c.Academic + c.Comprehensive),
+	}
+}
+
+// tiedWith 两名学生的综合成绩与各项比较依据都相同时并列
+func (s Standing) tiedWith(o Standing) bool {
+	return s.Composite == o.Composite && s.GPA == o.GPA && s.Academic == o.Academic
+}
+
+// RankStandings 对同一专业的学生按推免综合成绩从高到低排名
+// 综合成绩相同时依次比较推免绩点、学术专长成绩，仍相同的学生并列同一名次（如 1、2、2、4），
+// 并列学生按学号排序，保证每次计算结果一致
+func RankStandings(list []Standing) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case a.Composite != b.Composite:
+			return a.Composite > b.Composite
+		case a.GPA != b.GPA:
+			return a.GPA > b.GPA
+		case a.Academic != b.Academic:
+			return a.Academic > b.Academic
+		default:
+			return a.StudentId < b.StudentId
+		}
+	})
+	for i := range list {
+		list[i].Rank, list[i].Tied = i+1, false
+		if i > 0 && list[i].tiedWith(list[i-1]) {
+			list[i].Rank, list[i].Tied, list[i-1].Tied = list[i-1].Rank, true, true
+		}
+	}
+}
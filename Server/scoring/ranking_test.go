@@ -0,0 +1,81 @@
+package scoring
+
+import "testing"
+
+func TestStanding(t *testing.T) {
+	r := DefaultRules()
+	s := r.Standing(Candidate{StudentId: "1", GPA: 3.6, Academic: 5, Comprehensive: 1.5})
+	if s.AcademicScore != 90 || s.Composite != 78.5 {
+		t.Errorf("Standing = %+v, want academic score 90 and composite 78.5", s)
+	}
+
+	// 旧版本条例没有换算参数时使用默认值
+	old := &Rules{}
+	if got := old.Standing(Candidate{GPA: 2}); got.AcademicScore != 50 || got.Composite != 40 {
+		t.Errorf("默认参数 Standing = %+v", got)
+	}
+}
+
+func TestRankStandings(t *testing.T) {
+	list := []Standing{
+		{Candidate: Candidate{StudentId: "d", GPA: 3.5, Academic: 2}, Composite: 80},
+		{Candidate: Candidate{StudentId: "c", GPA: 3.5, Academic: 2}, Composite: 80},
+		{Candidate: Candidate{StudentId: "a", GPA: 3.9}, Composite: 90},
+		{Candidate: Candidate{StudentId: "b", GPA: 3.6, Academic: 1}, Composite: 80},
+		{Candidate: Candidate{StudentId: "e", GPA: 3.5, Academic: 1}, Composite: 80},
+		{Candidate: Candidate{StudentId: "f", GPA: 3}, Composite: 70},
+	}
+	RankStandings(list)
+
+	want := []struct {
+		id   string
+		rank int
+		tied bool
+	}{
+		{"a", 1, false},
+		// 综合成绩相同时绩点高者在前
+		{"b", 2, false},
+		// 绩点也相同时学术专长成绩高者在前，仍相同则并列并按学号排序
+		{"c", 3, true},
+		{"d", 3, true},
+		{"e", 5, false},
+		{"f", 6, false},
+	}
+	for i, w := range want {
+		if got := list[i]; got.StudentId != w.id || got.Rank != w.rank || got.Tied != w.tied {
+			t.Errorf("第 %d 位 = %s 名次 %d 并列 %v，want %s 名次 %d 并列 %v", i+1, got.StudentId, got.Rank, got.Tied, w.id, w.rank, w.tied)
+		}
+	}
+
+	// 输入顺序不同时结果一致，重复排名会清除之前的并列标记
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	list[0].Tied = true
+	RankStandings(list)
+	for i, w := range want {
+		if got := list[i]; got.StudentId != w.id || got.Rank != w.rank || got.Tied != w.tied {
+			t.Errorf("重排后第 %d 位 = %s 名次 %d 并列 %v", i+1, got.StudentId, got.Rank, got.Tied)
+		}
+	}
+}
+
+func TestRankStandingsAllTied(t *testing.T) {
+	list := []Standing{
+		{Candidate: Candidate{StudentId: "3"}, Composite: 60},
+		{Candidate: Candidate{StudentId: "1"}, Composite: 60},
+		{Candidate: Candidate{StudentId: "2"}, Composite: 60},
+	}
+	RankStandings(list)
+	for i, s := range list {
+		if s.Rank != 1 || !s.Tied || s.StudentId != string(rune('1'+i)) {
+			t.Errorf("第 %d 位 = %+v, want 学号 %d 并列第 1", i+1, s, i+1)
+		}
+	}
+
+	single := []Standing{{Candidate: Candidate{StudentId: "x"}, Composite: 50, Tied: true}}
+	RankStandings(single)
+	if single[0].Rank != 1 || single[0].Tied {
+		t.Errorf("单人排名 = %+v", single[0])
+	}
+}
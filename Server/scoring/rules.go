@@ -0,0 +1,194 @@
+package scoring
+
+// Rules 保研条例中的加分表，数值均为条例原文中的分值
+// 以结构体保存而不是写死在计算逻辑中，便于不同届别使用不同的条例版本
+type Rules struct {
+	// GPAScale 满绩点，学业综合成绩（百分制）= 推免绩点 / GPAScale × 100
+	GPAScale float64 `json:"gpaScale"`
+	// AcademicWeight 学业综合成绩计入推免综合成绩的比例
+	AcademicWeight float64 `json:"academicWeight"`
+	// GradePoints 百分制成绩对应的学分绩点，按分数从高到低排列
+	GradePoints []GradePoint `json:"gradePoints"`
+	// RetakenScore 重修通过的课程在成绩排名计算中按该分数（百分制）计
+	RetakenScore float64 `json:"retakenScore"`
+	// RetakeLimit 曾重修通过的课程达到该门次时不具备推免资格
+	RetakeLimit int `json:"retakeLimit"`
+	// RetakeExempt 不计入重修门次的课程名称关键字（游泳）
+	RetakeExempt []string `json:"retakeExempt"`
+
+	// PaperPoints 论文类别对应的每篇分值，top 为 Nature/Science/Cell 主刊及子刊
+	PaperPoints map[string]float64 `json:"paperPoints"`
+	// PaperShares 除导师外按作者排序计分的比例
+	PaperShares AuthorShares `json:"paperShares"`
+	// PatentPoints 国家发明专利授权的每项分值
+	PatentPoints float64 `json:"patentPoints"`
+	// PatentShares 专利只认除导师外的第一作者
+	PatentShares AuthorShares `json:"patentShares"`
+	// PaperCMaxItems C 类论文累计计分的篇数上限
+	PaperCMaxItems int `json:"paperCMaxItems"`
+
+	// CompetitionPoints 竞赛类别 -> 级别 -> 奖项 -> 团体项目分值
+	CompetitionPoints map[string]map[string]map[string]float64 `json:"competitionPoints"`
+	// RankedCompetitions 按队内排序计分的竞赛名称关键字（中国国际大学生创新大赛、“挑战杯”）
+	RankedCompetitions []string `json:"rankedCompetitions"`
+	// RankedShares 上述竞赛队内排序 1、2-3、4-5 名的计分比例
+	RankedShares []RankShare `json:"rankedShares"`
+	// CSPThresholds CCF CSP 认证排名百分比对应的全国奖项，按百分比从小到大排列
+	CSPThresholds []PercentileAward `json:"cspThresholds"`
+	// CompetitionMaxItems 每个学生计分的竞赛项数上限，ExternalCompetitionMaxItems 为其中非信息学院竞赛的上限
+	CompetitionMaxItems         int `json:"competitionMaxItems"`
+	ExternalCompetitionMaxItems int `json:"externalCompetitionMaxItems"`
+	// SameYearCompetitions 同一年度只计最高分的竞赛名称关键字（ICPC、CCPC）
+	SameYearCompetitions []string `json:"sameYearCompetitions"`
+
+	// InnovationPoints 创新创业训练项目级别 -> 角色 -> 分值
+	InnovationPoints map[string]map[string]float64 `json:"innovationPoints"`
+	InnovationCap    float64                       `json:"innovationCap"`
+
+	// InternshipPoints 国际组织实习满一学年的分值，超过一学期不满一年减半
+	InternshipPoints float64 `json:"internshipPoints"`
+	InternshipCap    float64 `json:"internshipCap"`
+	// MilitaryPoints 服兵役满 1 年、满 2 年的分值
+	MilitaryPoints []YearsPoints `json:"militaryPoints"`
+	MilitaryCap    float64       `json:"militaryCap"`
+
+	// VolunteerBaseHours 志愿服务开始加分的累计工时
+	VolunteerBaseHours float64 `json:"volunteerBaseHours"`
+	// VolunteerStepHours 达到基础工时后每增加多少小时加 VolunteerStepPoints 分
+	VolunteerStepHours  float64 `json:"volunteerStepHours"`
+	VolunteerStepPoints float64 `json:"volunteerStepPoints"`
+	// VolunteerCap 志愿工时与志愿表彰合计的上限
+	VolunteerCap float64 `json:"volunteerCap"`
+	// VolunteerHonourPoints 志愿服务表彰级别 -> 角色（leader/member/individual）-> 分值
+	VolunteerHonourPoints map[string]map[string]float64 `json:"volunteerHonourPoints"`
+
+	// HonourPoints 荣誉称号级别对应的每项分值，集体荣誉减半
+	HonourPoints map[string]float64 `json:"honourPoints"`
+	HonourCap    float64            `json:"honourCap"`
+
+	// CadreCoefficients 学生干部职务对应的系数，得分 = 系数 × 辅导员打分 / 100
+	CadreCoefficients map[string]float64 `json:"cadreCoefficients"`
+	CadreCap          float64            `json:"cadreCap"`
+
+	// SportsPoints 体育比赛级别 -> 名次 -> 团体项目分值
+	SportsPoints map[string]map[string]float64 `json:"sportsPoints"`
+}
+
+// GradePoint 百分制成绩不低于 MinScore 时的学分绩点
+type GradePoint struct {
+	MinScore float64 `json:"minScore"`
+	Point    float64 `json:"point"`
+}
+
+// AuthorShares 论文与专利按作者身份计分的比例
+type AuthorShares struct {
+	Sole    float64 `json:"sole"`
+	First   float64 `json:"first"`
+	Second  float64 `json:"second"`
+	CoFirst float64 `json:"coFirst"`
+}
+
+// RankShare 队内排序不超过 MaxRank 时的计分比例
+type RankShare struct {
+	MaxRank int     `json:"maxRank"`
+	Share   float64 `json:"share"`
+}
+
+// PercentileAward 排名前 Percent% 等同的奖项
+type PercentileAward struct {
+	Percent float64 `json:"percent"`
+	Award   string  `json:"award"`
+}
+
+// YearsPoints 满 Years 年的分值
+type YearsPoints struct {
+	Years  float64 `json:"years"`
+	Points float64 `json:"points"`
+}
+
+// DefaultRules 返回 2025 年 2 月修订的保研条例（信院〔2025〕3 号）中的加分表
+func DefaultRules() *Rules {
+	return &Rules{
+		GPAScale:       4,
+		AcademicWeight: 0.8,
+		GradePoints:    defaultGradePoints(),
+		RetakenScore:   60,
+		RetakeLimit:    3,
+		RetakeExempt:   []string{"游泳"},
+
+		PaperPoints:    map[string]float64{ClassTop: 20, ClassA: 10, ClassB: 6, ClassC: 1},
+		PaperShares:    AuthorShares{Sole: 1, First: 0.8, Second: 0.2, CoFirst: 0.5},
+		PatentPoints:   2,
+		PatentShares:   AuthorShares{Sole: 1, First: 0.8},
+		PaperCMaxItems: 2,
+
+		CompetitionPoints: map[string]map[string]map[string]float64{
+			ClassAPlus: {
+				LevelNational:   {AwardFirst: 30, AwardSecond: 15, AwardThird: 10},
+				LevelProvincial: {AwardFirst: 5, AwardSecond: 2},
+			},
+			ClassA: {
+				LevelNational:   {AwardFirst: 15, AwardSecond: 10, AwardThird: 5},
+				LevelProvincial: {AwardFirst: 2, AwardSecond: 1},
+			},
+			ClassAMinus: {
+				LevelNational:   {AwardFirst: 10, AwardSecond: 5, AwardThird: 2},
+				LevelProvincial: {AwardFirst: 1, AwardSecond: 0.5},
+			},
+		},
+		RankedCompetitions:          []string{"中国国际大学生创新大赛", "挑战杯"},
+		RankedShares:                []RankShare{{MaxRank: 1, Share: 1.0 / 3}, {MaxRank: 3, Share: 1.0 / 4}, {MaxRank: 5, Share: 1.0 / 5}},
+		CSPThresholds:               []PercentileAward{{Percent: 0.2, Award: AwardFirst}, {Percent: 1.5, Award: AwardSecond}, {Percent: 3, Award: AwardThird}},
+		CompetitionMaxItems:         3,
+		ExternalCompetitionMaxItems: 1,
+		SameYearCompetitions:        []string{"ICPC", "CCPC"},
+
+		InnovationPoints: map[string]map[string]float64{
+			LevelNational:   {RoleLeader: 1, RoleMember: 0.3},
+			LevelProvincial: {RoleLeader: 0.5, RoleMember: 0.2},
+			LevelSchool:     {RoleLeader: 0.1, RoleMember: 0.05},
+		},
+		InnovationCap: 2,
+
+		InternshipPoints: 1,
+		InternshipCap:    1,
+		MilitaryPoints:   []YearsPoints{{Years: 2, Points: 2}, {Years: 1, Points: 1}},
+		MilitaryCap:      2,
+
+		VolunteerBaseHours:  200,
+		VolunteerStepHours:  2,
+		VolunteerStepPoints: 0.05,
+		VolunteerCap:        1,
+		VolunteerHonourPoints: map[string]map[string]float64{
+			LevelNational:   {RoleLeader: 1, RoleMember: 0.5, RoleIndividual: 1},
+			LevelProvincial: {RoleLeader: 0.5, RoleMember: 0.25, RoleIndividual: 0.5},
+			LevelSchool:     {RoleLeader: 0.25, RoleMember: 0.1, RoleIndividual: 0.25},
+		},
+
+		HonourPoints: map[string]float64{LevelNational: 2, LevelProvincial: 1, LevelSchool: 0.2},
+		HonourCap:    2,
+
+		CadreCoefficients: map[string]float64{
+			PostExecutiveChair: 2,
+			PostPresidium:      1.5,
+			PostMinister:       1,
+			PostViceMinister:   0.75,
+			PostCommittee:      0.5,
+		},
+		CadreCap: 2,
+
+		SportsPoints: map[string]map[string]float64{
+			LevelInternational: {PlaceChampion: 8, PlaceRunnerUp: 6.5, PlaceThird: 5, PlaceTop8: 3.5},
+			LevelNational:      {PlaceChampion: 5, PlaceRunnerUp: 3.5, PlaceThird: 2, PlaceTop8: 1},
+		},
+	}
+}
+
+// defaultGradePoints 《厦门大学本科课程学分绩点计算办法》中百分制成绩与绩点的对应关系
+func defaultGradePoints() []GradePoint {
+	return []GradePoint{
+		{MinScore: 90, Point: 4}, {MinScore: 85, Point: 3.7}, {MinScore: 81, Point: 3.3}, {MinScore: 78, Point: 3},
+		{MinScore: 75, Point: 2.7}, {MinScore: 72, Point: 2.3}, {MinScore: 68, Point: 2}, {MinScore: 64, Point: 1.5},
+		{MinScore: 60, Point: 1},
+	}
+}
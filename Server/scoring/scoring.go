@@ -0,0 +1,581 @@
+// Package scoring 按保研条例计算单项材料的学院核定加分
+// 大模型只负责从材料中提取 Facts，分值全部由 Rules 中的加分表确定，相同的 Facts 总是得到相同的结果
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kind 加分项目的种类
+type Kind string
+
+const (
+	KindPaper           Kind = "paper"
+	KindPatent          Kind = "patent"
+	KindCompetition     Kind = "competition"
+	KindInnovation      Kind = "innovation"
+	KindInternship      Kind = "internship"
+	KindMilitary        Kind = "military"
+	KindVolunteerHours  Kind = "volunteer_hours"
+	KindVolunteerHonour Kind = "volunteer_honour"
+	KindHonour          Kind = "honour"
+	KindCadre           Kind = "cadre"
+	KindSports          Kind = "sports"
+)
+
+var kindLabels = map[Kind]string{
+	KindPaper:           "学术论文",
+	KindPatent:          "发明专利",
+	KindCompetition:     "学科竞赛",
+	KindInnovation:      "创新创业训练",
+	KindInternship:      "实习实践",
+	KindMilitary:        "参军入伍",
+	KindVolunteerHours:  "志愿服务时长",
+	KindVolunteerHonour: "志愿服务表彰",
+	KindHonour:          "荣誉称号",
+	KindCadre:           "学生干部",
+	KindSports:          "体育比赛",
+}
+
+// Label 种类的中文名称，未识别种类时返回「其他」
+func (k Kind) Label() string {
+	if label, ok := kindLabels[k]; ok {
+		return label
+	}
+	return "其他"
+}
+
+// Section 加分所属的考核综合成绩区块，与 material_records.category 一致
+const (
+	SectionAcademic      = "academic"
+	SectionComprehensive = "comprehensive"
+)
+
+// 论文与竞赛类别
+const (
+	ClassTop    = "top"
+	ClassAPlus  = "A+"
+	ClassA      = "A"
+	ClassAMinus = "A-"
+	ClassB      = "B"
+	ClassC      = "C"
+)
+
+// 级别
+const (
+	LevelInternational = "international"
+	LevelNational      = "national"
+	LevelProvincial    = "provincial"
+	LevelSchool        = "school"
+)
+
+// 竞赛奖项，first 含一等奖及以上
+const (
+	AwardFirst  = "first"
+	AwardSecond = "second"
+	AwardThird  = "third"
+)
+
+// 体育比赛名次，top8 为第四至八名
+const (
+	PlaceChampion = "champion"
+	PlaceRunnerUp = "runner_up"
+	PlaceThird    = "third_place"
+	PlaceTop8     = "top8"
+)
+
+// 创新项目与志愿服务表彰中的角色
+const (
+	RoleLeader     = "leader"
+	RoleMember     = "member"
+	RoleIndividual = "individual"
+)
+
+// 任职或实习时长
+const (
+	// TenureFullYear 满一学年
+	TenureFullYear = "full_year"
+	// TenureOverSemester 超过一学期但不满一年，按一学年标准减半
+	TenureOverSemester = "over_semester"
+	// TenureUnderSemester 不满一学期，不加分
+	TenureUnderSemester = "under_semester"
+)
+
+// 学生干部职务，对应条例社会工作表中的五档系数
+const (
+	// PostExecutiveChair 院学生会执行主席、团总支书记
+	PostExecutiveChair = "executive_chair"
+	// PostPresidium 院学生会主席团成员、团总支副书记
+	PostPresidium = "presidium"
+	// PostMinister 院学生会、团总支各部部长，党支部书记，班长、团支部书记
+	PostMinister = "minister"
+	// PostViceMinister 系团总支书记，院学生会、团总支各部门副部长，社团社长
+	PostViceMinister = "vice_minister"
+	// PostCommittee 党支部委员、系团总支各部部长、班委、团支部委员、长期志愿者、社团副社长及主要干部、队长等
+	PostCommittee = "committee"
+)
+
+// ErrInvalidFacts 提取的要素不完整或取值不在条例范围内，无法计分
+var ErrInvalidFacts = errors.New("加分要素无效")
+
+// Facts 从材料中提取的计分要素，只描述事实，不包含分值
+// 未用到的字段保持零值
+type Facts struct {
+	Kind Kind `json:"kind"`
+	// Name 论文题目、竞赛名称、项目名称、荣誉或职务名称等
+	Name string `json:"name,omitempty"`
+	// Class 论文：top/A/B/C；竞赛：A+/A/A-
+	Class string `json:"class,omitempty"`
+	// Level international/national/provincial/school
+	Level string `json:"level,omitempty"`
+	// Award 竞赛奖项 first/second/third；体育比赛名次 champion/runner_up/third_place/top8
+	Award string `json:"award,omitempty"`
+	// Percentile CCF CSP 认证的排名百分比，大于 0 时按 CSPThresholds 换算奖项
+	Percentile float64 `json:"percentile,omitempty"`
+	// Work 参赛作品名称，同一作品参加多项竞赛只计最高分
+	Work string `json:"work,omitempty"`
+	// External 是否为非信息学院的竞赛项目
+	External bool `json:"external,omitempty"`
+
+	// Venue 论文发表的期刊或会议名称，须在学术会议和期刊目录中
+	Venue string `json:"venue,omitempty"`
+	// FirstAffiliation 厦门大学是否为第一单位（论文、专利）
+	FirstAffiliation bool `json:"firstAffiliation,omitempty"`
+	// AuthorRank 除导师外的作者排序，从 1 开始
+	AuthorRank int `json:"authorRank,omitempty"`
+	// CoFirst 是否为共同第一作者
+	CoFirst bool `json:"coFirst,omitempty"`
+	// SoleAuthor 除导师外是否为独立作者
+	SoleAuthor bool `json:"soleAuthor,omitempty"`
+	// Granted 专利是否已获授权
+	Granted bool `json:"granted,omitempty"`
+
+	// TeamSize 参赛或获奖人数，1 表示个人项目
+	TeamSize int `json:"teamSize,omitempty"`
+	// TeamRank 队内排序，1 为队长
+	TeamRank int `json:"teamRank,omitempty"`
+	// Role leader/member/individual
+	Role string `json:"role,omitempty"`
+	// Concluded 创新训练项目是否已结题
+	Concluded bool `json:"concluded,omitempty"`
+
+	// Tenure 任职或实习时长 full_year/over_semester/under_semester
+	Tenure string `json:"tenure,omitempty"`
+	// Years 服兵役年数
+	Years float64 `json:"years,omitempty"`
+	// Hours 普通志愿服务工时
+	Hours float64 `json:"hours,omitempty"`
+	// HalvedHours 大型赛会志愿者与支教工时，按一半计入
+	HalvedHours float64 `json:"halvedHours,omitempty"`
+
+	// Collective 是否为集体荣誉称号
+	Collective bool `json:"collective,omitempty"`
+	// Post 学生干部职务档位
+	Post string `json:"post,omitempty"`
+	// CounsellorScore 辅导员或指导老师对任职学年的打分（0-100）
+	CounsellorScore float64 `json:"counsellorScore,omitempty"`
+	// AcademicYear 获奖或任职学年，如 2024-2025
+	AcademicYear string `json:"academicYear,omitempty"`
+}
+
+// Result 一项材料的计分结果
+type Result struct {
+	// Section academic 或 comprehensive
+	Section string  `json:"section"`
+	Points  float64 `json:"points"`
+	// Basis 可读的计分依据，写入加分登记表的“加分依据”列
+	Basis string `json:"basis"`
+}
+
+// Section 返回要素所属的区块，种类未知时返回空字符串
+func (f Facts) Section() string {
+	switch f.Kind {
+	case KindPaper, KindPatent, KindCompetition, KindInnovation:
+		return SectionAcademic
+	case KindInternship, KindMilitary, KindVolunteerHours, KindVolunteerHonour, KindHonour, KindCadre, KindSports:
+		return SectionComprehensive
+	}
+	return ""
+}
+
+// Score 按加分表计算一项材料的分值
+// 要素齐全但不满足加分条件（如非第一单位、未结题）时得 0 分并在 Basis 中说明；要素无效时返回 ErrInvalidFacts
+// 同类项目的累计上限与取最高规则不在此处理，由汇总时统一计算
+func (r *Rules) Score(f Facts) (Result, error) {
+	var points float64
+	var basis string
+	var err error
+	switch f.Kind {
+	case KindPaper:
+		points, basis, err = r.scorePaper(f)
+	case KindPatent:
+		points, basis, err = r.scorePatent(f)
+	case KindCompetition:
+		points, basis, err = r.scoreCompetition(f)
+	case KindInnovation:
+		points, basis, err = r.scoreInnovation(f)
+	case KindInternship:
+		points, basis, err = r.scoreInternship(f)
+	case KindMilitary:
+		points, basis = r.scoreMilitary(f)
+	case KindVolunteerHours:
+		points, basis, err = r.scoreVolunteerHours(f)
+	case KindVolunteerHonour:
+		points, basis, err = r.scoreVolunteerHonour(f)
+	case KindHonour:
+		points, basis, err = r.scoreHonour(f)
+	case KindCadre:
+		points, basis, err = r.scoreCadre(f)
+	case KindSports:
+		points, basis, err = r.scoreSports(f)
+	default:
+		err = invalid("未知的加分种类 %q", f.Kind)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Section: f.Section(), Points: Round(points), Basis: basis}, nil
+}
+
+// Round 分值保留 4 位小数
+func Round(x float64) float64 {
+	return math.Round(x*1e4) / 1e4
+}
+
+// Format 去掉多余小数位的分值文本
+func Format(x float64) string {
+	return strconv.FormatFloat(Round(x), 'f', -1, 64)
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFacts, fmt.Sprintf(format, args...))
+}
+
+var levelNames = map[string]string{
+	LevelInternational: "国际级",
+	LevelNational:      "国家级",
+	LevelProvincial:    "省级",
+	LevelSchool:        "校级",
+}
+
+var awardNames = map[string]string{
+	AwardFirst:    "一等奖及以上",
+	AwardSecond:   "二等奖",
+	AwardThird:    "三等奖",
+	PlaceChampion: "冠军",
+	PlaceRunnerUp: "亚军",
+	PlaceThird:    "季军",
+	PlaceTop8:     "第四至八名",
+}
+
+var roleNames = map[string]string{
+	RoleLeader:     "组长/队长",
+	RoleMember:     "成员",
+	RoleIndividual: "个人",
+}
+
+// authorShare 按作者身份返回计分比例与说明，不计分时比例为 0
+func authorShare(s AuthorShares, f Facts) (float64, string) {
+	switch {
+	case f.SoleAuthor:
+		return s.Sole, "独立作者"
+	case f.CoFirst && (f.AuthorRank == 1 || f.AuthorRank == 2) && s.CoFirst > 0:
+		return s.CoFirst, "共同第一作者"
+	case f.AuthorRank == 1:
+		return s.First, "除导师外第一作者"
+	case f.AuthorRank == 2 && s.Second > 0:
+		return s.Second, "除导师外第二作者"
+	}
+	return 0, fmt.Sprintf("除导师外第 %d 作者", f.AuthorRank)
+}
+
+func percent(share float64) string {
+	return Format(share*100) + "%"
+}
+
+func (r *Rules) scorePaper(f Facts) (float64, string, error) {
+	base, ok := r.PaperPoints[f.Class]
+	if !ok {
+		return 0, "", invalid("未知的论文类别 %q", f.Class)
+	}
+	if f.AuthorRank <= 0 && !f.SoleAuthor {
+		return 0, "", invalid("缺少作者排序")
+	}
+	label := f.Class + " 类论文"
+	if f.Class == ClassTop {
+		label = "Nature/Science/Cell 论文（等价两篇 A 类）"
+	}
+	if !f.FirstAffiliation {
+		return 0, label + "，厦门大学非第一单位，不加分", nil
+	}
+	share, who := authorShare(r.PaperShares, f)
+	if share == 0 {
+		return 0, fmt.Sprintf("%s，%s，只加除导师外前 2 名作者，不加分", label, who), nil
+	}
+	points := base * share
+	return points, fmt.Sprintf("%s %s 分 × %s %s = %s 分", label, Format(base), who, percent(share), Format(points)), nil
+}
+
+func (r *Rules) scorePatent(f Facts) (float64, string, error) {
+	if f.AuthorRank <= 0 && !f.SoleAuthor {
+		return 0, "", invalid("缺少发明人排序")
+	}
+	if !f.Granted {
+		return 0, "国家发明专利尚未授权，不加分", nil
+	}
+	if !f.FirstAffiliation {
+		return 0, "国家发明专利，厦门大学非第一单位，不加分", nil
+	}
+	share, who := authorShare(r.PatentShares, f)
+	if share == 0 {
+		return 0, fmt.Sprintf("国家发明专利，%s，只认除导师外第一作者，不加分", who), nil
+	}
+	points := r.PatentPoints * share
+	return points, fmt.Sprintf("国家发明专利授权 %s 分 × %s %s = %s 分", Format(r.PatentPoints), who, percent(share), Format(points)), nil
+}
+
+// cspAward 按排名百分比换算 CCF CSP 认证的全国奖项，未达到最低档时返回空字符串
+func (r *Rules) cspAward(percentile float64) string {
+	for _, t := range r.CSPThresholds {
+		if percentile <= t.Percent {
+			return t.Award
+		}
+	}
+	return ""
+}
+
+// ranked 竞赛是否按队内排序计分
+func (r *Rules) ranked(name string) bool {
+	for _, keyword := range r.RankedCompetitions {
+		if keyword != "" && strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// competitionShare 返回团体项目分值的计分比例与说明
+func (r *Rules) competitionShare(f Facts) (float64, string, error) {
+	switch {
+	case f.TeamSize <= 0:
+		return 0, "", invalid("缺少参赛人数")
+	case f.TeamSize == 1:
+		return 1.0 / 3, "个人项目按 1/3", nil
+	case r.ranked(f.Name):
+		if f.TeamRank <= 0 {
+			return 0, "", invalid("缺少队内排序")
+		}
+		for _, s := range r.RankedShares {
+			if f.TeamRank <= s.MaxRank {
+				return s.Share, fmt.Sprintf("队内排序第 %d 名按 %s", f.TeamRank, fraction(s.Share)), nil
+			}
+		}
+		return 0, fmt.Sprintf("队内排序第 %d 名，只计前 %d 名", f.TeamRank, r.RankedShares[len(r.RankedShares)-1].MaxRank), nil
+	case f.TeamSize == 2:
+		return 1.0 / 3, "2 人团队按 1/3", nil
+	case f.TeamSize <= 5:
+		return 1 / float64(f.TeamSize), fmt.Sprintf("%d 人团队平均 1/%d", f.TeamSize, f.TeamSize), nil
+	}
+	return 1.0 / 5, fmt.Sprintf("%d 人团队只取前 5 名核心成员按 1/5", f.TeamSize), nil
+}
+
+// fraction 将 1/n 形式的比例写成分数
+func fraction(share float64) string {
+	if share > 0 {
+		if n := math.Round(1 / share); math.Abs(1/n-share) < 1e-9 {
+			return "1/" + strconv.Itoa(int(n))
+		}
+	}
+	return percent(share)
+}
+
+func (r *Rules) scoreCompetition(f Facts) (float64, string, error) {
+	award := f.Award
+	if f.Percentile > 0 {
+		award = r.cspAward(f.Percentile)
+		if award == "" {
+			return 0, fmt.Sprintf("CCF CSP 认证排名前 %s%%，未达到全国三等奖标准，不加分", Format(f.Percentile)), nil
+		}
+		f.Class, f.Level, f.TeamSize = ClassAMinus, LevelNational, 1
+	}
+	levels, ok := r.CompetitionPoints[f.Class]
+	if !ok {
+		return 0, "", invalid("未知的竞赛类别 %q", f.Class)
+	}
+	awards, ok := levels[f.Level]
+	if !ok {
+		return 0, "", invalid("%s 类竞赛没有%s奖项", f.Class, levelName(f.Level))
+	}
+	base, ok := awards[award]
+	if !ok {
+		return 0, "", invalid("%s 类竞赛%s没有奖项 %q", f.Class, levelName(f.Level), award)
+	}
+	share, how, err := r.competitionShare(f)
+	if err != nil {
+		return 0, "", err
+	}
+	label := fmt.Sprintf("%s 类竞赛%s%s", f.Class, levelName(f.Level), awardNames[award])
+	if f.Percentile > 0 {
+		label = fmt.Sprintf("CCF CSP 认证排名前 %s%%，按 A- 类国家级%s", Format(f.Percentile), awardNames[award])
+	}
+	points := base * share
+	if share == 0 {
+		return 0, fmt.Sprintf("%s，%s，不加分", label, how), nil
+	}
+	return points, fmt.Sprintf("%s %s 分 × %s = %s 分", label, Format(base), how, Format(points)), nil
+}
+
+func levelName(level string) string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return level
+}
+
+func (r *Rules) scoreInnovation(f Facts) (float64, string, error) {
+	roles, ok := r.InnovationPoints[f.Level]
+	if !ok {
+		return 0, "", invalid("未知的立项级别 %q", f.Level)
+	}
+	points, ok := roles[f.Role]
+	if !ok {
+		return 0, "", invalid("未知的项目角色 %q", f.Role)
+	}
+	label := fmt.Sprintf("%s创新训练项目%s", levelName(f.Level), roleNames[f.Role])
+	if !f.Concluded {
+		return 0, label + "，项目未结题，不加分", nil
+	}
+	points = math.Min(points, r.InnovationCap)
+	return points, fmt.Sprintf("%s %s 分", label, Format(points)), nil
+}
+
+// tenureShare 任期或实习时长对应的比例
+func tenureShare(tenure string) (float64, string, error) {
+	switch tenure {
+	case TenureFullYear:
+		return 1, "满一学年", nil
+	case TenureOverSemester:
+		return 0.5, "超过一学期不满一年减半", nil
+	case TenureUnderSemester:
+		return 0, "不满一学期", nil
+	}
+	return 0, "", invalid("未知的时长 %q", tenure)
+}
+
+func (r *Rules) scoreInternship(f Facts) (float64, string, error) {
+	share, how, err := tenureShare(f.Tenure)
+	if err != nil {
+		return 0, "", err
+	}
+	if share == 0 {
+		return 0, "国际组织实习" + how + "，不加分", nil
+	}
+	points := r.InternshipPoints * share
+	return points, fmt.Sprintf("国际组织实习%s %s 分", how, Format(points)), nil
+}
+
+func (r *Rules) scoreMilitary(f Facts) (float64, string) {
+	for _, y := range r.MilitaryPoints {
+		if f.Years >= y.Years {
+			return y.Points, fmt.Sprintf("服兵役 %s 年（满 %s 年）%s 分", Format(f.Years), Format(y.Years), Format(y.Points))
+		}
+	}
+	return 0, fmt.Sprintf("服兵役 %s 年，不满加分年限", Format(f.Years))
+}
+
+func (r *Rules) scoreVolunteerHours(f Facts) (float64, string, error) {
+	if f.Hours < 0 || f.HalvedHours < 0 {
+		return 0, "", invalid("志愿工时不能为负数")
+	}
+	total := f.Hours + f.HalvedHours/2
+	counted := fmt.Sprintf("累计志愿工时 %s 小时", Format(total))
+	if f.HalvedHours > 0 {
+		counted = fmt.Sprintf("累计志愿工时 %s + %s/2 = %s 小时", Format(f.Hours), Format(f.HalvedHours), Format(total))
+	}
+	if total < r.VolunteerBaseHours {
+		return 0, fmt.Sprintf("%s，未达到 %s 小时，不加分", counted, Format(r.VolunteerBaseHours)), nil
+	}
+	steps := math.Floor((total - r.VolunteerBaseHours) / r.VolunteerStepHours)
+	points := math.Min(steps*r.VolunteerStepPoints, r.VolunteerCap)
+	return points, fmt.Sprintf("%s，超出 %s 小时部分每 %s 小时 %s 分，计 %s 分（该项最多 %s 分）", counted,
+		Format(r.VolunteerBaseHours), Format(r.VolunteerStepHours), Format(r.VolunteerStepPoints), Format(points), Format(r.VolunteerCap)), nil
+}
+
+func (r *Rules) scoreVolunteerHonour(f Facts) (float64, string, error) {
+	roles, ok := r.VolunteerHonourPoints[f.Level]
+	if !ok {
+		return 0, "", invalid("未知的表彰级别 %q", f.Level)
+	}
+	role := f.Role
+	if role == "" && f.TeamSize == 1 {
+		role = RoleIndividual
+	}
+	points, ok := roles[role]
+	if !ok {
+		return 0, "", invalid("未知的表彰角色 %q", f.Role)
+	}
+	points = math.Min(points, r.VolunteerCap)
+	return points, fmt.Sprintf("%s志愿服务表彰（%s）%s 分", levelName(f.Level), roleNames[role], Format(points)), nil
+}
+
+func (r *Rules) scoreHonour(f Facts) (float64, string, error) {
+	base, ok := r.HonourPoints[f.Level]
+	if !ok {
+		return 0, "", invalid("未知的荣誉级别 %q", f.Level)
+	}
+	if f.Collective {
+		points := base / 2
+		return points, fmt.Sprintf("%s集体荣誉称号 %s 分 × 50%% = %s 分", levelName(f.Level), Format(base), Format(points)), nil
+	}
+	return base, fmt.Sprintf("%s荣誉称号 %s 分", levelName(f.Level), Format(base)), nil
+}
+
+func (r *Rules) scoreCadre(f Facts) (float64, string, error) {
+	coefficient, ok := r.CadreCoefficients[f.Post]
+	if !ok {
+		return 0, "", invalid("未知的学生干部职务档位 %q", f.Post)
+	}
+	if f.CounsellorScore < 0 || f.CounsellorScore > 100 {
+		return 0, "", invalid("辅导员打分 %s 不在 0-100 之间", Format(f.CounsellorScore))
+	}
+	share, how, err := tenureShare(f.Tenure)
+	if err != nil {
+		return 0, "", err
+	}
+	if share == 0 {
+		return 0, "学生干部任职" + how + "，不加分", nil
+	}
+	points := math.Min(coefficient*f.CounsellorScore/100*share, r.CadreCap)
+	basis := fmt.Sprintf("学生干部系数 %s × 辅导员打分 %s / 100", Format(coefficient), Format(f.CounsellorScore))
+	if share != 1 {
+		basis += fmt.Sprintf(" × 50%%（%s）", how)
+	}
+	return points, fmt.Sprintf("%s = %s 分", basis, Format(points)), nil
+}
+
+func (r *Rules) scoreSports(f Facts) (float64, string, error) {
+	places, ok := r.SportsPoints[f.Level]
+	if !ok {
+		return 0, "", invalid("体育比赛没有%s奖项", levelName(f.Level))
+	}
+	base, ok := places[f.Award]
+	if !ok {
+		return 0, "", invalid("未知的体育比赛名次 %q", f.Award)
+	}
+	var share float64
+	var how string
+	switch {
+	case f.TeamSize <= 0:
+		return 0, "", invalid("缺少参赛人数")
+	case f.TeamSize <= 2:
+		share, how = 1.0/3, "个人或二人项目按 1/3"
+	default:
+		share, how = 1/float64(f.TeamSize), fmt.Sprintf("%d 人团体项目平均 1/%d", f.TeamSize, f.TeamSize)
+	}
+	points := base * share
+	return points, fmt.Sprintf("%s体育比赛%s %s 分 × %s = %s 分", levelName(f.Level), awardNames[f.Award], Format(base), how, Format(points)), nil
+}
@@ -0,0 +1,238 @@
+package scoring
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type scoreCase struct {
+	name  string
+	facts Facts
+	want  float64
+	// basis 为计分依据中应包含的文字，为空时不检查
+	basis string
+}
+
+func runScoreCases(t *testing.T, cases []scoreCase) {
+	t.Helper()
+	r := DefaultRules()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := r.Score(c.facts)
+			if err != nil {
+				t.Fatalf("Score(%+v): %v", c.facts, err)
+			}
+			if got.Points != c.want {
+				t.Errorf("Points = %v, want %v (basis %q)", got.Points, c.want, got.Basis)
+			}
+			if got.Section != c.facts.Section() || got.Section == "" {
+				t.Errorf("Section = %q", got.Section)
+			}
+			if c.basis != "" && !strings.Contains(got.Basis, c.basis) {
+				t.Errorf("Basis = %q, want containing %q", got.Basis, c.basis)
+			}
+		})
+	}
+}
+
+func TestScorePaper(t *testing.T) {
+	paper := func(class string, rank int) Facts {
+		return Facts{Kind: KindPaper, Class: class, AuthorRank: rank, FirstAffiliation: true}
+	}
+	sole := paper(ClassA, 1)
+	sole.SoleAuthor = true
+	coFirst := paper(ClassB, 2)
+	coFirst.CoFirst = true
+	otherAffiliation := paper(ClassA, 1)
+	otherAffiliation.FirstAffiliation = false
+
+	runScoreCases(t, []scoreCase{
+		{"top 第一作者", paper(ClassTop, 1), 16, "等价两篇 A 类"},
+		{"A 类第一作者", paper(ClassA, 1), 8, "A 类论文 10 分 × 除导师外第一作者 80% = 8 分"},
+		{"A 类第二作者", paper(ClassA, 2), 2, "20%"},
+		{"A 类独立作者", sole, 10, "独立作者 100%"},
+		{"A 类第三作者", paper(ClassA, 3), 0, "只加除导师外前 2 名作者"},
+		{"B 类第一作者", paper(ClassB, 1), 4.8, ""},
+		{"B 类第二作者", paper(ClassB, 2), 1.2, ""},
+		{"B 类共同第一作者", coFirst, 3, "共同第一作者 50%"},
+		{"C 类第一作者", paper(ClassC, 1), 0.8, ""},
+		{"C 类第二作者", paper(ClassC, 2), 0.2, ""},
+		{"非第一单位", otherAffiliation, 0, "非第一单位"},
+	})
+}
+
+func TestScorePatent(t *testing.T) {
+	patent := func(rank int) Facts {
+		return Facts{Kind: KindPatent, AuthorRank: rank, Granted: true, FirstAffiliation: true}
+	}
+	sole := patent(1)
+	sole.SoleAuthor = true
+	pending := patent(1)
+	pending.Granted = false
+
+	runScoreCases(t, []scoreCase{
+		{"第一作者", patent(1), 1.6, "2 分 × 除导师外第一作者 80% = 1.6 分"},
+		{"独立作者", sole, 2, ""},
+		{"第二作者", patent(2), 0, "只认除导师外第一作者"},
+		{"未授权", pending, 0, "尚未授权"},
+	})
+}
+
+func TestScoreCompetition(t *testing.T) {
+	comp := func(class, level, award string, size int) Facts {
+		return Facts{Kind: KindCompetition, Name: "竞赛", Class: class, Level: level, Award: award, TeamSize: size}
+	}
+	ranked := func(rank int) Facts {
+		f := comp(ClassAPlus, LevelNational, AwardFirst, 8)
+		f.Name, f.TeamRank = "“挑战杯”全国大学生课外学术科技作品竞赛", rank
+		return f
+	}
+
+	runScoreCases(t, []scoreCase{
+		{"A+ 国家级一等奖 3 人", comp(ClassAPlus, LevelNational, AwardFirst, 3), 10, "3 人团队平均 1/3"},
+		{"A+ 国家级二等奖个人", comp(ClassAPlus, LevelNational, AwardSecond, 1), 5, "个人项目按 1/3"},
+		{"A+ 国家级三等奖 5 人", comp(ClassAPlus, LevelNational, AwardThird, 5), 2, ""},
+		{"A+ 省级一等奖 4 人", comp(ClassAPlus, LevelProvincial, AwardFirst, 4), 1.25, ""},
+		{"A+ 省级二等奖 2 人", comp(ClassAPlus, LevelProvincial, AwardSecond, 2), 0.6667, "2 人团队按 1/3"},
+		{"A 国家级一等奖 3 人", comp(ClassA, LevelNational, AwardFirst, 3), 5, ""},
+		{"A 国家级二等奖 6 人", comp(ClassA, LevelNational, AwardSecond, 6), 2, "只取前 5 名核心成员按 1/5"},
+		{"A 国家级三等奖个人", comp(ClassA, LevelNational, AwardThird, 1), 1.6667, ""},
+		{"A 省级一等奖 2 人", comp(ClassA, LevelProvincial, AwardFirst, 2), 0.6667, ""},
+		{"A 省级二等奖 3 人", comp(ClassA, LevelProvincial, AwardSecond, 3), 0.3333, ""},
+		{"A- 国家级一等奖 5 人", comp(ClassAMinus, LevelNational, AwardFirst, 5), 2, ""},
+		{"A- 国家级二等奖个人", comp(ClassAMinus, LevelNational, AwardSecond, 1), 1.6667, ""},
+		{"A- 国家级三等奖 4 人", comp(ClassAMinus, LevelNational, AwardThird, 4), 0.5, ""},
+		{"A- 省级一等奖个人", comp(ClassAMinus, LevelProvincial, AwardFirst, 1), 0.3333, ""},
+		{"A- 省级二等奖 5 人", comp(ClassAMinus, LevelProvincial, AwardSecond, 5), 0.1, ""},
+		{"挑战杯队长", ranked(1), 10, "队内排序第 1 名按 1/3"},
+		{"挑战杯第 3 名", ranked(3), 7.5, "1/4"},
+		{"挑战杯第 5 名", ranked(5), 6, "1/5"},
+		{"挑战杯第 6 名", ranked(6), 0, "只计前 5 名"},
+	})
+}
+
+func TestScoreCSP(t *testing.T) {
+	csp := func(percentile float64) Facts {
+		return Facts{Kind: KindCompetition, Name: "CCF CSP 认证", Percentile: percentile}
+	}
+	runScoreCases(t, []scoreCase{
+		{"前 0.1%", csp(0.1), 3.3333, "按 A- 类国家级一等奖及以上"},
+		{"前 0.2%", csp(0.2), 3.3333, ""},
+		{"前 1%", csp(1), 1.6667, "二等奖"},
+		{"前 1.5%", csp(1.5), 1.6667, ""},
+		{"前 3%", csp(3), 0.6667, "三等奖"},
+		{"前 5%", csp(5), 0, "未达到全国三等奖标准"},
+	})
+}
+
+func TestScoreInnovation(t *testing.T) {
+	project := func(level, role string) Facts {
+		return Facts{Kind: KindInnovation, Level: level, Role: role, Concluded: true}
+	}
+	open := project(LevelNational, RoleLeader)
+	open.Concluded = false
+
+	runScoreCases(t, []scoreCase{
+		{"国家级组长", project(LevelNational, RoleLeader), 1, ""},
+		{"国家级成员", project(LevelNational, RoleMember), 0.3, ""},
+		{"省级组长", project(LevelProvincial, RoleLeader), 0.5, ""},
+		{"省级成员", project(LevelProvincial, RoleMember), 0.2, ""},
+		{"校级组长", project(LevelSchool, RoleLeader), 0.1, ""},
+		{"校级成员", project(LevelSchool, RoleMember), 0.05, ""},
+		{"未结题", open, 0, "未结题"},
+	})
+}
+
+func TestScoreVolunteerHours(t *testing.T) {
+	hours := func(h, halved float64) Facts {
+		return Facts{Kind: KindVolunteerHours, Hours: h, HalvedHours: halved}
+	}
+	runScoreCases(t, []scoreCase{
+		{"不足 200 小时", hours(199, 0), 0, "未达到 200 小时"},
+		{"恰好 200 小时", hours(200, 0), 0, ""},
+		{"201 小时", hours(201, 0), 0, ""},
+		{"210 小时", hours(210, 0), 0.25, "每 2 小时 0.05 分"},
+		{"211 小时按整 2 小时计", hours(211, 0), 0.25, ""},
+		{"赛会工时减半", hours(200, 20), 0.25, "200 + 20/2 = 210 小时"},
+		{"超过上限", hours(300, 0), 1, "该项最多 1 分"},
+	})
+}
+
+func TestScoreCadre(t *testing.T) {
+	cadre := func(post string, score float64, tenure string) Facts {
+		return Facts{Kind: KindCadre, Post: post, CounsellorScore: score, Tenure: tenure}
+	}
+	runScoreCases(t, []scoreCase{
+		{"执行主席", cadre(PostExecutiveChair, 90, TenureFullYear), 1.8, "学生干部系数 2 × 辅导员打分 90 / 100 = 1.8 分"},
+		{"主席团", cadre(PostPresidium, 100, TenureFullYear), 1.5, ""},
+		{"部长", cadre(PostMinister, 80, TenureFullYear), 0.8, ""},
+		{"副部长", cadre(PostViceMinister, 60, TenureFullYear), 0.45, ""},
+		{"班委超过一学期", cadre(PostCommittee, 100, TenureOverSemester), 0.25, "× 50%"},
+		{"不满一学期", cadre(PostMinister, 100, TenureUnderSemester), 0, "不满一学期"},
+		{"打分为 0", cadre(PostMinister, 0, TenureFullYear), 0, ""},
+	})
+}
+
+func TestScoreSports(t *testing.T) {
+	sports := func(level, place string, size int) Facts {
+		return Facts{Kind: KindSports, Level: level, Award: place, TeamSize: size}
+	}
+	runScoreCases(t, []scoreCase{
+		{"国际级冠军个人", sports(LevelInternational, PlaceChampion, 1), 2.6667, "个人或二人项目按 1/3"},
+		{"国际级季军 5 人", sports(LevelInternational, PlaceThird, 5), 1, "5 人团体项目平均 1/5"},
+		{"国家级亚军二人", sports(LevelNational, PlaceRunnerUp, 2), 1.1667, ""},
+		{"国家级第四至八名 4 人", sports(LevelNational, PlaceTop8, 4), 0.25, ""},
+	})
+}
+
+func TestScoreComprehensive(t *testing.T) {
+	runScoreCases(t, []scoreCase{
+		{"国际组织实习满一学年", Facts{Kind: KindInternship, Tenure: TenureFullYear}, 1, ""},
+		{"国际组织实习超过一学期", Facts{Kind: KindInternship, Tenure: TenureOverSemester}, 0.5, ""},
+		{"服兵役 2 年", Facts{Kind: KindMilitary, Years: 2}, 2, ""},
+		{"服兵役 1.5 年", Facts{Kind: KindMilitary, Years: 1.5}, 1, "满 1 年"},
+		{"服兵役不满 1 年", Facts{Kind: KindMilitary, Years: 0.5}, 0, "不满加分年限"},
+		{"国家级志愿表彰组长", Facts{Kind: KindVolunteerHonour, Level: LevelNational, Role: RoleLeader}, 1, ""},
+		{"省级志愿表彰个人", Facts{Kind: KindVolunteerHonour, Level: LevelProvincial, TeamSize: 1}, 0.5, "个人"},
+		{"国家级荣誉称号", Facts{Kind: KindHonour, Level: LevelNational}, 2, ""},
+		{"省级集体荣誉减半", Facts{Kind: KindHonour, Level: LevelProvincial, Collective: true}, 0.5, "× 50%"},
+	})
+}
+
+func TestScoreInvalidFacts(t *testing.T) {
+	r := DefaultRules()
+	cases := []struct {
+		name  string
+		facts Facts
+	}{
+		{"未知种类", Facts{Kind: "award"}},
+		{"缺少种类", Facts{}},
+		{"论文类别未知", Facts{Kind: KindPaper, Class: "D", AuthorRank: 1, FirstAffiliation: true}},
+		{"论文缺少作者排序", Facts{Kind: KindPaper, Class: ClassA, FirstAffiliation: true}},
+		{"专利缺少发明人排序", Facts{Kind: KindPatent, Granted: true, FirstAffiliation: true}},
+		{"竞赛类别未知", Facts{Kind: KindCompetition, Class: ClassB, Level: LevelNational, Award: AwardFirst, TeamSize: 1}},
+		{"竞赛没有校级奖项", Facts{Kind: KindCompetition, Class: ClassAPlus, Level: LevelSchool, Award: AwardFirst, TeamSize: 1}},
+		{"省级竞赛没有三等奖", Facts{Kind: KindCompetition, Class: ClassAMinus, Level: LevelProvincial, Award: AwardThird, TeamSize: 1}},
+		{"竞赛缺少参赛人数", Facts{Kind: KindCompetition, Class: ClassA, Level: LevelNational, Award: AwardFirst}},
+		{"挑战杯缺少队内排序", Facts{Kind: KindCompetition, Name: "挑战杯", Class: ClassAPlus, Level: LevelNational, Award: AwardFirst, TeamSize: 5}},
+		{"创新项目级别未知", Facts{Kind: KindInnovation, Level: LevelInternational, Role: RoleLeader, Concluded: true}},
+		{"创新项目角色未知", Facts{Kind: KindInnovation, Level: LevelNational, Role: RoleIndividual, Concluded: true}},
+		{"实习时长未知", Facts{Kind: KindInternship, Tenure: "two_weeks"}},
+		{"志愿工时为负数", Facts{Kind: KindVolunteerHours, Hours: -1}},
+		{"志愿表彰级别未知", Facts{Kind: KindVolunteerHonour, Level: LevelInternational, Role: RoleLeader}},
+		{"志愿表彰缺少角色", Facts{Kind: KindVolunteerHonour, Level: LevelNational, TeamSize: 3}},
+		{"荣誉级别未知", Facts{Kind: KindHonour, Level: LevelInternational}},
+		{"学生干部职务未知", Facts{Kind: KindCadre, Post: "chairman", CounsellorScore: 90, Tenure: TenureFullYear}},
+		{"辅导员打分超过 100", Facts{Kind: KindCadre, Post: PostMinister, CounsellorScore: 120, Tenure: TenureFullYear}},
+		{"学生干部缺少任职时长", Facts{Kind: KindCadre, Post: PostMinister, CounsellorScore: 90}},
+		{"体育比赛没有省级奖项", Facts{Kind: KindSports, Level: LevelProvincial, Award: PlaceChampion, TeamSize: 1}},
+		{"体育比赛名次未知", Facts{Kind: KindSports, Level: LevelNational, Award: AwardFirst, TeamSize: 1}},
+		{"体育比赛缺少参赛人数", Facts{Kind: KindSports, Level: LevelNational, Award: PlaceChampion}},
+	}
+	for _, c := range cases {
+		if got, err := r.Score(c.facts); !errors.Is(err, ErrInvalidFacts) {
+			t.Errorf("%s: Score = %+v, %v, want ErrInvalidFacts", c.name, got, err)
+		}
+	}
+}
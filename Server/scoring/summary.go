@@ -0,0 +1,381 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 汇总中每条记录的计入情况
+const (
+	// StatusCounted 全额计入
+	StatusCounted = "counted"
+	// StatusCapped 因累计上限只计入一部分或未计入
+	StatusCapped = "capped"
+	// StatusExcluded 因取最高、项数限制等规则不计入
+	StatusExcluded = "excluded"
+)
+
+// Item 参与汇总的一条加分记录
+type Item struct {
+	ID string
+	// Section 记录所属区块，Facts 有效时以 Facts 为准
+	Section string
+	// Facts 为零值表示没有计分要素的旧记录，只参与区块总分上限
+	Facts Facts
+	// Points 学院核定加分
+	Points float64
+	// AwardDate 获奖时间，Facts 没有学年时用其年份判断同一年度
+	AwardDate string
+}
+
+// ItemResult 记录在汇总中的计入情况
+type ItemResult struct {
+	ID      string  `json:"id"`
+	Section string  `json:"section"`
+	Kind    Kind    `json:"kind"`
+	Points  float64 `json:"points"`
+	// Counted 实际计入总分的分值
+	Counted float64 `json:"counted"`
+	Status  string  `json:"status"`
+	Reason  string  `json:"reason"`
+}
+
+// SectionTotal 区块总分
+type SectionTotal struct {
+	Section string `json:"section"`
+	// Raw 未封顶前计入的分值之和
+	Raw   float64 `json:"raw"`
+	Total float64 `json:"total"`
+	Cap   float64 `json:"cap"`
+	// Items 有分值计入的记录数
+	Items int `json:"items"`
+}
+
+// Summary 一名学生的加分汇总
+type Summary struct {
+	Items         []ItemResult `json:"items"`
+	Academic      SectionTotal `json:"academic"`
+	Comprehensive SectionTotal `json:"comprehensive"`
+	Total         float64      `json:"total"`
+}
+
+type entry struct {
+	item Item
+	res  *ItemResult
+}
+
+// exclude 将记录标记为不计入
+func (e *entry) exclude(reason string) {
+	e.res.Status, e.res.Counted, e.res.Reason = StatusExcluded, 0, reason
+}
+
+// limit 按剩余额度计入记录，返回计入后的剩余额度
+func (e *entry) limit(remaining float64, reason string) float64 {
+	if e.res.Counted <= remaining {
+		return remaining - e.res.Counted
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	e.res.Status, e.res.Counted, e.res.Reason = StatusCapped, Round(remaining), reason
+	return 0
+}
+
+func (e *entry) active() bool {
+	return e.res.Status != StatusExcluded && e.res.Counted > 0
+}
+
+// year 记录所属的年度，优先使用学年，否则取获奖时间的年份
+func (it Item) year() string {
+	if it.Facts.AcademicYear != "" {
+		return it.Facts.AcademicYear
+	}
+	if len(it.AwardDate) >= 4 {
+		return it.AwardDate[:4]
+	}
+	return ""
+}
+
+// Summarize 按条例的项数限制、取最高规则与累计上限汇总加分，自动选出总分最高的计分组合
+// 每个区块最终再按 academicCap、comprehensiveCap 封顶
+func (r *Rules) Summarize(items []Item, academicCap, comprehensiveCap float64) Summary {
+	results := make([]ItemResult, len(items))
+	entries := make([]*entry, len(items))
+	for i, it := range items {
+		section := it.Facts.Section()
+		if section == "" {
+			section = it.Section
+		}
+		results[i] = ItemResult{ID: it.ID, Section: section, Kind: it.Facts.Kind, Points: it.Points, Counted: it.Points, Status: StatusCounted}
+		entries[i] = &entry{item: it, res: &results[i]}
+	}
+	// 分值从高到低处理，同分按记录编号保证结果稳定
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].res.Points != entries[j].res.Points {
+			return entries[i].res.Points > entries[j].res.Points
+		}
+		return entries[i].item.ID < entries[j].item.ID
+	})
+	for _, e := range entries {
+		if e.res.Points <= 0 {
+			e.exclude("未获得加分")
+		}
+	}
+
+	// 竞赛的同组取最高与项数限制相互影响，联合求解
+	r.selectCompetitions(entries)
+
+	// 其余种类同组只计最高分，之后只受累计上限约束，逐组取最高即为最优
+	r.keepHighest(entries, KindInnovation, func(f Facts, _ Item) string { return f.Name }, "同一项目多级别立项只计最高分")
+	r.keepHighest(entries, KindVolunteerHonour, func(f Facts, _ Item) string { return f.Name }, "同一志愿服务的多项表彰只计最高分")
+	r.keepHighest(entries, KindHonour, func(f Facts, it Item) string { return it.year() }, "同一学年多项荣誉称号只计最高分")
+	r.keepHighest(entries, KindCadre, func(f Facts, it Item) string { return it.year() }, "同一学年兼任多个学生干部职务只计最高分")
+	r.keepHighest(entries, KindSports, func(f Facts, _ Item) string { return f.Name }, "同一赛事不同级别只计最高分")
+
+	// C 类论文篇数限制：分值从高到低依次选取
+	papersC := 0
+	for _, e := range entries {
+		if !e.active() {
+			continue
+		}
+		if f := e.item.Facts; f.Kind == KindPaper && f.Class == ClassC {
+			if papersC >= r.PaperCMaxItems {
+				e.exclude(fmt.Sprintf("C 类论文最多计 %d 篇", r.PaperCMaxItems))
+			} else {
+				papersC++
+			}
+		}
+	}
+
+	// 分项累计上限
+	for _, c := range []struct {
+		kinds []Kind
+		cap   float64
+		name  string
+	}{
+		{[]Kind{KindInnovation}, r.InnovationCap, "创新创业训练"},
+		{[]Kind{KindInternship}, r.InternshipCap, "国际组织实习"},
+		{[]Kind{KindMilitary}, r.MilitaryCap, "参军入伍服兵役"},
+		{[]Kind{KindVolunteerHours, KindVolunteerHonour}, r.VolunteerCap, "志愿服务"},
+		{[]Kind{KindHonour}, r.HonourCap, "荣誉称号"},
+		{[]Kind{KindCadre}, r.CadreCap, "社会工作"},
+	} {
+		remaining := c.cap
+		reason := fmt.Sprintf("%s最多加 %s 分", c.name, Format(c.cap))
+		for _, e := range entries {
+			if e.active() && hasKind(c.kinds, e.item.Facts.Kind) {
+				remaining = e.limit(remaining, reason)
+			}
+		}
+	}
+
+	// 区块上限
+	summary := Summary{Items: results}
+	for _, sec := range []struct {
+		total *SectionTotal
+		name  string
+		label string
+		cap   float64
+	}{
+		{&summary.Academic, SectionAcademic, "学术专长成绩", academicCap},
+		{&summary.Comprehensive, SectionComprehensive, "综合表现成绩", comprehensiveCap},
+	} {
+		sec.total.Section, sec.total.Cap = sec.name, sec.cap
+		remaining := sec.cap
+		reason := fmt.Sprintf("%s最多 %s 分", sec.label, Format(sec.cap))
+		for _, e := range entries {
+			if !e.active() || e.res.Section != sec.name {
+				continue
+			}
+			sec.total.Raw += e.res.Counted
+			remaining = e.limit(remaining, reason)
+			if e.res.Counted > 0 {
+				sec.total.Items++
+				sec.total.Total += e.res.Counted
+			}
+		}
+		sec.total.Raw, sec.total.Total = Round(sec.total.Raw), Round(sec.total.Total)
+	}
+	summary.Total = Round(summary.Academic.Total + summary.Comprehensive.Total)
+	return summary
+}
+
+// keepHighest 同种类中 key 相同的记录只保留分值最高的一条，key 为空的记录不参与
+// entries 已按分值从高到低排列
+func (r *Rules) keepHighest(entries []*entry, kind Kind, key func(Facts, Item) string, reason string) {
+	kept := make(map[string]string)
+	for _, e := range entries {
+		if !e.active() || e.item.Facts.Kind != kind {
+			continue
+		}
+		k := strings.TrimSpace(key(e.item.Facts, e.item))
+		if k == "" {
+			continue
+		}
+		if best, ok := kept[k]; ok {
+			e.exclude(fmt.Sprintf("%s（已计 %s）", reason, best))
+			continue
+		}
+		kept[k] = e.item.ID
+	}
+}
+
+// competitionGroup 竞赛中同组只计最高分的一条规则，key 为空的记录不属于任何组
+type competitionGroup struct {
+	key    func(Facts, Item) string
+	reason string
+}
+
+func (r *Rules) competitionGroups() []competitionGroup {
+	return []competitionGroup{
+		{func(f Facts, _ Item) string { return f.Work }, "同一作品参加多项竞赛只计最高分"},
+		{func(f Facts, it Item) string {
+			if r.sameYearCompetition(f.Name) && it.year() != "" {
+				return it.year()
+			}
+			return ""
+		}, "同一年度的 ICPC 与 CCPC 只计最高分"},
+		{func(f Facts, _ Item) string {
+			if f.Percentile > 0 || strings.Contains(strings.ToUpper(f.Name), "CSP") {
+				return "csp"
+			}
+			return ""
+		}, "CCF CSP 认证只计最高分"},
+	}
+}
+
+// selectCompetitions 在同组只计一项、竞赛项数与非信息学院竞赛项数的限制下选出总分最高的竞赛组合
+// 逐组先取最高分可能占用非信息学院竞赛的名额而错过更优组合，因此按分值从高到低回溯搜索，
+// 以剩余名额内最高分值之和剪枝；总分相同时优先选取分值较高的记录
+// entries 已按分值从高到低排列
+func (r *Rules) selectCompetitions(entries []*entry) {
+	groups := r.competitionGroups()
+	cands := make([]*entry, 0)
+	keys := make([][]string, 0)
+	for _, e := range entries {
+		if !e.active() || e.item.Facts.Kind != KindCompetition {
+			continue
+		}
+		k := make([]string, len(groups))
+		for g, group := range groups {
+			k[g] = strings.TrimSpace(group.key(e.item.Facts, e.item))
+		}
+		cands = append(cands, e)
+		keys = append(keys, k)
+	}
+
+	used := make([]map[string]bool, len(groups))
+	for g := range used {
+		used[g] = make(map[string]bool)
+	}
+	conflicts := func(i int) bool {
+		for g, k := range keys[i] {
+			if k != "" && used[g][k] {
+				return true
+			}
+		}
+		return false
+	}
+	mark := func(i int, on bool) {
+		for g, k := range keys[i] {
+			if k != "" {
+				used[g][k] = on
+			}
+		}
+	}
+
+	chosen := make([]bool, len(cands))
+	best := make([]bool, len(cands))
+	bestScore := -1.0
+	var search func(i, count, external int, score float64)
+	search = func(i, count, external int, score float64) {
+		if score > bestScore {
+			bestScore = score
+			copy(best, chosen)
+		}
+		if i == len(cands) || count >= r.CompetitionMaxItems {
+			return
+		}
+		bound := score
+		for j := i; j < len(cands) && j < i+r.CompetitionMaxItems-count; j++ {
+			bound += cands[j].res.Counted
+		}
+		if bound <= bestScore {
+			return
+		}
+		e := cands[i]
+		if ext := e.item.Facts.External; !(ext && external >= r.ExternalCompetitionMaxItems) && !conflicts(i) {
+			mark(i, true)
+			chosen[i] = true
+			next := external
+			if ext {
+				next++
+			}
+			search(i+1, count+1, next, score+e.res.Counted)
+			chosen[i] = false
+			mark(i, false)
+		}
+		search(i+1, count, external, score)
+	}
+	search(0, 0, 0, 0)
+
+	// 未选中的记录按同组规则、非信息学院竞赛项数、竞赛项数的顺序说明原因
+	keptBy := make([]map[string]string, len(groups))
+	for g := range keptBy {
+		keptBy[g] = make(map[string]string)
+	}
+	external := 0
+	for i, e := range cands {
+		if !best[i] {
+			continue
+		}
+		for g, k := range keys[i] {
+			if k != "" {
+				keptBy[g][k] = e.item.ID
+			}
+		}
+		if e.item.Facts.External {
+			external++
+		}
+	}
+	for i, e := range cands {
+		if best[i] {
+			continue
+		}
+		reason := ""
+		for g, k := range keys[i] {
+			if id, ok := keptBy[g][k]; ok && k != "" {
+				reason = fmt.Sprintf("%s（已计 %s）", groups[g].reason, id)
+				break
+			}
+		}
+		if reason == "" && e.item.Facts.External && external >= r.ExternalCompetitionMaxItems {
+			reason = fmt.Sprintf("非信息学院竞赛最多计 %d 项", r.ExternalCompetitionMaxItems)
+		}
+		if reason == "" {
+			reason = fmt.Sprintf("学业竞赛最多计 %d 项", r.CompetitionMaxItems)
+		}
+		e.exclude(reason)
+	}
+}
+
+// sameYearCompetition 竞赛是否属于同一年度只计最高分的赛事
+func (r *Rules) sameYearCompetition(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, keyword := range r.SameYearCompetitions {
+		if keyword != "" && strings.Contains(upper, strings.ToUpper(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
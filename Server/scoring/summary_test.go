@@ -0,0 +1,175 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func summaryResult(t *testing.T, s Summary, id string) ItemResult {
+	t.Helper()
+	for _, it := range s.Items {
+		if it.ID == id {
+			return it
+		}
+	}
+	t.Fatalf("汇总中没有记录 %s", id)
+	return ItemResult{}
+}
+
+func expectCounted(t *testing.T, s Summary, want map[string]float64) {
+	t.Helper()
+	for id, counted := range want {
+		if got := summaryResult(t, s, id); got.Counted != counted {
+			t.Errorf("%s: Counted = %v, want %v (status %s, reason %q)", id, got.Counted, counted, got.Status, got.Reason)
+		}
+	}
+}
+
+func expectReason(t *testing.T, s Summary, id, status, reason string) {
+	t.Helper()
+	got := summaryResult(t, s, id)
+	if got.Status != status || !strings.Contains(got.Reason, reason) {
+		t.Errorf("%s: status %s reason %q, want %s containing %q", id, got.Status, got.Reason, status, reason)
+	}
+}
+
+func TestSummarizeCompetitionDedupWithExternalLimit(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "ext-work", Points: 10, Facts: Facts{Kind: KindCompetition, Name: "外院竞赛甲", Work: "作品", External: true}},
+		{ID: "college-work", Points: 8, Facts: Facts{Kind: KindCompetition, Name: "院内竞赛", Work: "作品"}},
+		{ID: "ext-other", Points: 12, Facts: Facts{Kind: KindCompetition, Name: "外院竞赛乙", External: true}},
+	}
+	s := r.Summarize(items, 100, 100)
+	if s.Academic.Total != 20 {
+		t.Errorf("Academic.Total = %v, want 20", s.Academic.Total)
+	}
+	expectCounted(t, s, map[string]float64{"ext-other": 12, "college-work": 8, "ext-work": 0})
+	expectReason(t, s, "ext-work", StatusExcluded, "同一作品参加多项竞赛只计最高分")
+}
+
+func TestSummarizeCompetitionLimits(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "c1", Points: 5, Facts: Facts{Kind: KindCompetition, Name: "竞赛一"}},
+		{ID: "c2", Points: 4, Facts: Facts{Kind: KindCompetition, Name: "竞赛二"}},
+		{ID: "c3", Points: 3, Facts: Facts{Kind: KindCompetition, Name: "竞赛三", External: true}},
+		{ID: "c4", Points: 2, Facts: Facts{Kind: KindCompetition, Name: "竞赛四", External: true}},
+		{ID: "c5", Points: 1, Facts: Facts{Kind: KindCompetition, Name: "竞赛五"}},
+	}
+	s := r.Summarize(items, 100, 100)
+	expectCounted(t, s, map[string]float64{"c1": 5, "c2": 4, "c3": 3, "c4": 0, "c5": 0})
+	expectReason(t, s, "c4", StatusExcluded, "非信息学院竞赛最多计 1 项")
+	expectReason(t, s, "c5", StatusExcluded, "学业竞赛最多计 3 项")
+}
+
+func TestSummarizeCompetitionGroups(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "icpc", Points: 6, AwardDate: "2023-11-01", Facts: Facts{Kind: KindCompetition, Name: "ICPC 亚洲区域赛"}},
+		{ID: "ccpc", Points: 4, AwardDate: "2023-10-01", Facts: Facts{Kind: KindCompetition, Name: "CCPC 中国大学生程序设计竞赛"}},
+		{ID: "ccpc-next", Points: 3, AwardDate: "2024-10-01", Facts: Facts{Kind: KindCompetition, Name: "CCPC 中国大学生程序设计竞赛"}},
+		{ID: "csp-high", Points: 2, Facts: Facts{Kind: KindCompetition, Name: "CCF CSP 认证", Percentile: 1}},
+		{ID: "csp-low", Points: 1, Facts: Facts{Kind: KindCompetition, Name: "CCF CSP 认证", Percentile: 10}},
+	}
+	s := r.Summarize(items, 100, 100)
+	expectCounted(t, s, map[string]float64{"icpc": 6, "ccpc": 0, "ccpc-next": 3, "csp-high": 2, "csp-low": 0})
+	expectReason(t, s, "ccpc", StatusExcluded, "同一年度的 ICPC 与 CCPC 只计最高分（已计 icpc）")
+	expectReason(t, s, "csp-low", StatusExcluded, "CCF CSP 认证只计最高分（已计 csp-high）")
+}
+
+func TestSummarizePaperCLimit(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "p1", Points: 1, Facts: Facts{Kind: KindPaper, Class: ClassC, Name: "论文一"}},
+		{ID: "p2", Points: 1, Facts: Facts{Kind: KindPaper, Class: ClassC, Name: "论文二"}},
+		{ID: "p3", Points: 0.5, Facts: Facts{Kind: KindPaper, Class: ClassC, Name: "论文三"}},
+	}
+	s := r.Summarize(items, 100, 100)
+	expectCounted(t, s, map[string]float64{"p1": 1, "p2": 1, "p3": 0})
+	expectReason(t, s, "p3", StatusExcluded, "C 类论文最多计 2 篇")
+}
+
+func TestSummarizeSubCaps(t *testing.T) {
+	r := DefaultRules()
+	cases := []struct {
+		name  string
+		items []Item
+		cap   float64
+	}{
+		{"创新创业训练", []Item{
+			{ID: "a", Points: 1.5, Facts: Facts{Kind: KindInnovation, Name: "项目甲"}},
+			{ID: "b", Points: 1, Facts: Facts{Kind: KindInnovation, Name: "项目乙"}},
+		}, r.InnovationCap},
+		{"国际组织实习", []Item{
+			{ID: "a", Points: 1, Facts: Facts{Kind: KindInternship, Name: "实习甲"}},
+			{ID: "b", Points: 0.5, Facts: Facts{Kind: KindInternship, Name: "实习乙"}},
+		}, r.InternshipCap},
+		{"参军入伍服兵役", []Item{
+			{ID: "a", Points: 2, Facts: Facts{Kind: KindMilitary, Name: "服役"}},
+			{ID: "b", Points: 1, Facts: Facts{Kind: KindMilitary, Name: "表彰"}},
+		}, r.MilitaryCap},
+		{"志愿服务", []Item{
+			{ID: "a", Points: 0.75, Facts: Facts{Kind: KindVolunteerHours, Name: "志愿工时"}},
+			{ID: "b", Points: 0.5, Facts: Facts{Kind: KindVolunteerHonour, Name: "优秀志愿者"}},
+		}, r.VolunteerCap},
+		{"荣誉称号", []Item{
+			{ID: "a", Points: 1.5, AwardDate: "2023-05-01", Facts: Facts{Kind: KindHonour, Name: "三好学生"}},
+			{ID: "b", Points: 1, AwardDate: "2024-05-01", Facts: Facts{Kind: KindHonour, Name: "优秀学生干部"}},
+		}, r.HonourCap},
+		{"社会工作", []Item{
+			{ID: "a", Points: 1.5, AwardDate: "2023-05-01", Facts: Facts{Kind: KindCadre, Name: "班长"}},
+			{ID: "b", Points: 1, AwardDate: "2024-05-01", Facts: Facts{Kind: KindCadre, Name: "团支书"}},
+		}, r.CadreCap},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := r.Summarize(c.items, 100, 100)
+			a, b := summaryResult(t, s, "a"), summaryResult(t, s, "b")
+			if a.Status != StatusCounted || a.Counted != a.Points {
+				t.Errorf("a: %+v, want fully counted", a)
+			}
+			want := Round(c.cap - a.Points)
+			if b.Status != StatusCapped || b.Counted != want {
+				t.Errorf("b: %+v, want capped at %v", b, want)
+			}
+			if !strings.Contains(b.Reason, c.name+"最多加 "+Format(c.cap)+" 分") {
+				t.Errorf("b: reason %q", b.Reason)
+			}
+			if got := s.Academic.Total + s.Comprehensive.Total; got != c.cap {
+				t.Errorf("total = %v, want %v", got, c.cap)
+			}
+		})
+	}
+}
+
+func TestSummarizeKeepHighest(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "honour-a", Points: 1.5, AwardDate: "2023-05-01", Facts: Facts{Kind: KindHonour, Name: "三好学生"}},
+		{ID: "honour-b", Points: 1, AwardDate: "2023-06-01", Facts: Facts{Kind: KindHonour, Name: "优秀学生干部"}},
+		{ID: "sports-a", Points: 1, Facts: Facts{Kind: KindSports, Name: "校运会"}},
+		{ID: "sports-b", Points: 0.5, Facts: Facts{Kind: KindSports, Name: "校运会"}},
+	}
+	s := r.Summarize(items, 100, 100)
+	expectCounted(t, s, map[string]float64{"honour-a": 1.5, "honour-b": 0, "sports-a": 1, "sports-b": 0})
+	expectReason(t, s, "honour-b", StatusExcluded, "同一学年多项荣誉称号只计最高分（已计 honour-a）")
+	expectReason(t, s, "sports-b", StatusExcluded, "同一赛事不同级别只计最高分")
+}
+
+func TestSummarizeSectionCaps(t *testing.T) {
+	r := DefaultRules()
+	items := []Item{
+		{ID: "legacy-a", Section: SectionAcademic, Points: 3},
+		{ID: "legacy-b", Section: SectionAcademic, Points: 2},
+		{ID: "legacy-c", Section: SectionComprehensive, Points: 1},
+		{ID: "zero", Section: SectionAcademic, Points: 0},
+	}
+	s := r.Summarize(items, 4, 2)
+	expectCounted(t, s, map[string]float64{"legacy-a": 3, "legacy-b": 1, "legacy-c": 1})
+	expectReason(t, s, "legacy-b", StatusCapped, "学术专长成绩最多 4 分")
+	expectReason(t, s, "zero", StatusExcluded, "未获得加分")
+	if s.Academic.Raw != 5 || s.Academic.Total != 4 || s.Academic.Items != 2 || s.Total != 5 {
+		t.Errorf("summary = %+v / %+v, total %v", s.Academic, s.Comprehensive, s.Total)
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlAppealRepository struct {
+	s *Store
+}
+
+const appealColumns = `id, materialId, round, appellant, statement, COALESCE(files, ''), status, createdAt,
+	COALESCE(decidedBy, ''), COALESCE(decidedAt, ''), COALESCE(comment, '')`
+
+func scanAppeal(row rowScanner) (*Appeal, error) {
+	var a Appeal
+	err := row.Scan(&a.ID, &a.MaterialId, &a.Round, &a.Appellant, &a.Statement, &a.Files, &a.Status, &a.CreatedAt,
+		&a.DecidedBy, &a.DecidedAt, &a.Comment)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *sqlAppealRepository) list(ctx context.Context, query string, args ...interface{}) ([]Appeal, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+appealColumns+` FROM appeals `+query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appeals := make([]Appeal, 0)
+	for rows.Next() {
+		a, err := scanAppeal(rows)
+		if err != nil {
+			return nil, err
+		}
+		appeals = append(appeals, *a)
+	}
+	return appeals, rows.Err()
+}
+
+func (r *sqlAppealRepository) Create(ctx context.Context, a *Appeal) error {
+	if a.CreatedAt == "" {
+		a.CreatedAt = Now()
+	}
+	if a.Status == "" {
+		a.Status = AppealPending
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO appeals (materialId, round, appellant, statement, files, status, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		a.MaterialId, a.Round, a.Appellant, a.Statement, a.Files, a.Status, a.CreatedAt).Scan(&a.ID)
+}
+
+func (r *sqlAppealRepository) Get(ctx context.Context, id int64) (*Appeal, error) {
+	a, err := scanAppeal(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+appealColumns+` FROM appeals WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (r *sqlAppealRepository) ListByMaterial(ctx context.Context, materialId string) ([]Appeal, error) {
+	return r.list(ctx, `WHERE materialId = ? ORDER BY id`, materialId)
+}
+
+func (r *sqlAppealRepository) ListByStatus(ctx context.Context, status string) ([]Appeal, error) {
+	if status == "" {
+		return r.list(ctx, `ORDER BY id`)
+	}
+	return r.list(ctx, `WHERE status = ? ORDER BY id`, status)
+}
+
+func (r *sqlAppealRepository) Decide(ctx context.Context, id int64, status, decidedBy, comment string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE appeals SET status = ?, decidedBy = ?, decidedAt = ?, comment = ?
+		WHERE id = ? AND status = ?`), status, decidedBy, Now(), comment, id, AppealPending)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
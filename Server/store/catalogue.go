@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type sqlCatalogueRepository struct {
+	s *Store
+}
+
+const catalogueColumns = `id, regulationCode, kind, name, COALESCE(aliases, ''), class, COALESCE(level, ''), COALESCE(host, ''),
+	external, COALESCE(note, ''), COALESCE(updatedBy, ''), updatedAt`
+
+func scanCatalogueEntry(row rowScanner) (*CatalogueEntry, error) {
+	var (
+		e        CatalogueEntry
+		aliases  string
+		external int
+	)
+	err := row.Scan(&e.ID, &e.RegulationCode, &e.Kind, &e.Name, &aliases, &e.Class, &e.Level, &e.Host,
+		&external, &e.Note, &e.UpdatedBy, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.External = external != 0
+	e.Aliases = make([]string, 0)
+	if aliases != "" {
+		if err := json.Unmarshal([]byte(aliases), &e.Aliases); err != nil {
+			return nil, err
+		}
+	}
+	return &e, nil
+}
+
+func encodeAliases(aliases []string) (string, error) {
+	if aliases == nil {
+		aliases = []string{}
+	}
+	data, err := json.Marshal(aliases)
+	return string(data), err
+}
+
+func (r *sqlCatalogueRepository) List(ctx context.Context, f CatalogueFilter) ([]CatalogueEntry, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if f.RegulationCode != "" {
+		where = append(where, "regulationCode = ?")
+		args = append(args, f.RegulationCode)
+	}
+	if f.Kind != "" {
+		where = append(where, "kind = ?")
+		args = append(args, f.Kind)
+	}
+	if f.Keyword != "" {
+		like := "%" + strings.ToLower(f.Keyword) + "%"
+		where = append(where, "(LOWER(name) LIKE ? OR LOWER(COALESCE(aliases, '')) LIKE ? OR LOWER(COALESCE(host, '')) LIKE ?)")
+		args = append(args, like, like, like)
+	}
+	query := `SELECT ` + catalogueColumns + ` FROM catalogue_entries`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(query+` ORDER BY regulationCode, kind, id`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]CatalogueEntry, 0)
+	for rows.Next() {
+		e, err := scanCatalogueEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *sqlCatalogueRepository) Get(ctx context.Context, id int64) (*CatalogueEntry, error) {
+	e, err := scanCatalogueEntry(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+catalogueColumns+` FROM catalogue_entries WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+// nameTaken 同一条例同一种类下是否已有其他同名条目
+func (r *sqlCatalogueRepository) nameTaken(ctx context.Context, e *CatalogueEntry) (bool, error) {
+	var n int
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COUNT(*) FROM catalogue_entries
+		WHERE regulationCode = ? AND kind = ? AND name = ? AND id <> ?`), e.RegulationCode, e.Kind, e.Name, e.ID).Scan(&n)
+	return n > 0, err
+}
+
+func (r *sqlCatalogueRepository) Create(ctx context.Context, e *CatalogueEntry) error {
+	if e.UpdatedAt == "" {
+		e.UpdatedAt = Now()
+	}
+	aliases, err := encodeAliases(e.Aliases)
+	if err != nil {
+		return err
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		repo := &sqlCatalogueRepository{tx}
+		if taken, err := repo.nameTaken(ctx, e); err != nil {
+			return err
+		} else if taken {
+			return ErrConflict
+		}
+		return tx.conn().QueryRowContext(ctx, tx.Rebind(`INSERT INTO catalogue_entries (regulationCode, kind, name, aliases, class, level, host,
+			external, note, updatedBy, updatedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+			e.RegulationCode, e.Kind, e.Name, aliases, e.Class, e.Level, e.Host, boolToInt(e.External), e.Note, e.UpdatedBy, e.UpdatedAt).Scan(&e.ID)
+	})
+}
+
+func (r *sqlCatalogueRepository) Update(ctx context.Context, e *CatalogueEntry) error {
+	e.UpdatedAt = Now()
+	aliases, err := encodeAliases(e.Aliases)
+	if err != nil {
+		return err
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		repo := &sqlCatalogueRepository{tx}
+		if taken, err := repo.nameTaken(ctx, e); err != nil {
+			return err
+		} else if taken {
+			return ErrConflict
+		}
+		result, err := tx.conn().ExecContext(ctx, tx.Rebind(`UPDATE catalogue_entries SET regulationCode = ?, kind = ?, name = ?, aliases = ?,
+			class = ?, level = ?, host = ?, external = ?, note = ?, updatedBy = ?, updatedAt = ? WHERE id = ?`),
+			e.RegulationCode, e.Kind, e.Name, aliases, e.Class, e.Level, e.Host, boolToInt(e.External), e.Note, e.UpdatedBy, e.UpdatedAt, e.ID)
+		if err != nil {
+			return err
+		}
+		return requireAffected(result)
+	})
+}
+
+func (r *sqlCatalogueRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`DELETE FROM catalogue_entries WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlCatalogueRepository) Count(ctx context.Context, regulationCode string) (int, error) {
+	var n int
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COUNT(*) FROM catalogue_entries WHERE regulationCode = ?`), regulationCode).Scan(&n)
+	return n, err
+}
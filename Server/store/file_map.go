@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlFileMapRepository struct {
+	s *Store
+}
+
+func (r *sqlFileMapRepository) Find(ctx context.Context, md5, filename string) (*FileMapping, error) {
+	f := FileMapping{Md5: md5, Filename: filename}
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COALESCE(file_id, ''), COALESCE(url, '') FROM file_map WHERE md5 = ? AND filename = ?`), md5, filename).
+		Scan(&f.FileId, &f.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *sqlFileMapRepository) DeleteByFileId(ctx context.Context, fileId string) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`DELETE FROM file_map WHERE file_id = ?`), fileId)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlFileMapRepository) Save(ctx context.Context, f *FileMapping) error {
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO file_map (md5, filename, file_id, url) VALUES (?, ?, ?, ?)
+		ON CONFLICT (md5) DO UPDATE SET filename = excluded.filename, file_id = excluded.file_id, url = excluded.url`),
+		f.Md5, f.Filename, f.FileId, f.URL)
+	return err
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LegacyDatabases 旧版按功能分散的数据库文件，按合并优先级排列
+var LegacyDatabases = []string{"./user_info.db", "./hci.db", "./app.db", "./students.db"}
+
+// legacyNaturalKeys 自增主键在不同旧库间会冲突的表，改用业务键去重
+var legacyNaturalKeys = map[string]string{
+	"users":    "username",
+	"students": "studentId",
+}
+
+// MergeLegacy 将旧版数据库中的数据合并到统一库
+// 主键已存在的记录以统一库为准；返回每个来源表合并的行数
+// 旧库均为 SQLite 文件，只能合并到 SQLite 统一库
+func (s *Store) MergeLegacy(paths []string) (map[string]int64, error) {
+	if s.Dialect != DialectSQLite {
+		return nil, fmt.Errorf("旧版数据库只能合并到 SQLite，当前为 %s", s.Dialect)
+	}
+	ctx := context.Background()
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	merged := make(map[string]int64)
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := mergeLegacyFile(ctx, conn, path, merged); err != nil {
+			return merged, fmt.Errorf("合并 %s 失败: %w", path, err)
+		}
+	}
+	return merged, nil
+}
+
+func mergeLegacyFile(ctx context.Context, conn *sql.Conn, path string, merged map[string]int64) error {
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS legacy`, path); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `DETACH DATABASE legacy`)
+
+	tables, err := queryStrings(ctx, conn, `SELECT name FROM legacy.sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return err
+	}
+
+	// 先收集列信息再开启事务，事务期间连接只能通过 tx 使用
+	type mergeStep struct {
+		table string
+		query string
+	}
+	steps := make([]mergeStep, 0, len(tables))
+	for _, table := range tables {
+		if table == "schema_version" {
+			continue
+		}
+		mainCols, err := tableColumns(ctx, conn, "main", table)
+		if err != nil {
+			return err
+		}
+		if len(mainCols) == 0 {
+			continue
+		}
+		legacyCols, err := tableColumns(ctx, conn, "legacy", table)
+		if err != nil {
+			return err
+		}
+
+		naturalKey := legacyNaturalKeys[table]
+		cols := make([]string, 0, len(legacyCols))
+		for _, c := range legacyCols {
+			if !containsString(mainCols, c) {
+				continue
+			}
+			if naturalKey != "" && c == "id" {
+				continue
+			}
+			cols = append(cols, c)
+		}
+		if len(cols) == 0 {
+			continue
+		}
+
+		colList := `"` + strings.Join(cols, `", "`) + `"`
+		query := fmt.Sprintf(`INSERT OR IGNORE INTO main."%s" (%s) SELECT %s FROM legacy."%s"`, table, colList, colList, table)
+		if naturalKey != "" && containsString(cols, naturalKey) {
+			query += fmt.Sprintf(` WHERE "%s" NOT IN (SELECT "%s" FROM main."%s" WHERE "%s" IS NOT NULL)`, naturalKey, naturalKey, table, naturalKey)
+		}
+		steps = append(steps, mergeStep{table: table, query: query})
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query)
+		if err != nil {
+			return fmt.Errorf("表 %s: %w", step.table, err)
+		}
+		n, _ := result.RowsAffected()
+		merged[path+":"+step.table] += n
+	}
+
+	return tx.Commit()
+}
+
+func tableColumns(ctx context.Context, conn *sql.Conn, schema, table string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf(`PRAGMA %s.table_info("%s")`, schema, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := make([]string, 0)
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultV   sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultV, &primaryKey); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+func queryStrings(ctx context.Context, conn *sql.Conn, query string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
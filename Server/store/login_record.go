@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlLoginRecordRepository struct {
+	s *Store
+}
+
+func (r *sqlLoginRecordRepository) Create(ctx context.Context, rec *LoginRecord) error {
+	if rec.LoginTime == "" {
+		rec.LoginTime = Now()
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO login_records (username, ip, login_time, role) VALUES (?, ?, ?, ?) RETURNING id`),
+		rec.Username, rec.IP, rec.LoginTime, rec.Role).Scan(&rec.ID)
+}
+
+func (r *sqlLoginRecordRepository) Get(ctx context.Context, id int64) (*LoginRecord, error) {
+	rec := LoginRecord{ID: id}
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COALESCE(username, ''), COALESCE(ip, ''), COALESCE(login_time, ''), COALESCE(role, '')
+		FROM login_records WHERE id = ?`), id).
+		Scan(&rec.Username, &rec.IP, &rec.LoginTime, &rec.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+type sqlUploadRecordRepository struct {
+	s *Store
+}
+
+func (r *sqlUploadRecordRepository) Create(ctx context.Context, rec *UploadRecord) error {
+	if rec.UploadTime == "" {
+		rec.UploadTime = Now()
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO upload_records (filename, path, upload_time) VALUES (?, ?, ?) RETURNING id`),
+		rec.Filename, rec.Path, rec.UploadTime).Scan(&rec.ID)
+}
+
+func (r *sqlUploadRecordRepository) Get(ctx context.Context, id int64) (*UploadRecord, error) {
+	rec := UploadRecord{ID: id}
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COALESCE(filename, ''), COALESCE(path, ''), COALESCE(upload_time, '')
+		FROM upload_records WHERE id = ?`), id).
+		Scan(&rec.Filename, &rec.Path, &rec.UploadTime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
@@ -0,0 +1,394 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+const materialColumns = `id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(category, ''), COALESCE(tags, ''), COALESCE(files, ''),
+	COALESCE(status, ''), COALESCE(uploader, ''), COALESCE(uploadTime, ''), COALESCE(reviewer, ''), COALESCE(reviewTime, ''),
+	COALESCE(reviewComment, ''), COALESCE(aiScore, 0), COALESCE(aiConfidence, 0), COALESCE(aiSuggestions, ''), COALESCE(aiRiskLevel, ''),
+	reviewRound, revision, COALESCE(deletedAt, ''), COALESCE(deletedBy, ''), COALESCE(awardDate, ''), COALESCE(cutoffFlag, '')`
+
+type sqlMaterialRepository struct {
+	s *Store
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMaterial(row rowScanner) (*Material, error) {
+	var m Material
+	err := row.Scan(&m.ID, &m.Title, &m.Description, &m.Category, &m.Tags, &m.Files,
+		&m.Status, &m.Uploader, &m.UploadTime, &m.Reviewer, &m.ReviewTime,
+		&m.ReviewComment, &m.AiScore, &m.AiConfidence, &m.AiSuggestions, &m.AiRiskLevel,
+		&m.ReviewRound, &m.Revision, &m.DeletedAt, &m.DeletedBy, &m.AwardDate, &m.CutoffFlag)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *sqlMaterialRepository) Create(ctx context.Context, m *Material) error {
+	if m.UploadTime == "" {
+		m.UploadTime = Now()
+	}
+	if m.ReviewRound == 0 {
+		m.ReviewRound = 1
+	}
+	if m.Revision == 0 {
+		m.Revision = 1
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		_, err := tx.conn().ExecContext(ctx, tx.Rebind(`INSERT INTO materials (
+			id, title, description, category, tags, files, status, uploader, uploadTime,
+			reviewer, reviewTime, reviewComment, aiScore, aiConfidence, aiSuggestions, aiRiskLevel, reviewRound, revision,
+			awardDate, cutoffFlag
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			m.ID, m.Title, m.Description, m.Category, m.Tags, m.Files, m.Status, m.Uploader, m.UploadTime,
+			m.Reviewer, m.ReviewTime, m.ReviewComment, m.AiScore, m.AiConfidence, m.AiSuggestions, m.AiRiskLevel, m.ReviewRound, m.Revision,
+			m.AwardDate, m.CutoffFlag)
+		if err != nil {
+			return err
+		}
+		err = insertRevision(ctx, tx, &MaterialRevision{
+			MaterialId: m.ID, Revision: m.Revision, Title: m.Title, Description: m.Description, Category: m.Category,
+			Tags: m.Tags, Files: m.Files, Editor: m.Uploader, CreatedAt: m.UploadTime,
+		})
+		if err != nil {
+			return err
+		}
+		return insertTransition(ctx, tx, &MaterialTransition{
+			MaterialId: m.ID, To: m.Status, Action: "create", Actor: m.Uploader, CreatedAt: m.UploadTime,
+		})
+	})
+}
+
+func (r *sqlMaterialRepository) Get(ctx context.Context, id string) (*Material, error) {
+	m, err := scanMaterial(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+materialColumns+` FROM materials WHERE id = ? AND deletedAt IS NULL`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+func (r *sqlMaterialRepository) GetDeleted(ctx context.Context, id string) (*Material, error) {
+	m, err := scanMaterial(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+materialColumns+` FROM materials WHERE id = ? AND deletedAt IS NOT NULL`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+func (r *sqlMaterialRepository) GetForUpdate(ctx context.Context, id string) (*Material, error) {
+	// 空更新在 PostgreSQL 中取得行锁，在 SQLite 中取得写锁，两种方言都能阻止并发审核交错
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE materials SET reviewRound = reviewRound WHERE id = ? AND deletedAt IS NULL`), id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(result); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *sqlMaterialRepository) List(ctx context.Context, filter MaterialFilter) ([]Material, error) {
+	where := make([]string, 0, 4)
+	args := make([]interface{}, 0, 4)
+	if filter.Deleted {
+		where = append(where, "deletedAt IS NOT NULL")
+		if filter.DeletedBefore != "" {
+			where = append(where, "deletedAt < ?")
+			args = append(args, filter.DeletedBefore)
+		}
+	} else {
+		where = append(where, "deletedAt IS NULL")
+	}
+	if filter.Uploader != "" {
+		where = append(where, "uploader = ?")
+		args = append(args, filter.Uploader)
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ")+")")
+		for _, st := range filter.Statuses {
+			args = append(args, st)
+		}
+	}
+	if filter.UploadedFrom != "" {
+		where = append(where, "uploadTime >= ?")
+		args = append(args, filter.UploadedFrom)
+	}
+	if filter.UploadedBefore != "" {
+		where = append(where, "uploadTime < ?")
+		args = append(args, filter.UploadedBefore)
+	}
+	if filter.AssignedTo != "" {
+		where = append(where, "id IN (SELECT materialId FROM review_assignments WHERE reviewer = ? AND status = ?)")
+		args = append(args, filter.AssignedTo, AssignmentActive)
+	}
+	query := `SELECT ` + materialColumns + ` FROM materials WHERE ` + strings.Join(where, " AND ")
+	query += " ORDER BY uploadTime"
+
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	materials := make([]Material, 0)
+	for rows.Next() {
+		m, err := scanMaterial(rows)
+		if err != nil {
+			return nil, err
+		}
+		materials = append(materials, *m)
+	}
+	return materials, rows.Err()
+}
+
+func (r *sqlMaterialRepository) Transition(ctx context.Context, t *MaterialTransition) error {
+	if t.CreatedAt == "" {
+		t.CreatedAt = Now()
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		query := `UPDATE materials SET status = ? WHERE id = ? AND status = ? AND deletedAt IS NULL`
+		if t.NextRound {
+			query = `UPDATE materials SET status = ?, reviewRound = reviewRound + 1 WHERE id = ? AND status = ? AND deletedAt IS NULL`
+		}
+		result, err := tx.conn().ExecContext(ctx, tx.Rebind(query), t.To, t.MaterialId, t.From)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if _, err := tx.Materials.Get(ctx, t.MaterialId); err != nil {
+				return err
+			}
+			return ErrConflict
+		}
+		return insertTransition(ctx, tx, t)
+	})
+}
+
+func insertTransition(ctx context.Context, s *Store, t *MaterialTransition) error {
+	if t.CreatedAt == "" {
+		t.CreatedAt = Now()
+	}
+	return s.conn().QueryRowContext(ctx, s.Rebind(`INSERT INTO material_status_history (materialId, fromStatus, toStatus, action, actor, reason, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		t.MaterialId, t.From, t.To, t.Action, t.Actor, t.Reason, t.CreatedAt).Scan(&t.ID)
+}
+
+func (r *sqlMaterialRepository) History(ctx context.Context, id string) ([]MaterialTransition, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, materialId, COALESCE(fromStatus, ''), toStatus, action,
+		COALESCE(actor, ''), COALESCE(reason, ''), createdAt
+		FROM material_status_history WHERE materialId = ? ORDER BY id`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]MaterialTransition, 0)
+	for rows.Next() {
+		var t MaterialTransition
+		if err := rows.Scan(&t.ID, &t.MaterialId, &t.From, &t.To, &t.Action, &t.Actor, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, t)
+	}
+	return history, rows.Err()
+}
+
+func (r *sqlMaterialRepository) Revise(ctx context.Context, rev *MaterialRevision) error {
+	if rev.CreatedAt == "" {
+		rev.CreatedAt = Now()
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		result, err := tx.conn().ExecContext(ctx, tx.Rebind(`UPDATE materials SET title = ?, description = ?, category = ?, tags = ?, files = ?,
+			revision = ? WHERE id = ? AND revision = ? AND deletedAt IS NULL`),
+			rev.Title, rev.Description, rev.Category, rev.Tags, rev.Files, rev.Revision, rev.MaterialId, rev.Revision-1)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if _, err := tx.Materials.Get(ctx, rev.MaterialId); err != nil {
+				return err
+			}
+			return ErrConflict
+		}
+		return insertRevision(ctx, tx, rev)
+	})
+}
+
+func insertRevision(ctx context.Context, s *Store, rev *MaterialRevision) error {
+	return s.conn().QueryRowContext(ctx, s.Rebind(`INSERT INTO material_revisions (materialId, revision, title, description, category, tags, files, editor, note, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		rev.MaterialId, rev.Revision, rev.Title, rev.Description, rev.Category, rev.Tags, rev.Files, rev.Editor, rev.Note, rev.CreatedAt).Scan(&rev.ID)
+}
+
+const materialRevisionColumns = `id, materialId, revision, COALESCE(title, ''), COALESCE(description, ''), COALESCE(category, ''),
+	COALESCE(tags, ''), COALESCE(files, ''), COALESCE(editor, ''), COALESCE(note, ''), createdAt`
+
+func scanMaterialRevision(row rowScanner) (*MaterialRevision, error) {
+	var rev MaterialRevision
+	err := row.Scan(&rev.ID, &rev.MaterialId, &rev.Revision, &rev.Title, &rev.Description, &rev.Category,
+		&rev.Tags, &rev.Files, &rev.Editor, &rev.Note, &rev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (r *sqlMaterialRepository) Revisions(ctx context.Context, id string) ([]MaterialRevision, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+materialRevisionColumns+` FROM material_revisions WHERE materialId = ? ORDER BY revision`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]MaterialRevision, 0)
+	for rows.Next() {
+		rev, err := scanMaterialRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *sqlMaterialRepository) GetRevision(ctx context.Context, id string, revision int) (*MaterialRevision, error) {
+	rev, err := scanMaterialRevision(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+materialRevisionColumns+`
+		FROM material_revisions WHERE materialId = ? AND revision = ?`), id, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rev, err
+}
+
+func (r *sqlMaterialRepository) UpdateReviewSummary(ctx context.Context, id, reviewer, reviewTime, comment string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE materials SET reviewer = ?, reviewTime = ?, reviewComment = ? WHERE id = ?`),
+		reviewer, reviewTime, comment, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlMaterialRepository) UpdateAssessment(ctx context.Context, m *Material) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE materials SET aiScore = ?, aiConfidence = ?, aiSuggestions = ?, aiRiskLevel = ?,
+		awardDate = ?, cutoffFlag = ? WHERE id = ? AND deletedAt IS NULL`),
+		m.AiScore, m.AiConfidence, m.AiSuggestions, m.AiRiskLevel, m.AwardDate, m.CutoffFlag, m.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlMaterialRepository) SoftDelete(ctx context.Context, id, deletedBy string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE materials SET deletedAt = ?, deletedBy = ? WHERE id = ? AND deletedAt IS NULL`),
+		Now(), deletedBy, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlMaterialRepository) Restore(ctx context.Context, id string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE materials SET deletedAt = NULL, deletedBy = NULL WHERE id = ? AND deletedAt IS NOT NULL`), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// materialChildTables 以 materialId 关联材料、随材料永久删除的表
+var materialChildTables = []string{"material_reviews", "material_status_history", "material_revisions", "review_assignments", "appeals", "material_records"}
+
+func (r *sqlMaterialRepository) Purge(ctx context.Context, id string) error {
+	return r.s.InTx(ctx, func(tx *Store) error {
+		result, err := tx.conn().ExecContext(ctx, tx.Rebind(`DELETE FROM materials WHERE id = ? AND deletedAt IS NOT NULL`), id)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result); err != nil {
+			return err
+		}
+		for _, table := range materialChildTables {
+			if _, err := tx.conn().ExecContext(ctx, tx.Rebind(`DELETE FROM `+table+` WHERE materialId = ?`), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqlMaterialRepository) FileLists(ctx context.Context) ([]string, error) {
+	rows, err := r.s.conn().QueryContext(ctx, `SELECT files FROM materials WHERE files IS NOT NULL
+		UNION ALL SELECT files FROM material_revisions WHERE files IS NOT NULL
+		UNION ALL SELECT files FROM appeals WHERE files IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]string, 0)
+	for rows.Next() {
+		var files string
+		if err := rows.Scan(&files); err != nil {
+			return nil, err
+		}
+		lists = append(lists, files)
+	}
+	return lists, rows.Err()
+}
+
+func (r *sqlMaterialRepository) Statistics(ctx context.Context, uploader string) (*MaterialStatistics, error) {
+	where := " WHERE deletedAt IS NULL"
+	args := make([]interface{}, 0, 1)
+	if uploader != "" {
+		where += " AND uploader = ?"
+		args = append(args, uploader)
+	}
+
+	stats := &MaterialStatistics{ByStatus: make(map[string]int), ByCategory: make(map[string]int)}
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT COALESCE(category, ''), COALESCE(status, ''), COUNT(*) FROM materials`+where+` GROUP BY category, status`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category, status string
+		var count int
+		if err := rows.Scan(&category, &status, &count); err != nil {
+			return nil, err
+		}
+		stats.Total += count
+		stats.ByCategory[category] += count
+		stats.ByStatus[status] += count
+	}
+	return stats, rows.Err()
+}
+
+// requireAffected 更新或删除未命中任何行时返回 ErrNotFound
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlMaterialRecordRepository struct {
+	s *Store
+}
+
+func (r *sqlMaterialRecordRepository) Exists(ctx context.Context, materialId string) (bool, error) {
+	var count int
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COUNT(1) FROM material_records WHERE materialId = ?`), materialId).Scan(&count)
+	return count > 0, err
+}
+
+const materialRecordColumns = `materialId, accountId, COALESCE(type, ''), COALESCE(category, ''), COALESCE(id, ''),
+	COALESCE(project, ''), COALESCE(awardDate, ''), COALESCE(awardType, ''), COALESCE(teamRank, ''), COALESCE(selfScore, 0),
+	COALESCE(scoreBasis, ''), COALESCE(collegeScore, 0), COALESCE(facts, '')`
+
+func scanMaterialRecord(row rowScanner) (*MaterialRecord, error) {
+	var rec MaterialRecord
+	if err := row.Scan(&rec.MaterialId, &rec.AccountId, &rec.Type, &rec.Category, &rec.Id, &rec.Project,
+		&rec.AwardDate, &rec.AwardType, &rec.TeamRank, &rec.SelfScore, &rec.ScoreBasis, &rec.CollegeScore, &rec.Facts); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *sqlMaterialRecordRepository) Get(ctx context.Context, materialId string) (*MaterialRecord, error) {
+	rec, err := scanMaterialRecord(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+materialRecordColumns+` FROM material_records WHERE materialId = ?`), materialId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rec, err
+}
+
+func (r *sqlMaterialRecordRepository) Save(ctx context.Context, rec *MaterialRecord) error {
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO material_records (
+		materialId, accountId, type, category, id, project, awardDate, awardType, teamRank, selfScore, scoreBasis, collegeScore, facts
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (materialId) DO UPDATE SET
+		accountId = excluded.accountId, type = excluded.type, category = excluded.category, id = excluded.id,
+		project = excluded.project, awardDate = excluded.awardDate, awardType = excluded.awardType, teamRank = excluded.teamRank,
+		selfScore = excluded.selfScore, scoreBasis = excluded.scoreBasis, collegeScore = excluded.collegeScore, facts = excluded.facts`),
+		rec.MaterialId, rec.AccountId, rec.Type, rec.Category, rec.Id, rec.Project,
+		rec.AwardDate, rec.AwardType, rec.TeamRank, rec.SelfScore, rec.ScoreBasis, rec.CollegeScore, rec.Facts)
+	return err
+}
+
+func (r *sqlMaterialRecordRepository) ListByAccount(ctx context.Context, accountId string) ([]MaterialRecord, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+materialRecordColumns+` FROM material_records WHERE accountId = ?
+		AND materialId IN (SELECT id FROM materials WHERE deletedAt IS NULL AND status = 'approved') ORDER BY materialId`), accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]MaterialRecord, 0)
+	for rows.Next() {
+		rec, err := scanMaterialRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *rec)
+	}
+	return records, rows.Err()
+}
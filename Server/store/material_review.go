@@ -0,0 +1,59 @@
+package store
+
+import "context"
+
+type sqlMaterialReviewRepository struct {
+	s *Store
+}
+
+func (r *sqlMaterialReviewRepository) Create(ctx context.Context, rv *MaterialReview) error {
+	if rv.CreatedAt == "" {
+		rv.CreatedAt = Now()
+	}
+	if rv.Revision == 0 {
+		rv.Revision = 1
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO material_reviews (materialId, reviewer, decision, comment, round, revision, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		rv.MaterialId, rv.Reviewer, rv.Decision, rv.Comment, rv.Round, rv.Revision, rv.CreatedAt).Scan(&rv.ID)
+}
+
+func (r *sqlMaterialReviewRepository) ListByMaterial(ctx context.Context, materialId string) ([]MaterialReview, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, materialId, reviewer, decision, COALESCE(comment, ''), round, revision, createdAt
+		FROM material_reviews WHERE materialId = ? ORDER BY id`), materialId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]MaterialReview, 0)
+	for rows.Next() {
+		var rv MaterialReview
+		if err := rows.Scan(&rv.ID, &rv.MaterialId, &rv.Reviewer, &rv.Decision, &rv.Comment, &rv.Round, &rv.Revision, &rv.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *sqlMaterialReviewRepository) DecidedRounds(ctx context.Context, reviewer string) (map[string]int, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT rv.materialId, MAX(rv.round) FROM material_reviews rv
+		JOIN materials m ON m.id = rv.materialId AND m.revision = rv.revision
+		WHERE rv.reviewer = ? AND rv.decision <> 'comment' GROUP BY rv.materialId`), reviewer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rounds := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var round int
+		if err := rows.Scan(&id, &round); err != nil {
+			return nil, err
+		}
+		rounds[id] = round
+	}
+	return rounds, rows.Err()
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"strings"
+)
+
+type sqlMessageRepository struct {
+	s *Store
+}
+
+func (r *sqlMessageRepository) Create(ctx context.Context, m *Message) error {
+	if m.Time == "" {
+		m.Time = Now()
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO messages (accountId, type, title, subTitle, avatar, content, time, status, messageType)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		m.AccountId, m.Type, m.Title, m.SubTitle, m.Avatar, m.Content, m.Time, m.Status, m.MessageType).Scan(&m.ID)
+}
+
+func (r *sqlMessageRepository) ListByAccount(ctx context.Context, accountId string) ([]Message, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, accountId, COALESCE(type, ''), COALESCE(title, ''), COALESCE(subTitle, ''),
+		COALESCE(avatar, ''), COALESCE(content, ''), COALESCE(time, ''), status, COALESCE(messageType, 0)
+		FROM messages WHERE accountId = ? ORDER BY time DESC, id DESC`), accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]Message, 0)
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.AccountId, &m.Type, &m.Title, &m.SubTitle,
+			&m.Avatar, &m.Content, &m.Time, &m.Status, &m.MessageType); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *sqlMessageRepository) MarkRead(ctx context.Context, accountId string, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, accountId)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE messages SET status = 1 WHERE accountId = ? AND id IN (`+placeholders+`)`), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles 迁移脚本，按方言分目录存放，文件名格式为 <序号>_<说明>.sql，按序号升序执行
+// 新增迁移时 sqlite 与 postgres 目录需同时提供同序号的脚本
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// migrationDirs 各方言对应的迁移目录
+var migrationDirs = map[Dialect]string{
+	DialectSQLite:   "migrations/sqlite",
+	DialectPostgres: "migrations/postgres",
+}
+
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func loadMigrations(dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("迁移文件名缺少序号: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("迁移文件序号无效: %s", name)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("迁移序号重复: %s 与 %s", other, name)
+		}
+		seen[version] = name
+
+		content, err := migrationFiles.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{Version: version, Name: name, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// SchemaVersion 返回当前已应用的最高迁移序号
+func (s *Store) SchemaVersion() (int, error) {
+	var version int
+	err := s.DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// Migrate 在事务中依次执行尚未应用的迁移，并记录到 schema_version
+func (s *Store) Migrate() error {
+	if _, err := s.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT,
+		appliedAt TEXT
+	)`); err != nil {
+		return fmt.Errorf("创建 schema_version 表失败: %w", err)
+	}
+
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations(migrationDirs[s.Dialect])
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		tx, err := s.DB.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移 %s 失败: %w", m.Name, err)
+		}
+		if _, err := tx.Exec(s.Rebind(`INSERT INTO schema_version (version, name, appliedAt) VALUES (?, ?, ?)`), m.Version, m.Name, Now()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("[数据库] 已应用迁移 %s", m.Name)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrateStudentUniqueId 旧版导入留下的重复学号在迁移时合并为最近导入的一行
+func TestMigrateStudentUniqueId(t *testing.T) {
+	s, err := Open(DialectSQLite, filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// 回到 0015 之前的结构，写入重复学号
+	for _, q := range []string{
+		`DROP INDEX uq_students_student_id`,
+		`CREATE INDEX idx_students_student_id ON students (studentId)`,
+		`DELETE FROM schema_version WHERE version >= 15`,
+		`INSERT INTO students (name, studentId, major, class, score, gpa, graduationYear) VALUES ('张三', '1001', '计算机', '一班', 80, 3.5, 2026)`,
+		`INSERT INTO students (name, studentId, major, class) VALUES ('李四', '1002', '软件工程', '二班')`,
+		`INSERT INTO students (name, studentId, major, class, score) VALUES ('张三', '1001', '人工智能', '', 85)`,
+		`INSERT INTO students (name, studentId, major) VALUES ('无学号', NULL, '计算机')`,
+		`INSERT INTO students (name, studentId, major) VALUES ('无学号', NULL, '计算机')`,
+	} {
+		if _, err := s.DB.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows, nulls int
+	if err := s.DB.QueryRow(`SELECT COUNT(*), COUNT(*) - COUNT(studentId) FROM students`).Scan(&rows, &nulls); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 4 || nulls != 2 {
+		t.Errorf("迁移后共 %d 行，其中 %d 行没有学号，want 4 与 2", rows, nulls)
+	}
+	st, err := s.Students.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 专业以最近导入为准，最近一次未填写的班级、绩点与毕业年份沿用之前的值
+	if st.Major != "人工智能" || st.Class != "一班" || st.GPA != 3.5 || st.GraduationYear != 2026 {
+		t.Errorf("合并后的学生 %+v", st)
+	}
+	if _, err := s.Exec(`INSERT INTO students (name, studentId) VALUES ('张三', '1001')`); err == nil {
+		t.Error("迁移后重复学号应写入失败")
+	}
+}
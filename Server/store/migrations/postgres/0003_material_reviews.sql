@@ -0,0 +1,49 @@
+-- 0003 材料审核状态机：审核轮次、逐条审核意见与状态变更历史（PostgreSQL）
+
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS reviewRound INTEGER NOT NULL DEFAULT 1;
+
+-- 每位审核人的每条意见，comment 仅留言不计票
+CREATE TABLE IF NOT EXISTS material_reviews (
+	id BIGSERIAL PRIMARY KEY,
+	materialId TEXT NOT NULL,
+	reviewer TEXT NOT NULL,
+	decision TEXT NOT NULL,
+	comment TEXT,
+	round INTEGER NOT NULL DEFAULT 1,
+	createdAt TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_reviews_material ON material_reviews (materialId, round);
+CREATE INDEX IF NOT EXISTS idx_material_reviews_reviewer ON material_reviews (reviewer);
+
+-- 同一轮次中每位审核人只能给出一次通过或驳回
+CREATE UNIQUE INDEX IF NOT EXISTS uq_material_reviews_decision ON material_reviews (materialId, round, reviewer) WHERE decision <> 'comment';
+
+-- 每次状态变更一行，fromStatus 为空表示材料创建
+CREATE TABLE IF NOT EXISTS material_status_history (
+	id BIGSERIAL PRIMARY KEY,
+	materialId TEXT NOT NULL,
+	fromStatus TEXT,
+	toStatus TEXT NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT,
+	reason TEXT,
+	createdAt TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_status_history_material ON material_status_history (materialId);
+
+-- 旧版逗号分隔的 reviewer 列迁入审核意见：pending 与 approved 材料中的审核人都投了通过票
+-- （旧版任一驳回会立即结束审核，rejected 材料无法区分各人意见，不做迁移）
+INSERT INTO material_reviews (materialId, reviewer, decision, comment, round, createdAt)
+SELECT DISTINCT m.id, trim(r.reviewer), 'approve', '', 1, COALESCE(NULLIF(m.reviewTime, ''), m.uploadTime, '')
+FROM materials m
+CROSS JOIN LATERAL unnest(string_to_array(m.reviewer, ',')) AS r(reviewer)
+WHERE m.status IN ('pending', 'approved') AND COALESCE(m.reviewer, '') <> '' AND trim(r.reviewer) <> '';
+
+UPDATE materials SET status = 'under_review' WHERE status = 'pending' AND COALESCE(reviewer, '') <> '';
+UPDATE materials SET status = 'submitted' WHERE status = 'pending' OR status IS NULL OR status = '';
+UPDATE materials SET status = 'draft' WHERE status = 'filled';
+
+INSERT INTO material_status_history (materialId, fromStatus, toStatus, action, actor, reason, createdAt)
+SELECT id, '', status, 'migrate', '', '旧版数据迁移', COALESCE(NULLIF(reviewTime, ''), uploadTime, '') FROM materials;
@@ -0,0 +1,18 @@
+-- 0004 按材料类别配置的审核结论规则（PostgreSQL）
+
+-- category 为 '*' 的一行是未单独配置类别时的默认规则
+CREATE TABLE IF NOT EXISTS review_policies (
+	category TEXT PRIMARY KEY,
+	requiredApprovals INTEGER NOT NULL,
+	quorum INTEGER NOT NULL DEFAULT 0,
+	rejectionMode TEXT NOT NULL,
+	voteRule TEXT NOT NULL,
+	tieBreak TEXT NOT NULL,
+	updatedBy TEXT,
+	updatedAt TEXT
+);
+
+-- 默认规则与旧版硬编码一致：三票通过，任一驳回即驳回
+INSERT INTO review_policies (category, requiredApprovals, quorum, rejectionMode, voteRule, tieBreak, updatedBy, updatedAt)
+VALUES ('*', 3, 0, 'veto', 'unanimity', 'escalate', '', '')
+ON CONFLICT (category) DO NOTHING;
@@ -0,0 +1,18 @@
+-- 0006 审核阶段截止时间、审核时限与超时升级（PostgreSQL）
+
+-- 每个阶段一行：submission 提交窗口，review 审核窗口；closesAt 为空表示不设截止时间
+-- slaHours 为单条审核分配的处理时限（小时），0 表示不限，目前只对 review 阶段生效
+CREATE TABLE IF NOT EXISTS review_phases (
+	phase TEXT PRIMARY KEY,
+	closesAt TEXT,
+	slaHours INTEGER NOT NULL DEFAULT 0,
+	updatedBy TEXT,
+	updatedAt TEXT
+);
+
+INSERT INTO review_phases (phase, closesAt, slaHours, updatedBy, updatedAt)
+VALUES ('submission', '', 0, '', ''), ('review', '', 72, '', '')
+ON CONFLICT (phase) DO NOTHING;
+
+-- 超时后已升级（追加审核人或通知管理员）的时间，避免重复升级
+ALTER TABLE review_assignments ADD COLUMN IF NOT EXISTS escalatedAt TEXT;
@@ -0,0 +1,7 @@
+-- 0009 材料软删除与回收站（PostgreSQL）
+
+-- deletedAt 不为空的材料在回收站中，保留期满后由清理任务永久删除
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS deletedAt TEXT;
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS deletedBy TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_materials_deleted ON materials (deletedAt);
@@ -0,0 +1,16 @@
+-- 0015 学生名单按学号唯一（PostgreSQL）
+
+-- 旧版导入会为同一学号重复插入多行：保留最近导入的一行，各列取最近一次填写的值，其余行删除
+UPDATE students SET
+	name = COALESCE(NULLIF(name, ''), (SELECT g.name FROM students g WHERE g.studentId = students.studentId AND COALESCE(g.name, '') <> '' ORDER BY g.id DESC LIMIT 1)),
+	major = COALESCE(NULLIF(TRIM(major), ''), (SELECT g.major FROM students g WHERE g.studentId = students.studentId AND TRIM(COALESCE(g.major, '')) <> '' ORDER BY g.id DESC LIMIT 1)),
+	class = COALESCE(NULLIF(TRIM(class), ''), (SELECT g.class FROM students g WHERE g.studentId = students.studentId AND TRIM(COALESCE(g.class, '')) <> '' ORDER BY g.id DESC LIMIT 1)),
+	score = COALESCE(score, (SELECT g.score FROM students g WHERE g.studentId = students.studentId AND g.score IS NOT NULL ORDER BY g.id DESC LIMIT 1)),
+	gpa = COALESCE(gpa, (SELECT g.gpa FROM students g WHERE g.studentId = students.studentId AND g.gpa IS NOT NULL ORDER BY g.id DESC LIMIT 1)),
+	graduationYear = COALESCE(NULLIF(graduationYear, 0), (SELECT g.graduationYear FROM students g WHERE g.studentId = students.studentId AND COALESCE(g.graduationYear, 0) > 0 ORDER BY g.id DESC LIMIT 1))
+WHERE id IN (SELECT MAX(id) FROM students WHERE studentId IS NOT NULL GROUP BY studentId HAVING COUNT(*) > 1);
+
+DELETE FROM students WHERE studentId IS NOT NULL AND id NOT IN (SELECT MAX(id) FROM students WHERE studentId IS NOT NULL GROUP BY studentId);
+
+DROP INDEX IF EXISTS idx_students_student_id;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_students_student_id ON students (studentId);
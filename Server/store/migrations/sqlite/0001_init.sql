@@ -0,0 +1,193 @@
+-- 0001 统一数据库初始结构，合并原 user_info.db / hci.db / app.db / students.db 中的表
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT UNIQUE,
+	password TEXT,
+	name TEXT,
+	avatar TEXT,
+	job TEXT,
+	organization TEXT,
+	location TEXT,
+	email TEXT,
+	introduction TEXT,
+	personalWebsite TEXT,
+	jobName TEXT,
+	organizationName TEXT,
+	locationName TEXT,
+	phone TEXT,
+	registrationDate TEXT,
+	accountId TEXT UNIQUE,
+	certification INTEGER,
+	role TEXT,
+	mustChangePassword INTEGER DEFAULT 0,
+	updateTime DATETIME DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS login_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT,
+	ip TEXT,
+	login_time DATETIME,
+	role TEXT
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	tokenHash TEXT PRIMARY KEY,
+	accountId TEXT,
+	username TEXT,
+	role TEXT,
+	ip TEXT,
+	createdAt DATETIME DEFAULT (datetime('now')),
+	expiresAt DATETIME,
+	revokedAt DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_account ON sessions (accountId);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	accountId TEXT,
+	role TEXT,
+	method TEXT,
+	path TEXT,
+	permission TEXT,
+	decision TEXT,
+	ip TEXT,
+	time DATETIME DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS materials (
+	id TEXT PRIMARY KEY,
+	title TEXT,
+	description TEXT,
+	category TEXT,
+	tags TEXT,
+	files TEXT, -- 存储多文件信息的JSON字符串
+	status TEXT,
+	uploader TEXT,
+	uploadTime TEXT,
+	reviewer TEXT,
+	reviewTime TEXT,
+	reviewComment TEXT,
+	aiScore REAL,
+	aiConfidence REAL,
+	aiSuggestions TEXT,
+	aiRiskLevel TEXT,
+	fileSize INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_materials_uploader ON materials (uploader);
+CREATE INDEX IF NOT EXISTS idx_materials_status ON materials (status);
+
+CREATE TABLE IF NOT EXISTS material_records (
+	materialId TEXT PRIMARY KEY,
+	accountId TEXT,
+	type TEXT,
+	category TEXT,
+	id TEXT,
+	project TEXT,
+	awardDate TEXT,
+	awardType TEXT,
+	teamRank TEXT,
+	selfScore REAL,
+	scoreBasis TEXT,
+	collegeScore REAL
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_records_account ON material_records (accountId);
+
+CREATE TABLE IF NOT EXISTS file_map (
+	md5 TEXT PRIMARY KEY,
+	filename TEXT,
+	file_id TEXT,
+	url TEXT
+);
+
+CREATE TABLE IF NOT EXISTS upload_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	filename TEXT,
+	path TEXT,
+	upload_time DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS user_activities (
+	id TEXT PRIMARY KEY,
+	userId TEXT,
+	type TEXT,
+	title TEXT,
+	timestamp TEXT,
+	description TEXT,
+	status TEXT
+);
+
+CREATE TABLE IF NOT EXISTS user_projects (
+	id TEXT PRIMARY KEY,
+	name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS user_teams (
+	id TEXT PRIMARY KEY,
+	name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT,
+	description TEXT,
+	peopleNumber INTEGER,
+	ownerAccountId TEXT
+);
+
+CREATE TABLE IF NOT EXISTS project_contributors (
+	projectId INTEGER,
+	name TEXT,
+	email TEXT,
+	avatar TEXT
+);
+
+CREATE TABLE IF NOT EXISTS teams (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	avatar TEXT,
+	name TEXT,
+	peopleNumber INTEGER,
+	ownerAccountId TEXT
+);
+
+CREATE TABLE IF NOT EXISTS enterprise_certification (
+	accountId TEXT PRIMARY KEY,
+	accountType INTEGER,
+	status INTEGER,
+	time TEXT,
+	legalPerson TEXT,
+	certificateType TEXT,
+	authenticationNumber TEXT,
+	enterpriseName TEXT
+);
+
+CREATE TABLE IF NOT EXISTS certification_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	accountId TEXT,
+	certificationType INTEGER,
+	certificationContent TEXT,
+	status INTEGER,
+	time TEXT
+);
+
+CREATE TABLE IF NOT EXISTS students (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT,
+	studentId TEXT,
+	major TEXT,
+	class TEXT,
+	score REAL
+);
+
+CREATE INDEX IF NOT EXISTS idx_students_student_id ON students (studentId);
+
+CREATE TABLE IF NOT EXISTS submit_material_batch (
+	id TEXT PRIMARY KEY,
+	name TEXT,
+	status TEXT,
+	reviewer TEXT
+);
@@ -0,0 +1,16 @@
+-- 0002 消息中心
+
+CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	accountId TEXT NOT NULL,
+	type TEXT,
+	title TEXT,
+	subTitle TEXT,
+	avatar TEXT,
+	content TEXT,
+	time TEXT,
+	status INTEGER NOT NULL DEFAULT 0,
+	messageType INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_account ON messages (accountId);
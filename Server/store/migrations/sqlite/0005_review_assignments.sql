@@ -0,0 +1,36 @@
+-- 0005 审核任务分配与审核人可用状态
+
+-- 每份材料每轮分配给若干审核人：active 待审核，done 已给出结论，released 已收回（改派或材料退出审核）
+CREATE TABLE IF NOT EXISTS review_assignments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	materialId TEXT NOT NULL,
+	round INTEGER NOT NULL DEFAULT 1,
+	reviewer TEXT NOT NULL,
+	status TEXT NOT NULL,
+	assignedBy TEXT,
+	assignedAt TEXT NOT NULL,
+	closedAt TEXT,
+	reason TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_assignments_material ON review_assignments (materialId, round);
+CREATE INDEX IF NOT EXISTS idx_review_assignments_reviewer ON review_assignments (reviewer, status);
+
+-- 同一轮次中每位审核人至多一条未收回的分配
+CREATE UNIQUE INDEX IF NOT EXISTS uq_review_assignments_open ON review_assignments (materialId, round, reviewer) WHERE status <> 'released';
+
+-- 暂停接收分配的审核人，未出现在表中的审核人视为可用
+CREATE TABLE IF NOT EXISTS reviewer_availability (
+	accountId TEXT PRIMARY KEY,
+	available INTEGER NOT NULL DEFAULT 1,
+	reason TEXT,
+	updatedBy TEXT,
+	updatedAt TEXT
+);
+
+-- 已给出结论的审核意见视为已完成的分配，待审材料由服务启动时补充分配
+INSERT INTO review_assignments (materialId, round, reviewer, status, assignedBy, assignedAt, closedAt, reason)
+SELECT materialId, round, reviewer, 'done', '', MIN(createdAt), MIN(createdAt), '旧版数据迁移'
+FROM material_reviews
+WHERE decision <> 'comment'
+GROUP BY materialId, round, reviewer;
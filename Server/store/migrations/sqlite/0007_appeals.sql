@@ -0,0 +1,23 @@
+-- 0007 学生对驳回材料的申诉
+
+-- 每次申诉对应一次驳回：round 为被驳回的审核轮次，files 为补充材料的 JSON 数组
+-- status：pending 待处理，reopened 申诉成立、材料重新进入审核，upheld 维持驳回
+CREATE TABLE IF NOT EXISTS appeals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	materialId TEXT NOT NULL,
+	round INTEGER NOT NULL,
+	appellant TEXT NOT NULL,
+	statement TEXT NOT NULL,
+	files TEXT,
+	status TEXT NOT NULL,
+	createdAt TEXT NOT NULL,
+	decidedBy TEXT,
+	decidedAt TEXT,
+	comment TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_appeals_material ON appeals (materialId);
+CREATE INDEX IF NOT EXISTS idx_appeals_status ON appeals (status);
+
+-- 同一轮驳回只能申诉一次
+CREATE UNIQUE INDEX IF NOT EXISTS uq_appeals_round ON appeals (materialId, round);
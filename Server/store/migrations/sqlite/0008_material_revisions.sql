@@ -0,0 +1,29 @@
+-- 0008 材料版本：每次修改生成不可变的版本，审核意见记录所审核的版本
+
+ALTER TABLE materials ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE material_reviews ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+-- 材料每个版本的内容快照，只追加不修改；materials 中保存最新版本
+CREATE TABLE IF NOT EXISTS material_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	materialId TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	title TEXT,
+	description TEXT,
+	category TEXT,
+	tags TEXT,
+	files TEXT,
+	editor TEXT,
+	note TEXT,
+	createdAt TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_material_revisions ON material_revisions (materialId, revision);
+
+-- 材料修改后审核人需要对新版本重新给出结论：同一轮次中每位审核人对每个版本只能给出一次通过或驳回
+DROP INDEX IF EXISTS uq_material_reviews_decision;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_material_reviews_decision ON material_reviews (materialId, round, revision, reviewer) WHERE decision <> 'comment';
+
+-- 已有材料的当前内容作为第一版
+INSERT INTO material_revisions (materialId, revision, title, description, category, tags, files, editor, note, createdAt)
+SELECT id, 1, title, description, category, tags, files, uploader, '', COALESCE(uploadTime, '') FROM materials;
@@ -0,0 +1,7 @@
+-- 0009 材料软删除与回收站
+
+-- deletedAt 不为空的材料在回收站中，保留期满后由清理任务永久删除
+ALTER TABLE materials ADD COLUMN deletedAt TEXT;
+ALTER TABLE materials ADD COLUMN deletedBy TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_materials_deleted ON materials (deletedAt);
@@ -0,0 +1,4 @@
+-- 0010 加分记录的计分要素
+
+-- facts 为大模型从材料中提取的计分要素（JSON），collegeScore 与 scoreBasis 由规则引擎据此计算
+ALTER TABLE material_records ADD COLUMN facts TEXT;
@@ -0,0 +1,27 @@
+-- 0011 按届别划分的保研条例版本
+
+-- 同一 code 的条例每次修改保存为新版本，version 从 1 递增，历史版本保留不改
+-- 适用于毕业年份在 [cohortFrom, cohortTo] 内的学生，cohortTo 为 0 表示不设上限
+-- cutoff 为成果取得的截止日（MM-DD），截止于推免当年即毕业前一年的该日
+-- journals、competitions 为附件中的学术会议和期刊目录、学业竞赛项目库，rules 为加分表（JSON）
+CREATE TABLE IF NOT EXISTS regulation_sets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	cohortFrom INTEGER NOT NULL,
+	cohortTo INTEGER NOT NULL DEFAULT 0,
+	cutoff TEXT NOT NULL,
+	guidelines TEXT NOT NULL,
+	journals TEXT,
+	competitions TEXT,
+	rules TEXT NOT NULL,
+	note TEXT,
+	createdBy TEXT,
+	createdAt TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_regulation_sets_version ON regulation_sets (code, version);
+
+-- 学生的毕业年份（届别），据此确定适用的条例版本
+ALTER TABLE students ADD COLUMN graduationYear INTEGER;
@@ -0,0 +1,21 @@
+-- 0012 结构化的期刊会议目录与学业竞赛项目库
+
+-- 每套条例（regulation_sets.code）有自己的目录，首次启动时由条例附件解析生成，之后由管理员维护
+-- kind：journal 学术会议和期刊，competition 学业竞赛；aliases 为别名的 JSON 数组
+-- class：期刊 top/A/B/C，竞赛 A+/A/A-；level 为竞赛级别；external 为 1 表示非信息学院竞赛项目
+CREATE TABLE IF NOT EXISTS catalogue_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	regulationCode TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	name TEXT NOT NULL,
+	aliases TEXT,
+	class TEXT NOT NULL,
+	level TEXT,
+	host TEXT,
+	external INTEGER NOT NULL DEFAULT 0,
+	note TEXT,
+	updatedBy TEXT,
+	updatedAt TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_catalogue_entries_name ON catalogue_entries (regulationCode, kind, name);
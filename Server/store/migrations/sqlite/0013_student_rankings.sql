@@ -0,0 +1,27 @@
+-- 0013 推免绩点与专业排名
+
+-- 学生的推免绩点（GPA），保留 4 位小数，未录入时为 NULL
+ALTER TABLE students ADD COLUMN gpa REAL;
+
+-- 每名学生最近一次计算的推免综合成绩与专业内名次，按专业整体重算
+-- academicScore 为绩点换算的学业综合成绩，academicBonus、comprehensiveBonus 为封顶后的学术专长与综合表现成绩
+-- majorRank 为专业内名次，并列学生名次相同且 tied 为 1；total 为该专业参与排名的人数
+CREATE TABLE IF NOT EXISTS student_rankings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	studentId TEXT NOT NULL,
+	name TEXT,
+	major TEXT NOT NULL,
+	gpa REAL NOT NULL,
+	academicScore REAL NOT NULL,
+	academicBonus REAL NOT NULL,
+	comprehensiveBonus REAL NOT NULL,
+	composite REAL NOT NULL,
+	majorRank INTEGER NOT NULL,
+	total INTEGER NOT NULL,
+	tied INTEGER NOT NULL DEFAULT 0,
+	regulationCode TEXT,
+	computedAt TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_student_rankings_student ON student_rankings (studentId);
+CREATE INDEX IF NOT EXISTS idx_student_rankings_major ON student_rankings (major, majorRank);
@@ -0,0 +1,20 @@
+-- 0014 推免资格审查：成绩单与材料的成果取得时间
+
+-- 由信息导入接口导入的成绩单，每名学生每学期每门课程一行，重复导入时覆盖
+-- score 为百分制成绩，两级计分等没有百分制成绩的课程为 NULL；retaken 为 1 表示重修通过
+CREATE TABLE IF NOT EXISTS transcript_courses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	studentId TEXT NOT NULL,
+	term TEXT NOT NULL DEFAULT '',
+	course TEXT NOT NULL,
+	credit REAL NOT NULL DEFAULT 0,
+	score REAL,
+	retaken INTEGER NOT NULL DEFAULT 0,
+	importedAt TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_transcript_courses_course ON transcript_courses (studentId, term, course);
+
+-- 材料的成果取得时间，晚于条例截止日期时 cutoffFlag 记录提示，供审核人在审核前看到
+ALTER TABLE materials ADD COLUMN awardDate TEXT;
+ALTER TABLE materials ADD COLUMN cutoffFlag TEXT;
@@ -0,0 +1,12 @@
+package store
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// openPostgres 连接学院共享的 PostgreSQL 数据库
+func openPostgres(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
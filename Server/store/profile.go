@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlProfileRepository struct {
+	s *Store
+}
+
+func (r *sqlProfileRepository) Activities(ctx context.Context, accountId string) ([]Activity, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, COALESCE(userId, ''), COALESCE(type, ''), COALESCE(title, ''),
+		COALESCE(timestamp, ''), COALESCE(description, ''), COALESCE(status, '')
+		FROM user_activities WHERE userId = ? ORDER BY timestamp DESC, id`), accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := make([]Activity, 0)
+	for rows.Next() {
+		var a Activity
+		if err := rows.Scan(&a.ID, &a.UserId, &a.Type, &a.Title, &a.Timestamp, &a.Description, &a.Status); err != nil {
+			return nil, err
+		}
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+func (r *sqlProfileRepository) ProjectSummaries(ctx context.Context) ([]ProfileItem, error) {
+	return r.items(ctx, `SELECT id, COALESCE(name, '') FROM user_projects ORDER BY id`)
+}
+
+func (r *sqlProfileRepository) TeamSummaries(ctx context.Context) ([]ProfileItem, error) {
+	return r.items(ctx, `SELECT id, COALESCE(name, '') FROM user_teams ORDER BY id`)
+}
+
+func (r *sqlProfileRepository) items(ctx context.Context, query string) ([]ProfileItem, error) {
+	rows, err := r.s.conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]ProfileItem, 0)
+	for rows.Next() {
+		var it ProfileItem
+		if err := rows.Scan(&it.ID, &it.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+func (r *sqlProfileRepository) Projects(ctx context.Context, owner string) ([]Project, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, COALESCE(name, ''), COALESCE(description, ''), COALESCE(peopleNumber, 0)
+		FROM projects WHERE ownerAccountId = ? ORDER BY id`), owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := make([]Project, 0)
+	index := make(map[int64]int)
+	for rows.Next() {
+		p := Project{Contributors: make([]Contributor, 0)}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.PeopleNumber); err != nil {
+			return nil, err
+		}
+		index[p.ID] = len(projects)
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if len(projects) == 0 {
+		return projects, nil
+	}
+
+	cRows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT projectId, COALESCE(name, ''), COALESCE(email, ''), COALESCE(avatar, '')
+		FROM project_contributors WHERE projectId IN (SELECT id FROM projects WHERE ownerAccountId = ?)`), owner)
+	if err != nil {
+		return nil, err
+	}
+	defer cRows.Close()
+	for cRows.Next() {
+		var projectId int64
+		var c Contributor
+		if err := cRows.Scan(&projectId, &c.Name, &c.Email, &c.Avatar); err != nil {
+			return nil, err
+		}
+		if i, ok := index[projectId]; ok {
+			projects[i].Contributors = append(projects[i].Contributors, c)
+		}
+	}
+	return projects, cRows.Err()
+}
+
+func (r *sqlProfileRepository) Teams(ctx context.Context, owner string) ([]Team, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, COALESCE(avatar, ''), COALESCE(name, ''), COALESCE(peopleNumber, 0)
+		FROM teams WHERE ownerAccountId = ? ORDER BY id`), owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]Team, 0)
+	for rows.Next() {
+		var t Team
+		if err := rows.Scan(&t.ID, &t.Avatar, &t.Name, &t.PeopleNumber); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+func (r *sqlProfileRepository) Enterprise(ctx context.Context, accountId string) (*EnterpriseCertification, error) {
+	var e EnterpriseCertification
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COALESCE(accountType, 0), COALESCE(status, 0), COALESCE(time, ''),
+		COALESCE(legalPerson, ''), COALESCE(certificateType, ''), COALESCE(authenticationNumber, ''), COALESCE(enterpriseName, '')
+		FROM enterprise_certification WHERE accountId = ?`), accountId).
+		Scan(&e.AccountType, &e.Status, &e.Time, &e.LegalPerson, &e.CertificateType, &e.AuthenticationNumber, &e.EnterpriseName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *sqlProfileRepository) CertificationRecords(ctx context.Context, accountId string) ([]CertificationRecord, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT COALESCE(certificationType, 0), COALESCE(certificationContent, ''),
+		COALESCE(status, 0), COALESCE(time, '')
+		FROM certification_records WHERE accountId = ? ORDER BY time DESC, id DESC`), accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]CertificationRecord, 0)
+	for rows.Next() {
+		var rec CertificationRecord
+		if err := rows.Scan(&rec.CertificationType, &rec.CertificationContent, &rec.Status, &rec.Time); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+type sqlSubmitBatchRepository struct {
+	s *Store
+}
+
+func (r *sqlSubmitBatchRepository) List(ctx context.Context) ([]SubmitBatch, error) {
+	rows, err := r.s.conn().QueryContext(ctx, `SELECT id, COALESCE(name, ''), COALESCE(status, ''), COALESCE(reviewer, '')
+		FROM submit_material_batch ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]SubmitBatch, 0)
+	for rows.Next() {
+		var b SubmitBatch
+		if err := rows.Scan(&b.ID, &b.Name, &b.Status, &b.Reviewer); err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
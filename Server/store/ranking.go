@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlRankingRepository struct {
+	s *Store
+}
+
+const rankingColumns = `studentId, COALESCE(name, ''), major, gpa, academicScore, academicBonus, comprehensiveBonus,
+	composite, majorRank, total, tied, COALESCE(regulationCode, ''), computedAt`
+
+func scanRanking(row rowScanner) (*Ranking, error) {
+	var (
+		rk   Ranking
+		tied int
+	)
+	err := row.Scan(&rk.StudentId, &rk.Name, &rk.Major, &rk.GPA, &rk.AcademicScore, &rk.AcademicBonus, &rk.ComprehensiveBonus,
+		&rk.Composite, &rk.Rank, &rk.Total, &tied, &rk.RegulationCode, &rk.ComputedAt)
+	if err != nil {
+		return nil, err
+	}
+	rk.Tied = tied != 0
+	return &rk, nil
+}
+
+func (r *sqlRankingRepository) ReplaceMajor(ctx context.Context, major string, rows []Ranking) error {
+	return r.s.InTx(ctx, func(tx *Store) error {
+		if _, err := tx.conn().ExecContext(ctx, tx.Rebind(`DELETE FROM student_rankings WHERE major = ?`), major); err != nil {
+			return err
+		}
+		now := Now()
+		for i := range rows {
+			rk := &rows[i]
+			rk.Major, rk.ComputedAt = major, now
+			// 学生转专业后移除其在原专业的排名
+			if _, err := tx.conn().ExecContext(ctx, tx.Rebind(`DELETE FROM student_rankings WHERE studentId = ?`), rk.StudentId); err != nil {
+				return err
+			}
+			_, err := tx.conn().ExecContext(ctx, tx.Rebind(`INSERT INTO student_rankings
+				(studentId, name, major, gpa, academicScore, academicBonus, comprehensiveBonus, composite, majorRank, total, tied, regulationCode, computedAt)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+				rk.StudentId, rk.Name, rk.Major, rk.GPA, rk.AcademicScore, rk.AcademicBonus, rk.ComprehensiveBonus,
+				rk.Composite, rk.Rank, rk.Total, boolToInt(rk.Tied), rk.RegulationCode, rk.ComputedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqlRankingRepository) ListByMajor(ctx context.Context, major string) ([]Ranking, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+rankingColumns+` FROM student_rankings
+		WHERE major = ? ORDER BY majorRank, studentId`), major)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]Ranking, 0)
+	for rows.Next() {
+		rk, err := scanRanking(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *rk)
+	}
+	return list, rows.Err()
+}
+
+func (r *sqlRankingRepository) Get(ctx context.Context, studentId string) (*Ranking, error) {
+	rk, err := scanRanking(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+rankingColumns+` FROM student_rankings
+		WHERE studentId = ?`), studentId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rk, err
+}
+
+func (r *sqlRankingRepository) Majors(ctx context.Context) ([]RankingMajor, error) {
+	rows, err := r.s.conn().QueryContext(ctx, `SELECT major, COUNT(*), MAX(computedAt) FROM student_rankings
+		GROUP BY major ORDER BY major`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	majors := make([]RankingMajor, 0)
+	for rows.Next() {
+		var m RankingMajor
+		if err := rows.Scan(&m.Major, &m.Total, &m.ComputedAt); err != nil {
+			return nil, err
+		}
+		majors = append(majors, m)
+	}
+	return majors, rows.Err()
+}
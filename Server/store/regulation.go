@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlRegulationSetRepository struct {
+	s *Store
+}
+
+const regulationSetColumns = `id, code, version, title, cohortFrom, cohortTo, cutoff, guidelines, COALESCE(journals, ''),
+	COALESCE(competitions, ''), rules, COALESCE(note, ''), COALESCE(createdBy, ''), createdAt`
+
+// regulationSetLatest 只保留每个 code 的最新版本
+const regulationSetLatest = `version = (SELECT MAX(v.version) FROM regulation_sets v WHERE v.code = regulation_sets.code)`
+
+func scanRegulationSet(row rowScanner) (*RegulationSet, error) {
+	var r RegulationSet
+	err := row.Scan(&r.ID, &r.Code, &r.Version, &r.Title, &r.CohortFrom, &r.CohortTo, &r.Cutoff, &r.Guidelines, &r.Journals,
+		&r.Competitions, &r.Rules, &r.Note, &r.CreatedBy, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *sqlRegulationSetRepository) list(ctx context.Context, query string, args ...interface{}) ([]RegulationSet, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+regulationSetColumns+` FROM regulation_sets `+query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]RegulationSet, 0)
+	for rows.Next() {
+		set, err := scanRegulationSet(rows)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, *set)
+	}
+	return sets, rows.Err()
+}
+
+func (r *sqlRegulationSetRepository) Create(ctx context.Context, set *RegulationSet) error {
+	if set.CreatedAt == "" {
+		set.CreatedAt = Now()
+	}
+	return r.s.InTx(ctx, func(tx *Store) error {
+		var latest int
+		if err := tx.conn().QueryRowContext(ctx, tx.Rebind(`SELECT COALESCE(MAX(version), 0) FROM regulation_sets WHERE code = ?`),
+			set.Code).Scan(&latest); err != nil {
+			return err
+		}
+		set.Version = latest + 1
+		return tx.conn().QueryRowContext(ctx, tx.Rebind(`INSERT INTO regulation_sets (code, version, title, cohortFrom, cohortTo, cutoff,
+			guidelines, journals, competitions, rules, note, createdBy, createdAt)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+			set.Code, set.Version, set.Title, set.CohortFrom, set.CohortTo, set.Cutoff, set.Guidelines, set.Journals,
+			set.Competitions, set.Rules, set.Note, set.CreatedBy, set.CreatedAt).Scan(&set.ID)
+	})
+}
+
+func (r *sqlRegulationSetRepository) Get(ctx context.Context, id int64) (*RegulationSet, error) {
+	set, err := scanRegulationSet(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+regulationSetColumns+` FROM regulation_sets WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return set, err
+}
+
+func (r *sqlRegulationSetRepository) Current(ctx context.Context) ([]RegulationSet, error) {
+	return r.list(ctx, `WHERE `+regulationSetLatest+` ORDER BY cohortFrom DESC, code`)
+}
+
+func (r *sqlRegulationSetRepository) Versions(ctx context.Context, code string) ([]RegulationSet, error) {
+	return r.list(ctx, `WHERE code = ? ORDER BY version DESC`, code)
+}
+
+func (r *sqlRegulationSetRepository) ForCohort(ctx context.Context, year int) (*RegulationSet, error) {
+	set, err := scanRegulationSet(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+regulationSetColumns+` FROM regulation_sets
+		WHERE `+regulationSetLatest+` AND cohortFrom <= ? AND (cohortTo = 0 OR cohortTo >= ?)
+		ORDER BY cohortFrom DESC, id DESC LIMIT 1`), year, year))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return set, err
+}
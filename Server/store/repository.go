@@ -0,0 +1,756 @@
+package store
+
+import "context"
+
+// Material 材料表中的一行，tags 与 aiSuggestions 以逗号分隔，files 为 JSON 字符串
+type Material struct {
+	ID            string
+	Title         string
+	Description   string
+	Category      string
+	Tags          string
+	Files         string
+	Status        string
+	Uploader      string
+	UploadTime    string
+	Reviewer      string
+	ReviewTime    string
+	ReviewComment string
+	AiScore       float64
+	AiConfidence  float64
+	AiSuggestions string
+	AiRiskLevel   string
+	// ReviewRound 当前审核轮次，申诉复审时加一
+	ReviewRound int
+	// Revision 当前内容的版本号，每次修改加一
+	Revision int
+	// DeletedAt 移入回收站的时间，为空表示未删除
+	DeletedAt string
+	DeletedBy string
+	// AwardDate 成果取得时间；CutoffFlag 非空表示该时间晚于条例截止日期，说明原因
+	AwardDate  string
+	CutoffFlag string
+}
+
+// MaterialFilter 材料列表查询条件，空字段表示不限
+type MaterialFilter struct {
+	Uploader string
+	Statuses []string
+	// AssignedTo 只返回分配给该审核人且尚未处理的材料
+	AssignedTo string
+	// Deleted 为 true 时只返回回收站中的材料，否则只返回未删除的材料
+	Deleted bool
+	// DeletedBefore 只返回在该时间之前移入回收站的材料，仅在 Deleted 为 true 时生效
+	DeletedBefore string
+	// UploadedFrom、UploadedBefore 限定上传时间范围 [UploadedFrom, UploadedBefore)
+	UploadedFrom   string
+	UploadedBefore string
+}
+
+// MaterialStatistics 材料数量统计
+type MaterialStatistics struct {
+	Total      int
+	ByStatus   map[string]int
+	ByCategory map[string]int
+}
+
+// MaterialTransition 一次材料状态变更
+// 写入时 From 为变更前应处于的状态，与库中不一致时返回 ErrConflict
+type MaterialTransition struct {
+	ID         int64  `json:"id"`
+	MaterialId string `json:"materialId"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Action     string `json:"action"`
+	Actor      string `json:"actor"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"createdAt"`
+	// NextRound 为 true 时审核轮次加一，仅写入时使用
+	NextRound bool `json:"-"`
+}
+
+// MaterialRevision 材料某一版本的内容快照，写入后不再修改
+type MaterialRevision struct {
+	ID          int64  `json:"id"`
+	MaterialId  string `json:"materialId"`
+	Revision    int    `json:"revision"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Tags        string `json:"tags"`
+	Files       string `json:"files"`
+	Editor      string `json:"editor"`
+	// Note 修改说明
+	Note      string `json:"note"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// MaterialRepository 材料的读写
+// 状态只能通过 Transition 修改，合法性由 workflow 包校验
+// 回收站中的材料只能通过 GetDeleted 与 Deleted 过滤条件读取，其余读写都视为不存在
+type MaterialRepository interface {
+	// Create 写入材料并记录初始状态与第一版内容
+	Create(ctx context.Context, m *Material) error
+	Get(ctx context.Context, id string) (*Material, error)
+	// GetForUpdate 在事务中读取材料并锁定该行，直到事务结束
+	GetForUpdate(ctx context.Context, id string) (*Material, error)
+	List(ctx context.Context, filter MaterialFilter) ([]Material, error)
+	// Transition 比较并更新状态，同时写入状态历史
+	Transition(ctx context.Context, t *MaterialTransition) error
+	// History 按时间顺序返回材料的状态变更历史
+	History(ctx context.Context, id string) ([]MaterialTransition, error)
+	// Revise 以 rev 的内容更新材料并记录为新版本，rev.Revision 为新版本号
+	// 材料当前版本不是 rev.Revision-1 时返回 ErrConflict
+	Revise(ctx context.Context, rev *MaterialRevision) error
+	// Revisions 按版本号返回材料的全部版本
+	Revisions(ctx context.Context, id string) ([]MaterialRevision, error)
+	GetRevision(ctx context.Context, id string, revision int) (*MaterialRevision, error)
+	// UpdateReviewSummary 更新列表展示用的最近审核人、审核时间与审核意见
+	UpdateReviewSummary(ctx context.Context, id, reviewer, reviewTime, comment string) error
+	// UpdateAssessment 以 m 的模型评估结果与成果取得时间、截止日期标记更新材料，用于修改证明文件后重新识别
+	UpdateAssessment(ctx context.Context, m *Material) error
+	// SoftDelete 将材料移入回收站，材料不存在或已在回收站时返回 ErrNotFound
+	SoftDelete(ctx context.Context, id, deletedBy string) error
+	// GetDeleted 返回回收站中的材料，不在回收站时返回 ErrNotFound
+	GetDeleted(ctx context.Context, id string) (*Material, error)
+	// Restore 将材料移出回收站，不在回收站时返回 ErrNotFound
+	Restore(ctx context.Context, id string) error
+	// Purge 永久删除回收站中的材料及其审核意见、状态历史、版本、分配、申诉与加分记录，不在回收站时返回 ErrNotFound
+	Purge(ctx context.Context, id string) error
+	// FileLists 返回全部材料（含回收站）、历史版本与申诉的 files 列，用于判断上传文件是否仍被引用
+	FileLists(ctx context.Context) ([]string, error)
+	// Statistics 统计未删除材料的数量，uploader 为空时统计全部
+	Statistics(ctx context.Context, uploader string) (*MaterialStatistics, error)
+}
+
+// MaterialReview 审核人对材料给出的一条意见
+type MaterialReview struct {
+	ID         int64  `json:"id"`
+	MaterialId string `json:"materialId"`
+	Reviewer   string `json:"reviewer"`
+	Decision   string `json:"decision"`
+	Comment    string `json:"comment"`
+	Round      int    `json:"round"`
+	// Revision 审核时材料的版本号
+	Revision  int    `json:"revision"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// MaterialReviewRepository 审核意见的读写，记录只追加不修改
+type MaterialReviewRepository interface {
+	Create(ctx context.Context, rv *MaterialReview) error
+	// ListByMaterial 按时间顺序返回材料的全部审核意见
+	ListByMaterial(ctx context.Context, materialId string) ([]MaterialReview, error)
+	// DecidedRounds 返回审核人对材料当前版本给出过通过或驳回的材料及其最近轮次
+	DecidedRounds(ctx context.Context, reviewer string) (map[string]int, error)
+}
+
+// DefaultPolicyCategory 默认审核规则在 review_policies 中的类别
+const DefaultPolicyCategory = "*"
+
+// ReviewPolicy 某一材料类别形成审核结论的规则，取值含义见 workflow.Policy
+type ReviewPolicy struct {
+	Category          string `json:"category"`
+	RequiredApprovals int    `json:"requiredApprovals"`
+	Quorum            int    `json:"quorum"`
+	RejectionMode     string `json:"rejectionMode"`
+	VoteRule          string `json:"voteRule"`
+	TieBreak          string `json:"tieBreak"`
+	UpdatedBy         string `json:"updatedBy"`
+	UpdatedAt         string `json:"updatedAt"`
+}
+
+// ReviewPolicyRepository 审核规则的读写
+type ReviewPolicyRepository interface {
+	// Get 返回指定类别的规则，未单独配置时返回 ErrNotFound，不回退到默认规则
+	Get(ctx context.Context, category string) (*ReviewPolicy, error)
+	// List 按类别返回全部规则，默认规则排在最前
+	List(ctx context.Context) ([]ReviewPolicy, error)
+	// Save 写入规则，同一类别已有规则时覆盖
+	Save(ctx context.Context, p *ReviewPolicy) error
+	Delete(ctx context.Context, category string) error
+}
+
+// 审核分配状态
+const (
+	AssignmentActive   = "active"
+	AssignmentDone     = "done"
+	AssignmentReleased = "released"
+)
+
+// ReviewAssignment 材料某一轮次分配给一位审核人的审核任务
+type ReviewAssignment struct {
+	ID         int64  `json:"id"`
+	MaterialId string `json:"materialId"`
+	Round      int    `json:"round"`
+	Reviewer   string `json:"reviewer"`
+	Status     string `json:"status"`
+	AssignedBy string `json:"assignedBy"`
+	AssignedAt string `json:"assignedAt"`
+	ClosedAt   string `json:"closedAt"`
+	// Reason 收回原因，如改派或材料退出审核
+	Reason string `json:"reason"`
+	// EscalatedAt 超时升级的时间，未升级时为空
+	EscalatedAt string `json:"escalatedAt"`
+}
+
+// ReviewerAvailability 审核人是否接收新的分配
+type ReviewerAvailability struct {
+	AccountId string `json:"accountId"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// ReviewAssignmentRepository 审核分配与审核人可用状态的读写
+type ReviewAssignmentRepository interface {
+	Create(ctx context.Context, a *ReviewAssignment) error
+	Get(ctx context.Context, id int64) (*ReviewAssignment, error)
+	// ListByMaterial 返回材料指定轮次的全部分配，含已收回的
+	ListByMaterial(ctx context.Context, materialId string, round int) ([]ReviewAssignment, error)
+	// ListActiveByReviewer 按分配先后返回审核人待处理的分配
+	ListActiveByReviewer(ctx context.Context, reviewer string) ([]ReviewAssignment, error)
+	// ListActive 按分配先后返回全部待处理的分配
+	ListActive(ctx context.Context) ([]ReviewAssignment, error)
+	// ActiveLoad 返回各审核人待处理的分配数
+	ActiveLoad(ctx context.Context) (map[string]int, error)
+	// LastAssigned 返回最近一次分配的审核人，尚无分配时返回空字符串
+	LastAssigned(ctx context.Context) (string, error)
+	// Complete 将审核人在该轮次待处理的分配标记为已完成，没有待处理分配时返回 ErrNotFound
+	Complete(ctx context.Context, materialId string, round int, reviewer string) error
+	// Release 收回一条待处理的分配，不存在或已不是待处理时返回 ErrNotFound
+	Release(ctx context.Context, id int64, reason string) error
+	// ReleaseByMaterial 收回材料全部待处理的分配，返回收回条数
+	ReleaseByMaterial(ctx context.Context, materialId, reason string) (int64, error)
+	// Reactivate 将材料该轮次已完成的分配重新置为待处理并重新计时，返回恢复条数
+	Reactivate(ctx context.Context, materialId string, round int) (int64, error)
+	// MarkEscalated 记录待处理分配已超时升级，分配已结束或已升级过时返回 ErrNotFound
+	MarkEscalated(ctx context.Context, id int64) error
+	SetAvailability(ctx context.Context, a *ReviewerAvailability) error
+	// Unavailable 返回暂停接收分配的审核人及原因
+	Unavailable(ctx context.Context) (map[string]string, error)
+}
+
+// 审核阶段
+const (
+	PhaseSubmission = "submission"
+	PhaseReview     = "review"
+)
+
+// ReviewPhase 审核阶段的截止时间与处理时限
+type ReviewPhase struct {
+	Phase string `json:"phase"`
+	// ClosesAt 阶段截止时间，为空表示不设截止时间
+	ClosesAt string `json:"closesAt"`
+	// SLAHours 单条审核分配的处理时限（小时），0 表示不限
+	SLAHours  int    `json:"slaHours"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// ReviewPhaseRepository 审核阶段配置的读写
+type ReviewPhaseRepository interface {
+	// Get 返回阶段配置，未配置时返回 ErrNotFound
+	Get(ctx context.Context, phase string) (*ReviewPhase, error)
+	List(ctx context.Context) ([]ReviewPhase, error)
+	// Save 写入阶段配置，已有配置时覆盖
+	Save(ctx context.Context, p *ReviewPhase) error
+}
+
+// 申诉状态
+const (
+	AppealPending  = "pending"
+	AppealReopened = "reopened"
+	AppealUpheld   = "upheld"
+)
+
+// Appeal 学生对一轮驳回提出的申诉
+type Appeal struct {
+	ID         int64  `json:"id"`
+	MaterialId string `json:"materialId"`
+	// Round 被驳回的审核轮次
+	Round     int    `json:"round"`
+	Appellant string `json:"appellant"`
+	Statement string `json:"statement"`
+	// Files 补充材料，与 materials.files 相同的 JSON 数组
+	Files     string `json:"files"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+	DecidedBy string `json:"decidedBy"`
+	DecidedAt string `json:"decidedAt"`
+	// Comment 申诉处理意见
+	Comment string `json:"comment"`
+}
+
+// AppealRepository 申诉的读写
+type AppealRepository interface {
+	Create(ctx context.Context, a *Appeal) error
+	Get(ctx context.Context, id int64) (*Appeal, error)
+	// ListByMaterial 按提出先后返回材料的全部申诉
+	ListByMaterial(ctx context.Context, materialId string) ([]Appeal, error)
+	// ListByStatus 按提出先后返回指定状态的申诉，status 为空时返回全部
+	ListByStatus(ctx context.Context, status string) ([]Appeal, error)
+	// Decide 写入待处理申诉的处理结果，申诉不存在或已处理时返回 ErrNotFound
+	Decide(ctx context.Context, id int64, status, decidedBy, comment string) error
+}
+
+// MaterialRecord 审核通过后由模型整理出的加分记录
+type MaterialRecord struct {
+	MaterialId   string  `json:"materialId"`
+	AccountId    string  `json:"accountId"`
+	Type         string  `json:"type"`
+	Category     string  `json:"category"`
+	Id           string  `json:"id"`
+	Project      string  `json:"project"`
+	AwardDate    string  `json:"awardDate"`
+	AwardType    string  `json:"awardType"`
+	TeamRank     string  `json:"teamRank"`
+	SelfScore    float64 `json:"selfScore"`
+	ScoreBasis   string  `json:"scoreBasis"`
+	CollegeScore float64 `json:"collegeScore"`
+	// Facts 计分要素的 JSON，CollegeScore 与 ScoreBasis 由规则引擎据此计算
+	Facts string `json:"-"`
+}
+
+// MaterialRecordRepository 加分记录的读写，每份材料至多一条记录
+type MaterialRecordRepository interface {
+	Exists(ctx context.Context, materialId string) (bool, error)
+	Get(ctx context.Context, materialId string) (*MaterialRecord, error)
+	// Save 写入记录，同一材料已有记录时覆盖
+	Save(ctx context.Context, rec *MaterialRecord) error
+	// ListByAccount 返回计入加分的记录，只包含材料未删除且当前为审核通过的记录
+	ListByAccount(ctx context.Context, accountId string) ([]MaterialRecord, error)
+}
+
+// User 用户表中的一行；Password 为空表示统一身份认证账号
+type User struct {
+	ID                 int64
+	Username           string
+	Password           string
+	Name               string
+	Avatar             string
+	Job                string
+	Organization       string
+	Location           string
+	Email              string
+	Introduction       string
+	PersonalWebsite    string
+	JobName            string
+	OrganizationName   string
+	LocationName       string
+	Phone              string
+	RegistrationDate   string
+	AccountId          string
+	Certification      int
+	Role               string
+	MustChangePassword bool
+	UpdateTime         string
+}
+
+// UserRepository 用户账号与资料的读写
+type UserRepository interface {
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByAccountId(ctx context.Context, accountId string) (*User, error)
+	Create(ctx context.Context, u *User) error
+	// UpsertProfile 同步统一身份认证资料：新账号直接写入，已有账号只更新资料字段，保留本地密码与角色
+	UpsertProfile(ctx context.Context, u *User) error
+	// SaveSettings 保存用户在个人设置页修改的资料，账号不存在时新建
+	SaveSettings(ctx context.Context, u *User) error
+	SetPassword(ctx context.Context, accountId, hash string, mustChange bool) error
+	// FlagDefaultPasswords 将密码等于用户名的旧版账号标记为必须修改密码
+	FlagDefaultPasswords(ctx context.Context) (int64, error)
+	CountByRole(ctx context.Context, role string) (int, error)
+	// ListByRole 按账号顺序返回指定角色的用户
+	ListByRole(ctx context.Context, role string) ([]User, error)
+}
+
+// Student 学生名单中一名学生的信息，学号唯一，成绩、GPA 与毕业年份未填写时为 0
+type Student struct {
+	StudentId      string  `json:"studentId"`
+	Name           string  `json:"name"`
+	Major          string  `json:"major"`
+	Class          string  `json:"class"`
+	Score          float64 `json:"score"`
+	GPA            float64 `json:"gpa"`
+	GraduationYear int     `json:"graduationYear"`
+}
+
+// StudentRepository 学生名单的读取
+type StudentRepository interface {
+	// Get 返回学号对应学生的信息，名单中没有时返回 ErrNotFound
+	Get(ctx context.Context, studentId string) (*Student, error)
+	// Roster 按学号顺序返回专业内的全部学生，major 为空时返回全部学生
+	Roster(ctx context.Context, major string) ([]Student, error)
+	// SetGPA 设置名单中学号的推免绩点，返回实际更新的行数（0 或 1）
+	SetGPA(ctx context.Context, studentId string, gpa float64) (int64, error)
+	// Classes 返回学号对应的班级，名单中没有或未填写班级的学号不出现在结果中
+	Classes(ctx context.Context, studentIds []string) (map[string]string, error)
+	// GraduationYear 返回学号对应的毕业年份，名单中没有或未填写时返回 ErrNotFound
+	GraduationYear(ctx context.Context, studentId string) (int, error)
+	// SetGraduationYear 设置名单中学号的毕业年份，返回实际更新的行数
+	SetGraduationYear(ctx context.Context, studentIds []string, year int) (int64, error)
+	// Upsert 按学号更新名单中的学生，名单中没有该学号时新增一行；返回是否为新增
+	Upsert(ctx context.Context, u *StudentUpsert) (bool, error)
+}
+
+// StudentUpsert 名单导入时按学号写入的一行，为 nil 的字段保留名单中已有的值
+type StudentUpsert struct {
+	StudentId      string
+	Name           *string
+	Major          *string
+	Class          *string
+	Score          *float64
+	GPA            *float64
+	GraduationYear *int
+}
+
+// TranscriptCourse 成绩单中的一门课程
+type TranscriptCourse struct {
+	ID        int64   `json:"id"`
+	StudentId string  `json:"studentId"`
+	Term      string  `json:"term"`
+	Course    string  `json:"course"`
+	Credit    float64 `json:"credit"`
+	// Score 百分制成绩，Graded 为 false 表示没有百分制成绩（如两级计分制课程）
+	Score      float64 `json:"score"`
+	Graded     bool    `json:"graded"`
+	Retaken    bool    `json:"retaken"`
+	ImportedAt string  `json:"importedAt"`
+}
+
+// TranscriptRepository 成绩单的读写
+type TranscriptRepository interface {
+	// ReplaceStudent 在同一事务中以 courses 替换学生的全部成绩单，同一学期的同名课程只能出现一次
+	ReplaceStudent(ctx context.Context, studentId string, courses []TranscriptCourse) error
+	// ListByStudent 按学期、课程名称顺序返回学生的成绩单，没有导入时返回空列表
+	ListByStudent(ctx context.Context, studentId string) ([]TranscriptCourse, error)
+}
+
+// Ranking 学生最近一次计算的推免综合成绩与专业排名
+type Ranking struct {
+	StudentId string  `json:"studentId"`
+	Name      string  `json:"name"`
+	Major     string  `json:"major"`
+	GPA       float64 `json:"gpa"`
+	// AcademicScore 由推免绩点换算的学业综合成绩（百分制）
+	AcademicScore      float64 `json:"academicScore"`
+	AcademicBonus      float64 `json:"academicBonus"`
+	ComprehensiveBonus float64 `json:"comprehensiveBonus"`
+	Composite          float64 `json:"composite"`
+	// Rank 专业内名次，并列学生名次相同且 Tied 为 true；Total 为专业参与排名的人数
+	Rank           int    `json:"rank"`
+	Total          int    `json:"total"`
+	Tied           bool   `json:"tied"`
+	RegulationCode string `json:"regulationCode"`
+	ComputedAt     string `json:"computedAt"`
+}
+
+// RankingMajor 一个专业的排名概况
+type RankingMajor struct {
+	Major      string `json:"major"`
+	Total      int    `json:"total"`
+	ComputedAt string `json:"computedAt"`
+}
+
+// RankingRepository 专业排名的读写，排名按专业整体替换
+type RankingRepository interface {
+	// ReplaceMajor 在同一事务中以 rows 替换专业的全部排名，rows 中的学生原先在其他专业的排名一并移除
+	ReplaceMajor(ctx context.Context, major string, rows []Ranking) error
+	// ListByMajor 按名次、学号顺序返回专业的排名
+	ListByMajor(ctx context.Context, major string) ([]Ranking, error)
+	// Get 返回学生的排名，没有参与排名时返回 ErrNotFound
+	Get(ctx context.Context, studentId string) (*Ranking, error)
+	// Majors 按专业名称顺序返回已有排名的专业
+	Majors(ctx context.Context) ([]RankingMajor, error)
+}
+
+// RegulationSet 某一版本的保研条例，适用于毕业年份在 [CohortFrom, CohortTo] 内的学生
+// 同一 Code 的条例每次修改保存为新版本，历史版本不再改动
+type RegulationSet struct {
+	ID      int64  `json:"id"`
+	Code    string `json:"code"`
+	Version int    `json:"version"`
+	Title   string `json:"title"`
+	// CohortTo 为 0 表示不设上限
+	CohortFrom int `json:"cohortFrom"`
+	CohortTo   int `json:"cohortTo"`
+	// Cutoff 成果取得的截止日，格式 MM-DD，截止于推免当年即毕业前一年的该日
+	Cutoff string `json:"cutoff"`
+	// Guidelines 条例正文，Journals、Competitions 为附件中的学术会议和期刊目录、学业竞赛项目库
+	Guidelines   string `json:"guidelines"`
+	Journals     string `json:"journals"`
+	Competitions string `json:"competitions"`
+	// Rules 加分表 JSON，结构见 scoring.Rules
+	Rules     string `json:"rules"`
+	Note      string `json:"note"`
+	CreatedBy string `json:"createdBy"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// Covers 报告条例是否适用于指定毕业年份
+func (r *RegulationSet) Covers(year int) bool {
+	return year >= r.CohortFrom && (r.CohortTo == 0 || year <= r.CohortTo)
+}
+
+// RegulationSetRepository 保研条例版本的读写
+type RegulationSetRepository interface {
+	// Create 保存条例的新版本，Version 取同一 Code 已有的最大版本号加一
+	Create(ctx context.Context, set *RegulationSet) error
+	Get(ctx context.Context, id int64) (*RegulationSet, error)
+	// Current 返回每个 Code 的最新版本，按 CohortFrom 从新到旧排列
+	Current(ctx context.Context) ([]RegulationSet, error)
+	// Versions 返回同一 Code 的全部版本，版本号从新到旧排列
+	Versions(ctx context.Context, code string) ([]RegulationSet, error)
+	// ForCohort 返回适用于指定毕业年份的条例最新版本，多套条例都适用时取 CohortFrom 最大的一套
+	// 没有适用的条例时返回 ErrNotFound
+	ForCohort(ctx context.Context, year int) (*RegulationSet, error)
+}
+
+// FileMapping 上传文件 md5 与存储位置的映射，用于秒传
+type FileMapping struct {
+	Md5      string
+	Filename string
+	FileId   string
+	URL      string
+}
+
+// FileMapRepository 上传文件映射的读写
+type FileMapRepository interface {
+	Find(ctx context.Context, md5, filename string) (*FileMapping, error)
+	Save(ctx context.Context, f *FileMapping) error
+	// DeleteByFileId 删除指向该存储文件的全部映射，返回删除条数
+	DeleteByFileId(ctx context.Context, fileId string) (int64, error)
+}
+
+// Message 消息中心的一条消息，Status 为 1 表示已读
+type Message struct {
+	ID          int64  `json:"id"`
+	AccountId   string `json:"-"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	SubTitle    string `json:"subTitle"`
+	Avatar      string `json:"avatar,omitempty"`
+	Content     string `json:"content"`
+	Time        string `json:"time"`
+	Status      int    `json:"status"`
+	MessageType int    `json:"messageType"`
+}
+
+// MessageRepository 消息中心的读写
+type MessageRepository interface {
+	Create(ctx context.Context, m *Message) error
+	ListByAccount(ctx context.Context, accountId string) ([]Message, error)
+	// MarkRead 将指定消息标记为已读，只作用于该账号自己的消息，返回实际更新的条数
+	MarkRead(ctx context.Context, accountId string, ids []int64) (int64, error)
+}
+
+// 目录种类
+const (
+	CatalogueJournal     = "journal"
+	CatalogueCompetition = "competition"
+)
+
+// CatalogueEntry 条例附件目录中的一种期刊、会议或一项竞赛，字段含义见 scoring.CatalogueEntry
+type CatalogueEntry struct {
+	ID             int64    `json:"id"`
+	RegulationCode string   `json:"regulationCode"`
+	Kind           string   `json:"kind"`
+	Name           string   `json:"name"`
+	Aliases        []string `json:"aliases"`
+	Class          string   `json:"class"`
+	Level          string   `json:"level"`
+	Host           string   `json:"host"`
+	External       bool     `json:"external"`
+	Note           string   `json:"note"`
+	UpdatedBy      string   `json:"updatedBy"`
+	UpdatedAt      string   `json:"updatedAt"`
+}
+
+// CatalogueFilter 目录查询条件，零值字段不参与过滤
+type CatalogueFilter struct {
+	RegulationCode string
+	Kind           string
+	// Keyword 按名称、别名或主办方模糊查询
+	Keyword string
+}
+
+// CatalogueRepository 期刊会议目录与竞赛项目库的读写
+type CatalogueRepository interface {
+	// List 按种类、名称排序返回符合条件的条目
+	List(ctx context.Context, f CatalogueFilter) ([]CatalogueEntry, error)
+	Get(ctx context.Context, id int64) (*CatalogueEntry, error)
+	// Create 新增条目，同一条例同一种类下已有同名条目时返回 ErrConflict
+	Create(ctx context.Context, e *CatalogueEntry) error
+	// Update 按 ID 覆盖条目，改名与其他条目重名时返回 ErrConflict
+	Update(ctx context.Context, e *CatalogueEntry) error
+	Delete(ctx context.Context, id int64) error
+	// Count 返回某套条例的条目数
+	Count(ctx context.Context, regulationCode string) (int, error)
+}
+
+// Session 服务端登录会话，只保存令牌的哈希
+type Session struct {
+	TokenHash string
+	AccountId string
+	Username  string
+	Role      string
+	IP        string
+	CreatedAt string
+	ExpiresAt string
+}
+
+// SessionRepository 登录会话的读写
+type SessionRepository interface {
+	// Create 写入新会话，CreatedAt 为空时取当前时间
+	Create(ctx context.Context, s *Session) error
+	// Active 返回未过期且未吊销的会话，不存在时返回 ErrNotFound
+	Active(ctx context.Context, tokenHash string) (*Session, error)
+	// Revoke 吊销会话，会话不存在或已吊销时不报错
+	Revoke(ctx context.Context, tokenHash string) error
+	// RevokeOthers 吊销账号除 keepTokenHash 以外的全部会话，返回吊销的条数
+	RevokeOthers(ctx context.Context, accountId, keepTokenHash string) (int64, error)
+}
+
+// AuditEntry 审计日志中的一条权限判定记录
+type AuditEntry struct {
+	ID         int64
+	AccountId  string
+	Role       string
+	Method     string
+	Path       string
+	Permission string
+	Decision   string
+	IP         string
+	Time       string
+}
+
+// AuditLogRepository 审计日志的写入，日志只追加不修改
+type AuditLogRepository interface {
+	// Append 追加一条记录并回填 ID，Time 为空时取当前时间
+	Append(ctx context.Context, e *AuditEntry) error
+}
+
+// LoginRecord 一次成功登录的记录
+type LoginRecord struct {
+	ID        int64
+	Username  string
+	IP        string
+	LoginTime string
+	Role      string
+}
+
+// LoginRecordRepository 登录记录的读写
+type LoginRecordRepository interface {
+	// Create 写入记录并回填 ID，LoginTime 为空时取当前时间
+	Create(ctx context.Context, rec *LoginRecord) error
+	// Get 按 ID 返回记录，不存在时返回 ErrNotFound
+	Get(ctx context.Context, id int64) (*LoginRecord, error)
+}
+
+// UploadRecord 个人设置页上传的一个文件
+type UploadRecord struct {
+	ID         int64
+	Filename   string
+	Path       string
+	UploadTime string
+}
+
+// UploadRecordRepository 个人设置页上传记录的读写
+type UploadRecordRepository interface {
+	// Create 写入记录并回填 ID，UploadTime 为空时取当前时间
+	Create(ctx context.Context, rec *UploadRecord) error
+	// Get 按 ID 返回记录，不存在时返回 ErrNotFound
+	Get(ctx context.Context, id int64) (*UploadRecord, error)
+}
+
+// Activity 个人中心展示的一条用户动态
+type Activity struct {
+	ID          string
+	UserId      string
+	Type        string
+	Title       string
+	Timestamp   string
+	Description string
+	Status      string
+}
+
+// ProfileItem 旧版个人中心的项目或团队，只有编号与名称
+type ProfileItem struct {
+	ID   string
+	Name string
+}
+
+// Contributor 项目的一名参与者
+type Contributor struct {
+	Name   string
+	Email  string
+	Avatar string
+}
+
+// Project 用户负责的项目及其参与者
+type Project struct {
+	ID           int64
+	Name         string
+	Description  string
+	PeopleNumber int
+	Contributors []Contributor
+}
+
+// Team 用户负责的团队
+type Team struct {
+	ID           int64
+	Avatar       string
+	Name         string
+	PeopleNumber int
+}
+
+// EnterpriseCertification 账号的企业认证信息
+type EnterpriseCertification struct {
+	AccountType          int
+	Status               int
+	Time                 string
+	LegalPerson          string
+	CertificateType      string
+	AuthenticationNumber string
+	EnterpriseName       string
+}
+
+// CertificationRecord 账号的一条认证申请记录
+type CertificationRecord struct {
+	CertificationType    int
+	CertificationContent string
+	Status               int
+	Time                 string
+}
+
+// ProfileRepository 个人中心页面展示的动态、项目、团队与认证信息的读取
+type ProfileRepository interface {
+	// Activities 按时间倒序返回用户的动态
+	Activities(ctx context.Context, accountId string) ([]Activity, error)
+	// ProjectSummaries、TeamSummaries 按编号顺序返回旧版个人中心的全部项目与团队
+	ProjectSummaries(ctx context.Context) ([]ProfileItem, error)
+	TeamSummaries(ctx context.Context) ([]ProfileItem, error)
+	// Projects 按编号顺序返回账号负责的项目，参与者一并返回
+	Projects(ctx context.Context, owner string) ([]Project, error)
+	// Teams 按编号顺序返回账号负责的团队
+	Teams(ctx context.Context, owner string) ([]Team, error)
+	// Enterprise 返回账号的企业认证信息，未认证时返回 ErrNotFound
+	Enterprise(ctx context.Context, accountId string) (*EnterpriseCertification, error)
+	// CertificationRecords 按时间倒序返回账号的认证申请记录
+	CertificationRecords(ctx context.Context, accountId string) ([]CertificationRecord, error)
+}
+
+// SubmitBatch 批量提交材料中的一批
+type SubmitBatch struct {
+	ID       string
+	Name     string
+	Status   string
+	Reviewer string
+}
+
+// SubmitBatchRepository 批量提交材料的读取
+type SubmitBatchRepository interface {
+	// List 按编号顺序返回全部批次
+	List(ctx context.Context) ([]SubmitBatch, error)
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlReviewAssignmentRepository struct {
+	s *Store
+}
+
+const reviewAssignmentColumns = `id, materialId, round, reviewer, status, COALESCE(assignedBy, ''), assignedAt, COALESCE(closedAt, ''),
+	COALESCE(reason, ''), COALESCE(escalatedAt, '')`
+
+func scanReviewAssignment(row rowScanner) (*ReviewAssignment, error) {
+	var a ReviewAssignment
+	err := row.Scan(&a.ID, &a.MaterialId, &a.Round, &a.Reviewer, &a.Status, &a.AssignedBy, &a.AssignedAt, &a.ClosedAt, &a.Reason, &a.EscalatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *sqlReviewAssignmentRepository) list(ctx context.Context, query string, args ...interface{}) ([]ReviewAssignment, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+reviewAssignmentColumns+` FROM review_assignments `+query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := make([]ReviewAssignment, 0)
+	for rows.Next() {
+		a, err := scanReviewAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, *a)
+	}
+	return assignments, rows.Err()
+}
+
+func (r *sqlReviewAssignmentRepository) Create(ctx context.Context, a *ReviewAssignment) error {
+	if a.AssignedAt == "" {
+		a.AssignedAt = Now()
+	}
+	if a.Status == "" {
+		a.Status = AssignmentActive
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO review_assignments (materialId, round, reviewer, status, assignedBy, assignedAt, closedAt, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		a.MaterialId, a.Round, a.Reviewer, a.Status, a.AssignedBy, a.AssignedAt, a.ClosedAt, a.Reason).Scan(&a.ID)
+}
+
+func (r *sqlReviewAssignmentRepository) Get(ctx context.Context, id int64) (*ReviewAssignment, error) {
+	a, err := scanReviewAssignment(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+reviewAssignmentColumns+` FROM review_assignments WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (r *sqlReviewAssignmentRepository) ListByMaterial(ctx context.Context, materialId string, round int) ([]ReviewAssignment, error) {
+	return r.list(ctx, `WHERE materialId = ? AND round = ? ORDER BY id`, materialId, round)
+}
+
+func (r *sqlReviewAssignmentRepository) ListActiveByReviewer(ctx context.Context, reviewer string) ([]ReviewAssignment, error) {
+	return r.list(ctx, `WHERE reviewer = ? AND status = ? ORDER BY id`, reviewer, AssignmentActive)
+}
+
+func (r *sqlReviewAssignmentRepository) ListActive(ctx context.Context) ([]ReviewAssignment, error) {
+	return r.list(ctx, `WHERE status = ? ORDER BY id`, AssignmentActive)
+}
+
+func (r *sqlReviewAssignmentRepository) ActiveLoad(ctx context.Context) (map[string]int, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT reviewer, COUNT(1) FROM review_assignments WHERE status = ? GROUP BY reviewer`), AssignmentActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	load := make(map[string]int)
+	for rows.Next() {
+		var reviewer string
+		var n int
+		if err := rows.Scan(&reviewer, &n); err != nil {
+			return nil, err
+		}
+		load[reviewer] = n
+	}
+	return load, rows.Err()
+}
+
+func (r *sqlReviewAssignmentRepository) LastAssigned(ctx context.Context) (string, error) {
+	var reviewer string
+	err := r.s.conn().QueryRowContext(ctx, `SELECT reviewer FROM review_assignments ORDER BY id DESC LIMIT 1`).Scan(&reviewer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return reviewer, err
+}
+
+func (r *sqlReviewAssignmentRepository) Complete(ctx context.Context, materialId string, round int, reviewer string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE review_assignments SET status = ?, closedAt = ?
+		WHERE materialId = ? AND round = ? AND reviewer = ? AND status = ?`),
+		AssignmentDone, Now(), materialId, round, reviewer, AssignmentActive)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlReviewAssignmentRepository) Release(ctx context.Context, id int64, reason string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE review_assignments SET status = ?, closedAt = ?, reason = ? WHERE id = ? AND status = ?`),
+		AssignmentReleased, Now(), reason, id, AssignmentActive)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlReviewAssignmentRepository) ReleaseByMaterial(ctx context.Context, materialId, reason string) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE review_assignments SET status = ?, closedAt = ?, reason = ? WHERE materialId = ? AND status = ?`),
+		AssignmentReleased, Now(), reason, materialId, AssignmentActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlReviewAssignmentRepository) Reactivate(ctx context.Context, materialId string, round int) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE review_assignments SET status = ?, assignedAt = ?, closedAt = NULL, escalatedAt = NULL
+		WHERE materialId = ? AND round = ? AND status = ?`), AssignmentActive, Now(), materialId, round, AssignmentDone)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlReviewAssignmentRepository) MarkEscalated(ctx context.Context, id int64) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE review_assignments SET escalatedAt = ?
+		WHERE id = ? AND status = ? AND COALESCE(escalatedAt, '') = ''`), Now(), id, AssignmentActive)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlReviewAssignmentRepository) SetAvailability(ctx context.Context, a *ReviewerAvailability) error {
+	if a.UpdatedAt == "" {
+		a.UpdatedAt = Now()
+	}
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO reviewer_availability (accountId, available, reason, updatedBy, updatedAt)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (accountId) DO UPDATE SET available = excluded.available, reason = excluded.reason,
+			updatedBy = excluded.updatedBy, updatedAt = excluded.updatedAt`),
+		a.AccountId, boolToInt(a.Available), a.Reason, a.UpdatedBy, a.UpdatedAt)
+	return err
+}
+
+func (r *sqlReviewAssignmentRepository) Unavailable(ctx context.Context) (map[string]string, error) {
+	rows, err := r.s.conn().QueryContext(ctx, `SELECT accountId, COALESCE(reason, '') FROM reviewer_availability WHERE available = 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unavailable := make(map[string]string)
+	for rows.Next() {
+		var accountId, reason string
+		if err := rows.Scan(&accountId, &reason); err != nil {
+			return nil, err
+		}
+		unavailable[accountId] = reason
+	}
+	return unavailable, rows.Err()
+}
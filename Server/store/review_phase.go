@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlReviewPhaseRepository struct {
+	s *Store
+}
+
+const reviewPhaseColumns = `phase, COALESCE(closesAt, ''), slaHours, COALESCE(updatedBy, ''), COALESCE(updatedAt, '')`
+
+func scanReviewPhase(row rowScanner) (*ReviewPhase, error) {
+	var p ReviewPhase
+	if err := row.Scan(&p.Phase, &p.ClosesAt, &p.SLAHours, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *sqlReviewPhaseRepository) Get(ctx context.Context, phase string) (*ReviewPhase, error) {
+	p, err := scanReviewPhase(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+reviewPhaseColumns+` FROM review_phases WHERE phase = ?`), phase))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return p, err
+}
+
+func (r *sqlReviewPhaseRepository) List(ctx context.Context) ([]ReviewPhase, error) {
+	rows, err := r.s.conn().QueryContext(ctx, `SELECT `+reviewPhaseColumns+` FROM review_phases ORDER BY phase DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	phases := make([]ReviewPhase, 0)
+	for rows.Next() {
+		p, err := scanReviewPhase(rows)
+		if err != nil {
+			return nil, err
+		}
+		phases = append(phases, *p)
+	}
+	return phases, rows.Err()
+}
+
+func (r *sqlReviewPhaseRepository) Save(ctx context.Context, p *ReviewPhase) error {
+	if p.UpdatedAt == "" {
+		p.UpdatedAt = Now()
+	}
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO review_phases (phase, closesAt, slaHours, updatedBy, updatedAt)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (phase) DO UPDATE SET closesAt = excluded.closesAt, slaHours = excluded.slaHours,
+			updatedBy = excluded.updatedBy, updatedAt = excluded.updatedAt`),
+		p.Phase, p.ClosesAt, p.SLAHours, p.UpdatedBy, p.UpdatedAt)
+	return err
+}
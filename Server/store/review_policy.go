@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlReviewPolicyRepository struct {
+	s *Store
+}
+
+const reviewPolicyColumns = `category, requiredApprovals, quorum, rejectionMode, voteRule, tieBreak, COALESCE(updatedBy, ''), COALESCE(updatedAt, '')`
+
+func scanReviewPolicy(row rowScanner) (*ReviewPolicy, error) {
+	var p ReviewPolicy
+	err := row.Scan(&p.Category, &p.RequiredApprovals, &p.Quorum, &p.RejectionMode, &p.VoteRule, &p.TieBreak, &p.UpdatedBy, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *sqlReviewPolicyRepository) Get(ctx context.Context, category string) (*ReviewPolicy, error) {
+	p, err := scanReviewPolicy(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+reviewPolicyColumns+` FROM review_policies WHERE category = ?`), category))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return p, err
+}
+
+func (r *sqlReviewPolicyRepository) List(ctx context.Context) ([]ReviewPolicy, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+reviewPolicyColumns+` FROM review_policies
+		ORDER BY CASE WHEN category = ? THEN 0 ELSE 1 END, category`), DefaultPolicyCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]ReviewPolicy, 0)
+	for rows.Next() {
+		p, err := scanReviewPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *sqlReviewPolicyRepository) Save(ctx context.Context, p *ReviewPolicy) error {
+	if p.UpdatedAt == "" {
+		p.UpdatedAt = Now()
+	}
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO review_policies (category, requiredApprovals, quorum, rejectionMode, voteRule, tieBreak, updatedBy, updatedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (category) DO UPDATE SET requiredApprovals = excluded.requiredApprovals, quorum = excluded.quorum,
+			rejectionMode = excluded.rejectionMode, voteRule = excluded.voteRule, tieBreak = excluded.tieBreak,
+			updatedBy = excluded.updatedBy, updatedAt = excluded.updatedAt`),
+		p.Category, p.RequiredApprovals, p.Quorum, p.RejectionMode, p.VoteRule, p.TieBreak, p.UpdatedBy, p.UpdatedAt)
+	return err
+}
+
+func (r *sqlReviewPolicyRepository) Delete(ctx context.Context, category string) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`DELETE FROM review_policies WHERE category = ?`), category)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
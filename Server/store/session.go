@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type sqlSessionRepository struct {
+	s *Store
+}
+
+func (r *sqlSessionRepository) Create(ctx context.Context, sess *Session) error {
+	if sess.CreatedAt == "" {
+		sess.CreatedAt = Now()
+	}
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO sessions (tokenHash, accountId, username, role, ip, createdAt, expiresAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		sess.TokenHash, sess.AccountId, sess.Username, sess.Role, sess.IP, sess.CreatedAt, sess.ExpiresAt)
+	return err
+}
+
+func (r *sqlSessionRepository) Active(ctx context.Context, tokenHash string) (*Session, error) {
+	sess := Session{TokenHash: tokenHash}
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COALESCE(accountId, ''), COALESCE(username, ''), COALESCE(role, ''),
+		COALESCE(ip, ''), COALESCE(createdAt, ''), COALESCE(expiresAt, '')
+		FROM sessions WHERE tokenHash = ? AND revokedAt IS NULL AND expiresAt > ?`), tokenHash, Now()).
+		Scan(&sess.AccountId, &sess.Username, &sess.Role, &sess.IP, &sess.CreatedAt, &sess.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (r *sqlSessionRepository) Revoke(ctx context.Context, tokenHash string) error {
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE sessions SET revokedAt = ? WHERE tokenHash = ? AND revokedAt IS NULL`), Now(), tokenHash)
+	return err
+}
+
+func (r *sqlSessionRepository) RevokeOthers(ctx context.Context, accountId, keepTokenHash string) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE sessions SET revokedAt = ?
+		WHERE accountId = ? AND tokenHash <> ? AND revokedAt IS NULL`), Now(), accountId, keepTokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type sqlAuditLogRepository struct {
+	s *Store
+}
+
+func (r *sqlAuditLogRepository) Append(ctx context.Context, e *AuditEntry) error {
+	if e.Time == "" {
+		e.Time = Now()
+	}
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO audit_log (accountId, role, method, path, permission, decision, ip, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		e.AccountId, e.Role, e.Method, e.Path, e.Permission, e.Decision, e.IP, e.Time).Scan(&e.ID)
+}
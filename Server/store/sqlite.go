@@ -0,0 +1,15 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSQLite 打开本地 SQLite 文件，开启 WAL 以便接口并发读写
+// 事务以 IMMEDIATE 方式开始，先取得写锁，避免读锁升级时的 SQLITE_BUSY
+func openSQLite(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on&_txlock=immediate", path)
+	return sql.Open("sqlite3", dsn)
+}
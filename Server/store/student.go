@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+type sqlStudentRepository struct {
+	s *Store
+}
+
+func (r *sqlStudentRepository) Classes(ctx context.Context, studentIds []string) (map[string]string, error) {
+	classes := make(map[string]string)
+	if len(studentIds) == 0 {
+		return classes, nil
+	}
+	args := make([]interface{}, len(studentIds))
+	for i, id := range studentIds {
+		args[i] = id
+	}
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT studentId, class FROM students
+		WHERE COALESCE(class, '') <> '' AND studentId IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(studentIds)), ", ")+`)`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, class string
+		if err := rows.Scan(&id, &class); err != nil {
+			return nil, err
+		}
+		classes[id] = strings.TrimSpace(class)
+	}
+	return classes, rows.Err()
+}
+
+func (r *sqlStudentRepository) GraduationYear(ctx context.Context, studentId string) (int, error) {
+	var year int
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT graduationYear FROM students
+		WHERE studentId = ? AND COALESCE(graduationYear, 0) > 0`), studentId).Scan(&year)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return year, err
+}
+
+func (r *sqlStudentRepository) SetGraduationYear(ctx context.Context, studentIds []string, year int) (int64, error) {
+	if len(studentIds) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, 0, len(studentIds)+1)
+	args = append(args, year)
+	for _, id := range studentIds {
+		args = append(args, id)
+	}
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE students SET graduationYear = ?
+		WHERE studentId IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(studentIds)), ", ")+`)`), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// studentQuery 名单中的学生，学号唯一
+const studentQuery = `SELECT s.studentId, COALESCE(s.name, ''), TRIM(COALESCE(s.major, '')), TRIM(COALESCE(s.class, '')),
+	COALESCE(s.score, 0), COALESCE(s.gpa, 0), COALESCE(s.graduationYear, 0)
+	FROM students s WHERE s.studentId IS NOT NULL`
+
+func scanStudent(row rowScanner) (*Student, error) {
+	var st Student
+	if err := row.Scan(&st.StudentId, &st.Name, &st.Major, &st.Class, &st.Score, &st.GPA, &st.GraduationYear); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *sqlStudentRepository) Get(ctx context.Context, studentId string) (*Student, error) {
+	st, err := scanStudent(r.s.conn().QueryRowContext(ctx, r.s.Rebind(studentQuery+` AND s.studentId = ?`), studentId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return st, err
+}
+
+func (r *sqlStudentRepository) Roster(ctx context.Context, major string) ([]Student, error) {
+	query, args := studentQuery, []interface{}{}
+	if major != "" {
+		query += ` AND TRIM(COALESCE(s.major, '')) = ?`
+		args = append(args, major)
+	}
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(query+` ORDER BY s.studentId`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := make([]Student, 0)
+	for rows.Next() {
+		st, err := scanStudent(rows)
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, *st)
+	}
+	return students, rows.Err()
+}
+
+func (r *sqlStudentRepository) SetGPA(ctx context.Context, studentId string, gpa float64) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE students SET gpa = ? WHERE studentId = ?`), gpa, studentId)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlStudentRepository) Upsert(ctx context.Context, u *StudentUpsert) (bool, error) {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE students SET name = COALESCE(?, name),
+		major = COALESCE(?, major), class = COALESCE(?, class), score = COALESCE(?, score),
+		gpa = COALESCE(?, gpa), graduationYear = COALESCE(?, graduationYear) WHERE studentId = ?`),
+		u.Name, u.Major, u.Class, u.Score, u.GPA, u.GraduationYear, u.StudentId)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return false, err
+	}
+	_, err = r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO students (studentId, name, major, class, score, gpa, graduationYear)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		u.StudentId, u.Name, u.Major, u.Class, u.Score, u.GPA, u.GraduationYear)
+	return err == nil, err
+}
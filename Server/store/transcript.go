@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type sqlTranscriptRepository struct {
+	s *Store
+}
+
+func (r *sqlTranscriptRepository) ReplaceStudent(ctx context.Context, studentId string, courses []TranscriptCourse) error {
+	return r.s.InTx(ctx, func(tx *Store) error {
+		if _, err := tx.conn().ExecContext(ctx, tx.Rebind(`DELETE FROM transcript_courses WHERE studentId = ?`), studentId); err != nil {
+			return err
+		}
+		now := Now()
+		for i := range courses {
+			c := &courses[i]
+			c.StudentId, c.ImportedAt = studentId, now
+			var score interface{}
+			if c.Graded {
+				score = c.Score
+			}
+			_, err := tx.conn().ExecContext(ctx, tx.Rebind(`INSERT INTO transcript_courses
+				(studentId, term, course, credit, score, retaken, importedAt) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+				c.StudentId, c.Term, c.Course, c.Credit, score, boolToInt(c.Retaken), c.ImportedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqlTranscriptRepository) ListByStudent(ctx context.Context, studentId string) ([]TranscriptCourse, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT id, studentId, term, course, credit, score, retaken, importedAt
+		FROM transcript_courses WHERE studentId = ? ORDER BY term, course`), studentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := make([]TranscriptCourse, 0)
+	for rows.Next() {
+		var (
+			c       TranscriptCourse
+			score   sql.NullFloat64
+			retaken int
+		)
+		if err := rows.Scan(&c.ID, &c.StudentId, &c.Term, &c.Course, &c.Credit, &score, &retaken, &c.ImportedAt); err != nil {
+			return nil, err
+		}
+		c.Score, c.Graded, c.Retaken = score.Float64, score.Valid, retaken != 0
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const userColumns = `id, COALESCE(username, ''), COALESCE(password, ''), COALESCE(name, ''), COALESCE(avatar, ''), COALESCE(job, ''),
+	COALESCE(organization, ''), COALESCE(location, ''), COALESCE(email, ''), COALESCE(introduction, ''), COALESCE(personalWebsite, ''),
+	COALESCE(jobName, ''), COALESCE(organizationName, ''), COALESCE(locationName, ''), COALESCE(phone, ''), COALESCE(registrationDate, ''),
+	COALESCE(accountId, ''), COALESCE(certification, 0), COALESCE(role, ''), COALESCE(mustChangePassword, 0), COALESCE(updateTime, '')`
+
+type sqlUserRepository struct {
+	s *Store
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	var u User
+	var mustChange int
+	err := row.Scan(
+		&u.ID, &u.Username, &u.Password, &u.Name, &u.Avatar, &u.Job,
+		&u.Organization, &u.Location, &u.Email, &u.Introduction, &u.PersonalWebsite,
+		&u.JobName, &u.OrganizationName, &u.LocationName, &u.Phone, &u.RegistrationDate,
+		&u.AccountId, &u.Certification, &u.Role, &mustChange, &u.UpdateTime)
+	if err != nil {
+		return nil, err
+	}
+	u.MustChangePassword = mustChange != 0
+	return &u, nil
+}
+
+func (r *sqlUserRepository) get(ctx context.Context, column, value string) (*User, error) {
+	u, err := scanUser(r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT `+userColumns+` FROM users WHERE `+column+` = ?`), value))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return u, err
+}
+
+func (r *sqlUserRepository) GetByUsername(ctx context.Context, username string) (*User, error) {
+	return r.get(ctx, "username", username)
+}
+
+func (r *sqlUserRepository) GetByAccountId(ctx context.Context, accountId string) (*User, error) {
+	return r.get(ctx, "accountId", accountId)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, u *User) error {
+	if u.RegistrationDate == "" {
+		u.RegistrationDate = Today()
+	}
+	u.UpdateTime = Now()
+	return r.s.conn().QueryRowContext(ctx, r.s.Rebind(`INSERT INTO users (
+		username, password, name, avatar, job, organization, location, email, introduction, personalWebsite,
+		jobName, organizationName, locationName, phone, registrationDate, accountId, certification, role, mustChangePassword, updateTime
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		u.Username, u.Password, u.Name, u.Avatar, u.Job, u.Organization, u.Location, u.Email, u.Introduction, u.PersonalWebsite,
+		u.JobName, u.OrganizationName, u.LocationName, u.Phone, u.RegistrationDate, u.AccountId, u.Certification, u.Role,
+		boolToInt(u.MustChangePassword), u.UpdateTime).Scan(&u.ID)
+}
+
+func (r *sqlUserRepository) UpsertProfile(ctx context.Context, u *User) error {
+	if u.UpdateTime == "" {
+		u.UpdateTime = Now()
+	}
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO users (
+		username, password, name, avatar, job, organization, location, email, introduction, personalWebsite,
+		jobName, organizationName, locationName, phone, registrationDate, accountId, certification, role, updateTime
+	) VALUES (?, '', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (username) DO UPDATE SET
+		name = excluded.name, avatar = excluded.avatar, job = excluded.job, organization = excluded.organization,
+		location = excluded.location, email = excluded.email, jobName = excluded.jobName,
+		organizationName = excluded.organizationName, locationName = excluded.locationName, phone = excluded.phone,
+		updateTime = excluded.updateTime`),
+		u.Username, u.Name, u.Avatar, u.Job, u.Organization, u.Location, u.Email,
+		u.Introduction, u.PersonalWebsite, u.JobName, u.OrganizationName, u.LocationName,
+		u.Phone, u.RegistrationDate, u.AccountId, u.Certification, u.Role, u.UpdateTime)
+	return err
+}
+
+func (r *sqlUserRepository) SaveSettings(ctx context.Context, u *User) error {
+	u.UpdateTime = Now()
+	_, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`INSERT INTO users (accountId, name, email, avatar, jobName, locationName, organizationName, introduction, updateTime)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (accountId) DO UPDATE SET
+			name = excluded.name, email = excluded.email, avatar = excluded.avatar, jobName = excluded.jobName,
+			locationName = excluded.locationName, organizationName = excluded.organizationName,
+			introduction = excluded.introduction, updateTime = excluded.updateTime`),
+		u.AccountId, u.Name, u.Email, u.Avatar, u.JobName, u.LocationName, u.OrganizationName, u.Introduction, u.UpdateTime)
+	return err
+}
+
+func (r *sqlUserRepository) SetPassword(ctx context.Context, accountId, hash string, mustChange bool) error {
+	result, err := r.s.conn().ExecContext(ctx, r.s.Rebind(`UPDATE users SET password = ?, mustChangePassword = ?, updateTime = ? WHERE accountId = ?`),
+		hash, boolToInt(mustChange), Now(), accountId)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+func (r *sqlUserRepository) FlagDefaultPasswords(ctx context.Context) (int64, error) {
+	result, err := r.s.conn().ExecContext(ctx, `UPDATE users SET mustChangePassword = 1 WHERE password = username`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlUserRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	var count int
+	err := r.s.conn().QueryRowContext(ctx, r.s.Rebind(`SELECT COUNT(1) FROM users WHERE role = ?`), role).Scan(&count)
+	return count, err
+}
+
+func (r *sqlUserRepository) ListByRole(ctx context.Context, role string) ([]User, error) {
+	rows, err := r.s.conn().QueryContext(ctx, r.s.Rebind(`SELECT `+userColumns+` FROM users WHERE role = ? ORDER BY accountId`), role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
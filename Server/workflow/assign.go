@@ -0,0 +1,55 @@
+package workflow
+
+import "sort"
+
+// Strategy 审核任务的分配策略
+type Strategy string
+
+const (
+	// StrategyRoundRobin 按账号顺序轮流分配
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyLeastLoaded 优先分配给待处理任务最少的审核人
+	StrategyLeastLoaded Strategy = "least_loaded"
+)
+
+// Candidate 可以接收分配的审核人
+type Candidate struct {
+	AccountId string
+	// Load 待处理的分配数
+	Load int
+}
+
+// ConflictOfInterest 返回审核人不能审核该材料的原因，没有利益冲突时返回空字符串
+// 班级为空表示名单中没有该学号，不视为同班
+func ConflictOfInterest(reviewer, reviewerClass, uploader, uploaderClass string) string {
+	switch {
+	case reviewer == uploader:
+		return "审核人不能审核本人上传的材料"
+	case reviewerClass != "" && reviewerClass == uploaderClass:
+		return "审核人不能审核同班同学的材料"
+	}
+	return ""
+}
+
+// PickReviewers 从候选人中选出至多 n 人，候选人应已排除利益冲突
+// 轮询从上次分配的审核人 last 之后按账号顺序选取；
+// 最少负载优先选择待处理分配最少的审核人，负载相同时按轮询顺序
+func PickReviewers(strategy Strategy, candidates []Candidate, last string, n int) []string {
+	sorted := append([]Candidate(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AccountId < sorted[j].AccountId })
+
+	start := sort.Search(len(sorted), func(i int) bool { return sorted[i].AccountId > last })
+	ordered := append(sorted[start:len(sorted):len(sorted)], sorted[:start]...)
+	if strategy == StrategyLeastLoaded {
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Load < ordered[j].Load })
+	}
+
+	if n > len(ordered) {
+		n = len(ordered)
+	}
+	picked := make([]string, 0, n)
+	for _, c := range ordered[:n] {
+		picked = append(picked, c.AccountId)
+	}
+	return picked
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RejectionMode 驳回意见的效力
+type RejectionMode string
+
+const (
+	// RejectionVeto 任一驳回立即形成驳回结论
+	RejectionVeto RejectionMode = "veto"
+	// RejectionVote 驳回只是一票，与通过票一起按表决规则计票
+	RejectionVote RejectionMode = "vote"
+)
+
+// VoteRule 驳回计票时的表决规则
+type VoteRule string
+
+const (
+	// RuleUnanimity 需全体一致通过，出现驳回票即无法通过
+	RuleUnanimity VoteRule = "unanimity"
+	// RuleMajority 收齐表决票数后按过半数决定，通过票多于驳回票即可通过
+	RuleMajority VoteRule = "majority"
+)
+
+// TieBreak 多数决平票时的处理方式
+type TieBreak string
+
+const (
+	// TieEscalate 提交管理员裁决
+	TieEscalate TieBreak = "escalate"
+	// TieApprove 平票视为通过
+	TieApprove TieBreak = "approve"
+	// TieReject 平票视为驳回
+	TieReject TieBreak = "reject"
+)
+
+// Policy 形成审核结论的规则，按材料类别配置
+type Policy struct {
+	// RequiredApprovals 形成通过结论至少需要的通过票数，多数决时只决定默认的表决票数
+	RequiredApprovals int
+	// Quorum 驳回计票时，开始表决前至少需要的有效票数（通过与驳回之和），0 表示等于 RequiredApprovals
+	Quorum    int
+	Rejection RejectionMode
+	Rule      VoteRule
+	TieBreak  TieBreak
+}
+
+// DefaultPolicy 未配置类别时使用的规则：三票通过，任一驳回即驳回
+var DefaultPolicy = Policy{
+	RequiredApprovals: 3,
+	Rejection:         RejectionVeto,
+	Rule:              RuleUnanimity,
+	TieBreak:          TieEscalate,
+}
+
+// ErrInvalidPolicy 审核规则配置不合法
+var ErrInvalidPolicy = errors.New("invalid review policy")
+
+// Validate 检查规则各字段取值，错误可用 errors.Is(err, ErrInvalidPolicy) 判断
+func (p Policy) Validate() error {
+	switch {
+	case p.RequiredApprovals < 1:
+		return fmt.Errorf("%w: 通过票数至少为 1，当前为 %d", ErrInvalidPolicy, p.RequiredApprovals)
+	case p.Quorum != 0 && p.Quorum < p.RequiredApprovals:
+		return fmt.Errorf("%w: 表决票数 %d 不能少于通过票数 %d", ErrInvalidPolicy, p.Quorum, p.RequiredApprovals)
+	}
+	switch p.Rejection {
+	case RejectionVeto, RejectionVote:
+	default:
+		return fmt.Errorf("%w: 未知的驳回方式 %q", ErrInvalidPolicy, p.Rejection)
+	}
+	switch p.Rule {
+	case RuleUnanimity, RuleMajority:
+	default:
+		return fmt.Errorf("%w: 未知的表决规则 %q", ErrInvalidPolicy, p.Rule)
+	}
+	switch p.TieBreak {
+	case TieEscalate, TieApprove, TieReject:
+	default:
+		return fmt.Errorf("%w: 未知的平票处理方式 %q", ErrInvalidPolicy, p.TieBreak)
+	}
+	return nil
+}
+
+// quorum 驳回计票时开始表决所需的有效票数
+func (p Policy) quorum() int {
+	if p.Quorum > 0 {
+		return p.Quorum
+	}
+	return p.RequiredApprovals
+}
+
+// Panel 一轮审核至少需要的审核人数
+func (p Policy) Panel() int {
+	return p.quorum()
+}
+
+// Evaluate 根据当前轮次的审核意见给出结论，尚无结论时返回空操作
+//
+// 一票否决时任一驳回即驳回，否则达到通过票数即通过，表决规则不起作用。
+// 驳回计票时先收集 quorum 张有效票：全体一致要求没有驳回票，出现驳回票即驳回；
+// 多数决收齐 quorum 张有效票后即形成结论：通过票过半时通过，驳回票过半时驳回，平票按 TieBreak 处理。
+func (p Policy) Evaluate(t Tally) Action {
+	if p.Rejection == RejectionVeto {
+		switch {
+		case t.Rejections > 0:
+			return ActionReject
+		case t.Approvals >= p.RequiredApprovals:
+			return ActionApprove
+		}
+		return ""
+	}
+
+	if t.Approvals+t.Rejections < p.quorum() {
+		return ""
+	}
+	switch p.Rule {
+	case RuleUnanimity:
+		if t.Rejections > 0 {
+			return ActionReject
+		}
+		if t.Approvals >= p.RequiredApprovals {
+			return ActionApprove
+		}
+	case RuleMajority:
+		switch {
+		case t.Approvals > t.Rejections:
+			return ActionApprove
+		case t.Rejections > t.Approvals:
+			return ActionReject
+		default:
+			switch p.TieBreak {
+			case TieApprove:
+				return ActionApprove
+			case TieReject:
+				return ActionReject
+			default:
+				return ActionEscalate
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	veto := DefaultPolicy
+	majority := Policy{RequiredApprovals: 3, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieEscalate}
+	majorityFour := Policy{RequiredApprovals: 2, Quorum: 4, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieEscalate}
+	tieEscalate := Policy{RequiredApprovals: 2, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieEscalate}
+	tieApprove := Policy{RequiredApprovals: 2, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieApprove}
+	tieReject := Policy{RequiredApprovals: 2, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieReject}
+	unanimity := Policy{RequiredApprovals: 2, Quorum: 3, Rejection: RejectionVote, Rule: RuleUnanimity, TieBreak: TieEscalate}
+
+	cases := []struct {
+		name   string
+		policy Policy
+		tally  Tally
+		want   Action
+	}{
+		{"一票否决：无意见", veto, Tally{}, ""},
+		{"一票否决：通过票不足", veto, Tally{Approvals: 2}, ""},
+		{"一票否决：达到通过票数", veto, Tally{Approvals: 3}, ActionApprove},
+		{"一票否决：任一驳回", veto, Tally{Approvals: 2, Rejections: 1}, ActionReject},
+
+		{"多数决：部分表决", majority, Tally{Approvals: 2}, ""},
+		{"多数决：部分表决含驳回", majority, Tally{Approvals: 1, Rejections: 1}, ""},
+		{"多数决：2 比 1 通过", majority, Tally{Approvals: 2, Rejections: 1}, ActionApprove},
+		{"多数决：1 比 2 驳回", majority, Tally{Approvals: 1, Rejections: 2}, ActionReject},
+		{"多数决：全票通过", majority, Tally{Approvals: 3}, ActionApprove},
+		{"多数决：全票驳回", majority, Tally{Rejections: 3}, ActionReject},
+		{"多数决：四人中的三票", majorityFour, Tally{Approvals: 2, Rejections: 1}, ""},
+		{"多数决：四人 3 比 1", majorityFour, Tally{Approvals: 3, Rejections: 1}, ActionApprove},
+		{"多数决：四人 2 比 2 提交裁决", majorityFour, Tally{Approvals: 2, Rejections: 2}, ActionEscalate},
+		{"多数决：1 比 1 提交裁决", tieEscalate, Tally{Approvals: 1, Rejections: 1}, ActionEscalate},
+		{"多数决：1 比 1 视为通过", tieApprove, Tally{Approvals: 1, Rejections: 1}, ActionApprove},
+		{"多数决：1 比 1 视为驳回", tieReject, Tally{Approvals: 1, Rejections: 1}, ActionReject},
+		{"多数决：两人中的一票", tieApprove, Tally{Approvals: 1}, ""},
+
+		{"全体一致：部分表决", unanimity, Tally{Approvals: 2}, ""},
+		{"全体一致：收齐后通过", unanimity, Tally{Approvals: 3}, ActionApprove},
+		{"全体一致：收齐后有驳回", unanimity, Tally{Approvals: 2, Rejections: 1}, ActionReject},
+	}
+	for _, c := range cases {
+		if got := c.policy.Evaluate(c.tally); got != c.want {
+			t.Errorf("%s: Evaluate(%+v) = %q, want %q", c.name, c.tally, got, c.want)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := []Policy{
+		DefaultPolicy,
+		{RequiredApprovals: 1, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieApprove},
+		{RequiredApprovals: 2, Quorum: 5, Rejection: RejectionVote, Rule: RuleUnanimity, TieBreak: TieReject},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", p, err)
+		}
+	}
+
+	invalid := []Policy{
+		{RequiredApprovals: 0, Rejection: RejectionVeto, Rule: RuleUnanimity, TieBreak: TieEscalate},
+		{RequiredApprovals: 3, Quorum: 2, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: TieEscalate},
+		{RequiredApprovals: 1, Rejection: "maybe", Rule: RuleUnanimity, TieBreak: TieEscalate},
+		{RequiredApprovals: 1, Rejection: RejectionVote, Rule: "plurality", TieBreak: TieEscalate},
+		{RequiredApprovals: 1, Rejection: RejectionVote, Rule: RuleMajority, TieBreak: "coin"},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidPolicy", p, err)
+		}
+	}
+}
+
+func TestPolicyPanel(t *testing.T) {
+	if got := DefaultPolicy.Panel(); got != 3 {
+		t.Errorf("DefaultPolicy.Panel() = %d, want 3", got)
+	}
+	p := Policy{RequiredApprovals: 2, Quorum: 5}
+	if got := p.Panel(); got != 5 {
+		t.Errorf("Panel() = %d, want 5", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Package workflow 定义材料审核的状态机，所有状态变更都必须经过 Next 校验
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// State 材料状态，直接存储在 materials.status 列
+type State string
+
+const (
+	// Draft 草稿，学生尚未提交
+	Draft State = "draft"
+	// Submitted 已提交，等待第一位审核人
+	Submitted State = "submitted"
+	// UnderReview 已有审核人给出意见，尚未形成结论
+	UnderReview State = "under_review"
+	// Escalated 审核意见平票，等待管理员裁决
+	Escalated State = "escalated"
+	// Approved 审核通过
+	Approved State = "approved"
+	// Rejected 审核驳回
+	Rejected State = "rejected"
+	// Appealed 学生对驳回提出申诉，等待受理
+	Appealed State = "appealed"
+	// ReReview 申诉受理后进入复审
+	ReReview State = "re_review"
+	// Withdrawn 学生撤回
+	Withdrawn State = "withdrawn"
+)
+
+// States 全部状态，按流程顺序排列
+var States = []State{Draft, Submitted, UnderReview, Escalated, Approved, Rejected, Appealed, ReReview, Withdrawn}
+
+// Action 触发状态变更的操作
+type Action string
+
+const (
+	// ActionSubmit 学生提交草稿或重新提交已撤回的材料
+	ActionSubmit Action = "submit"
+	// ActionStartReview 第一条审核意见写入时开始审核
+	ActionStartReview Action = "start_review"
+	// ActionApprove 审核结论为通过
+	ActionApprove Action = "approve"
+	// ActionReject 审核结论为驳回
+	ActionReject Action = "reject"
+	// ActionEscalate 审核意见平票，提交管理员裁决
+	ActionEscalate Action = "escalate"
+	// ActionAppeal 学生对驳回提出申诉
+	ActionAppeal Action = "appeal"
+	// ActionReopen 申诉成立，材料重新进入审核
+	ActionReopen Action = "reopen"
+	// ActionUphold 申诉不成立，维持驳回
+	ActionUphold Action = "uphold"
+	// ActionWithdraw 学生在结论形成前撤回
+	ActionWithdraw Action = "withdraw"
+)
+
+// transitions 状态转移表：当前状态 -> 操作 -> 目标状态
+var transitions = map[State]map[Action]State{
+	Draft: {
+		ActionSubmit:   Submitted,
+		ActionWithdraw: Withdrawn,
+	},
+	Submitted: {
+		ActionStartReview: UnderReview,
+		ActionWithdraw:    Withdrawn,
+	},
+	UnderReview: {
+		ActionApprove:  Approved,
+		ActionReject:   Rejected,
+		ActionEscalate: Escalated,
+		ActionWithdraw: Withdrawn,
+	},
+	Escalated: {
+		ActionApprove: Approved,
+		ActionReject:  Rejected,
+	},
+	Rejected: {
+		ActionAppeal: Appealed,
+	},
+	Appealed: {
+		ActionReopen: ReReview,
+		ActionUphold: Rejected,
+	},
+	ReReview: {
+		ActionApprove:  Approved,
+		ActionReject:   Rejected,
+		ActionEscalate: Escalated,
+	},
+	Withdrawn: {
+		ActionSubmit: Submitted,
+	},
+}
+
+// ErrIllegalTransition 当前状态不允许执行该操作
+var ErrIllegalTransition = errors.New("illegal material state transition")
+
+// TransitionError 描述被拒绝的状态变更
+type TransitionError struct {
+	From   State
+	Action Action
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("材料当前状态为 %s，不允许执行 %s", e.From, e.Action)
+}
+
+// Unwrap 使 errors.Is(err, ErrIllegalTransition) 成立
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// Next 返回在 from 状态执行 action 后的目标状态，不允许时返回 *TransitionError
+func Next(from State, action Action) (State, error) {
+	to, ok := transitions[from][action]
+	if !ok {
+		return "", &TransitionError{From: from, Action: action}
+	}
+	return to, nil
+}
+
+// Can 判断 from 状态是否允许执行 action
+func Can(from State, action Action) bool {
+	_, ok := transitions[from][action]
+	return ok
+}
+
+// NewRound 判断该变更是否开始新一轮审核：申诉受理后复审，或撤回后重新提交
+// 新一轮只统计本轮的审核意见，审核人可以重新给出结论
+func NewRound(from State, action Action) bool {
+	return action == ActionReopen || (from == Withdrawn && action == ActionSubmit)
+}
+
+// Valid 判断是否为已定义的状态
+func (s State) Valid() bool {
+	for _, st := range States {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// InReview 判断材料是否处于可以写入审核意见的状态
+func (s State) InReview() bool {
+	return s == Submitted || s == UnderReview || s == ReReview
+}
+
+// Editable 判断上传者能否修改材料内容：尚未提交、审核中或已撤回
+// 等待裁决、已形成结论或申诉中的材料内容保持不变
+func (s State) Editable() bool {
+	return s == Draft || s == Withdrawn || s.InReview()
+}
+
+// Final 判断材料是否已形成审核结论
+func (s State) Final() bool {
+	return s == Approved || s == Rejected
+}
+
+// PublicStatus 返回前端使用的三态状态：审核流程中的状态统一显示为 pending
+func (s State) PublicStatus() string {
+	switch s {
+	case Submitted, UnderReview, Escalated, Appealed, ReReview:
+		return "pending"
+	default:
+		return string(s)
+	}
+}
+
+// Decision 单个审核人的审核意见
+type Decision string
+
+const (
+	DecisionApprove Decision = "approve"
+	DecisionReject  Decision = "reject"
+	// DecisionComment 仅留言，不计入结论
+	DecisionComment Decision = "comment"
+)
+
+// ParseDecision 解析审核请求中的 status 字段，兼容前端的 approved / rejected
+func ParseDecision(s string) (Decision, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "approve", "approved", "pass":
+		return DecisionApprove, nil
+	case "reject", "rejected":
+		return DecisionReject, nil
+	case "comment", "":
+		return DecisionComment, nil
+	}
+	return "", fmt.Errorf("未知的审核意见: %q", s)
+}
+
+// Tally 当前轮次的审核意见统计
+type Tally struct {
+	Approvals  int
+	Rejections int
+}